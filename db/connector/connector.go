@@ -2,33 +2,48 @@ package connector
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"sync"
 
+	"github.com/jmoiron/sqlx"
+
 	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/dialect"
+	"github.com/imperiuse/golib/db/hook"
 	"github.com/imperiuse/golib/db/repo"
 	"github.com/imperiuse/golib/db/repo/empty"
+	"github.com/imperiuse/golib/db/stmtcache"
+	"github.com/imperiuse/golib/db/transaction"
+	"github.com/imperiuse/golib/reflect/orm"
 )
 
 type connector[C db.Config] struct {
 	cfg    C
 	logger db.Logger
 
-	dbConn db.PureSqlxConnection
-	phf    db.PlaceholderFormat
+	mConn   sync.RWMutex
+	dbConn  db.PureSqlxConnection
+	phf     db.PlaceholderFormat
+	dialect db.Dialect
+	hooks   *hook.Chain
 
 	// Special features for checking Repo names, caching and so on...
 	mV                sync.RWMutex
 	validationRepoMap map[db.Table]any
 	mC                sync.Mutex
 	cacheRepoMap      map[db.Table]db.Repository
+	registry          db.Registry
 }
 
 func New[C db.Config](cfg C, logger db.Logger, dbConn db.PureSqlxConnection) db.Connector[C] {
 	return &connector[C]{
-		cfg:    cfg,
-		logger: logger,
-		dbConn: dbConn,
-		phf:    cfg.PlaceholderFormat(),
+		cfg:     cfg,
+		logger:  logger,
+		dbConn:  dbConn,
+		phf:     dialect.PlaceholderFormat(cfg),
+		dialect: dialect.Of(cfg),
+		hooks:   hook.NewChain(),
 
 		mV:                sync.RWMutex{},
 		validationRepoMap: map[db.Table]any{},
@@ -37,6 +52,17 @@ func New[C db.Config](cfg C, logger db.Logger, dbConn db.PureSqlxConnection) db.
 	}
 }
 
+// NewWithStmtCache - the same as New, but all queries of repositories are sent through LRU cache of prepared
+// statements (size statements, stmtcache.DefaultSize if size <= 0). Returned *stmtcache.Conn gives access to
+// Stats() and Invalidate(), cache is invalidated and wraps new connection on Reconnect of connector.
+func NewWithStmtCache[C db.Config](
+	cfg C, logger db.Logger, dbConn db.PureSqlxConnection, size int,
+) (db.Connector[C], *stmtcache.Conn) {
+	conn := stmtcache.New(dbConn, size)
+
+	return New(cfg, logger, conn), conn
+}
+
 // AddAllowsRepos - store information about available repo names
 func (c *connector[C]) AddAllowsRepos(repos ...db.Table) {
 	c.mV.Lock()
@@ -82,22 +108,52 @@ func (c *connector[C]) Logger() db.Logger {
 
 // Connection - return pure sqlx connection
 func (c *connector[C]) Connection() db.PureSqlxConnection {
+	c.mConn.RLock()
+	defer c.mConn.RUnlock()
+
 	return c.dbConn
 }
 
-// Repo - return db.Repository based on dto.Name() method
+// Reconnect - replace connection of connector (e.g. in storage.OnReconnect), cached classic repositories and
+// generic repositories of Registry are dropped and recreated with new connection on next usage
+// (repositories kept by caller use old connection), registered DTO are kept. If connector was created by
+// NewWithStmtCache, cached statements are closed and the same cache is used for new connection.
+func (c *connector[C]) Reconnect(dbConn db.PureSqlxConnection) {
+	c.mConn.Lock()
+	if cache, ok := c.dbConn.(*stmtcache.Conn); ok {
+		if _, cached := dbConn.(*stmtcache.Conn); cached {
+			cache.Invalidate()
+		} else {
+			cache.Reconnect(dbConn)
+			dbConn = cache
+		}
+	}
+	c.dbConn = dbConn
+	c.mConn.Unlock()
+
+	c.mC.Lock()
+	c.cacheRepoMap = map[db.Table]db.Repository{}
+	c.mC.Unlock()
+
+	c.registry.Reset()
+}
+
+// Repo - return db.Repository based on dto.Name() method, primary key columns are taken from dto (orm_pk tags),
+// if dto has orm_tenant column, all queries of repository are scoped by tenant of context (see package db/tenant)
 // if cfg.IsEnableValidationRepoNames() == true =>  do validation action too)
 // if cfg.IsEnableReposCache() == true => use cache.
 func (c *connector[C]) Repo(dto db.DTO) db.Repository {
-	var repoName = dto.Repo()
-
-	return c.RepoByName(repoName)
+	return c.repo(dto.Repo(), orm.GetPrimaryKeyColumns(dto), orm.GetTenantColumn(dto))
 }
 
-// RepoByName - return db.Repository based repoName
+// RepoByName - return db.Repository based repoName (primary key - `id` column, not scoped by tenant)
 // if cfg.IsEnableValidationRepoNames() == true =>  do validation action too)
 // if cfg.IsEnableReposCache() == true => use cache.
 func (c *connector[C]) RepoByName(repoName db.Table) db.Repository {
+	return c.repo(repoName, []db.Column{orm.DefaultPrimaryKey}, "")
+}
+
+func (c *connector[C]) repo(repoName db.Table, pk []db.Column, tenant db.Column) db.Repository {
 	if c.cfg.IsEnableValidationRepoNames() {
 		c.mV.RLock()
 		defer c.mV.RUnlock()
@@ -108,21 +164,53 @@ func (c *connector[C]) RepoByName(repoName db.Table) db.Repository {
 	}
 
 	if c.cfg.IsEnableReposCache() {
+		key := repoName
+		if len(pk) != 1 || pk[0] != orm.DefaultPrimaryKey {
+			key = fmt.Sprintf("%s(%s)", repoName, strings.Join(pk, ","))
+		}
+
+		if tenant != "" {
+			key = fmt.Sprintf("%s[%s]", key, tenant)
+		}
+
 		c.mC.Lock()
 		defer c.mC.Unlock()
 
-		r, found := c.cacheRepoMap[repoName]
+		r, found := c.cacheRepoMap[key]
 		if found {
 			return r
 		}
 
-		r = repo.New(c.logger, c.dbConn, repoName, c.phf)
-		c.cacheRepoMap[repoName] = r
+		r = c.newRepo(repoName, pk, tenant)
+		c.cacheRepoMap[key] = r
 
 		return r
 	}
 
-	return repo.New(c.logger, c.dbConn, repoName, c.phf)
+	return c.newRepo(repoName, pk, tenant)
+}
+
+func (c *connector[C]) newRepo(repoName db.Table, pk []db.Column, tenant db.Column) db.Repository {
+	return repo.NewWithDialect(c.logger, c.Connection(), repoName, c.phf, c.dialect).
+		WithQueryHook(c.hooks).
+		WithPrimaryKey(pk...).
+		WithTenantColumn(tenant)
+}
+
+// Registry - cached generic repositories of connector (see repo.For)
+func (c *connector[C]) Registry() *db.Registry {
+	return &c.registry
+}
+
+// AddQueryHooks - add hooks to chain of hooks of connector, hooks are called around every query of all repositories
+// created by connector (including repo.NewGen and repo.Join), even created before adding.
+func (c *connector[C]) AddQueryHooks(hooks ...db.QueryHook) {
+	c.hooks.Add(hooks...)
+}
+
+// QueryHook - chain of all added hooks
+func (c *connector[C]) QueryHook() db.QueryHook {
+	return c.hooks
 }
 
 // AutoCreate - wrapper for c.Repo(dto).Create(ctx, dto)
@@ -140,7 +228,25 @@ func (c *connector[C]) AutoUpdate(ctx context.Context, dto db.DTO) (int64, error
 	return c.Repo(dto).Update(ctx, dto.Identity(), dto)
 }
 
-// AutoDelete - wrapper for c.Repo(dto).Delete(ctx, dto.Identity())
+// AutoDelete - wrapper for c.Repo(dto).Delete(ctx, dto.Identity()), if dto implements db.BeforeDeleteHook,
+// the hook (on passed dto, row is not read) and delete are executed in one transaction.
 func (c *connector[C]) AutoDelete(ctx context.Context, dto db.DTO) (int64, error) {
-	return c.Repo(dto).Delete(ctx, dto.Identity())
+	h, ok := dto.(db.BeforeDeleteHook)
+	if !ok {
+		return c.Repo(dto).Delete(ctx, dto.Identity())
+	}
+
+	var n int64 = repo.RowsAffectedUnknown
+
+	err := transaction.InTransaction(ctx, c.Connection(), func(ctx context.Context, _ *sqlx.Tx) (err error) {
+		if err = h.BeforeDelete(ctx); err != nil {
+			return fmt.Errorf("[connector.AutoDelete] BeforeDelete: %w", err)
+		}
+
+		n, err = c.Repo(dto).Delete(ctx, dto.Identity())
+
+		return err
+	})
+
+	return n, err
 }
@@ -3,6 +3,7 @@ package connector
 import (
 	"context"
 	"database/sql"
+	"reflect"
 	"testing"
 
 	"go.uber.org/zap"
@@ -14,6 +15,7 @@ import (
 	"github.com/imperiuse/golib/db/example/simple/config"
 	"github.com/imperiuse/golib/db/example/simple/dto"
 	"github.com/imperiuse/golib/db/mocks"
+	"github.com/imperiuse/golib/db/repo"
 	"github.com/imperiuse/golib/db/repo/empty"
 )
 
@@ -28,6 +30,33 @@ func TestConnector_New(t *testing.T) {
 	assert.Nil(t, c.Connection())
 }
 
+func TestConnector_Reconnect(t *testing.T) {
+	cfg := config.New(squirrel.Dollar, false, true)
+	c := New[config.SimpleTestConfig](cfg, zap.NewNop(), mocks.BadMockDBConn)
+
+	r := c.Repo(dto.User[dto.ID]{})
+	assert.Same(t, r, c.Repo(dto.User[dto.ID]{})) // cached
+
+	users := repo.Register[dto.ID, dto.User[dto.ID]](c)
+	assert.Same(t, users, repo.For[dto.ID, dto.User[dto.ID]](c))
+
+	c.(db.Reconnector).Reconnect(mocks.GoodMockDBConn)
+	assert.Equal(t, mocks.GoodMockDBConn, c.Connection())
+
+	assert.NotSame(t, r, c.Repo(dto.User[dto.ID]{}))
+	assert.NotSame(t, users, repo.For[dto.ID, dto.User[dto.ID]](c))
+	assert.Equal(t, []reflect.Type{reflect.TypeOf(dto.User[dto.ID]{})}, repo.Registered(c))
+}
+
+func TestConnector_ReconnectWithStmtCache(t *testing.T) {
+	cfg := config.New(squirrel.Dollar, false, false)
+	c, conn := NewWithStmtCache[config.SimpleTestConfig](cfg, zap.NewNop(), mocks.BadMockDBConn, 0)
+
+	c.(db.Reconnector).Reconnect(mocks.GoodMockDBConn)
+	assert.Same(t, conn, c.Connection()) // the same cache wraps new connection
+	assert.Equal(t, mocks.GoodMockDBConn, conn.Connection())
+}
+
 func TestConnector_All(t *testing.T) {
 	ctx := context.Background()
 
@@ -3,6 +3,8 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
+
 	"go.uber.org/zap"
 
 	"github.com/jmoiron/sqlx"
@@ -22,6 +24,9 @@ type (
 	// SelectBuilder - squirrel.SelectBuilder
 	SelectBuilder = squirrel.SelectBuilder
 
+	// InsertBuilder - squirrel.InsertBuilder
+	InsertBuilder = squirrel.InsertBuilder
+
 	// PlaceholderFormat - squirrel.PlaceholderFormat
 	PlaceholderFormat = squirrel.PlaceholderFormat
 
@@ -49,7 +54,25 @@ type (
 	Config = interface {
 		IsEnableValidationRepoNames() bool
 		IsEnableReposCache() bool
-		PlaceholderFormat() PlaceholderFormat // squirrel int code for placeholder
+		PlaceholderFormat() PlaceholderFormat // squirrel int code for placeholder, nil -> placeholder of dialect
+	}
+
+	// DialectConfig - optional interface of Config, Config without it (or nil dialect) -> Postgres (see db/dialect)
+	DialectConfig interface {
+		Dialect() Dialect
+	}
+
+	// Dialect - differences of sql dialects which matter for repositories
+	Dialect interface {
+		Name() string // postgres, mysql, sqlite (the same names as orm.Dialect)
+
+		PlaceholderFormat() PlaceholderFormat
+		SupportsReturning() bool  // true -> INSERT ... RETURNING id, false -> sql.Result.LastInsertId()
+		SupportsSkipLocked() bool // true -> SELECT ... FOR UPDATE SKIP LOCKED, false -> no row locks (SQLite)
+		QuoteIdent(string) string
+
+		// Upsert - add upsert part to insert builder, empty update columns -> do nothing on conflict
+		Upsert(b InsertBuilder, conflict []Column, update []Column) InsertBuilder
 	}
 
 	GDTO[I ID] interface {
@@ -62,6 +85,52 @@ type (
 		Identity() ID
 	}
 
+	// Lifecycle hooks of DTO (optional, usually implemented by pointer receiver to change DTO), they are called by
+	// repositories inside transaction of write (see transaction.TxFromContext), error of hook aborts operation.
+
+	BeforeCreateHook interface {
+		BeforeCreate(context.Context) error // before insert, changes of DTO are inserted
+	}
+
+	AfterCreateHook interface {
+		AfterCreate(context.Context) error // after insert, pk of DTO is set (not composite pk)
+	}
+
+	BeforeUpdateHook interface {
+		BeforeUpdate(context.Context) error // before Update (not UpdateFields), changes of DTO are saved
+	}
+
+	AfterGetHook interface {
+		AfterGet(context.Context) error // after every read row: Get, FindBy, Select*, Iterate, Stream, preload
+	}
+
+	BeforeDeleteHook interface {
+		// BeforeDelete - before Delete of generic repository (DTO is read by id before) and connector.AutoDelete
+		// (passed DTO), not called by Delete of classic repository (it has id only) and UpdateCustom/raw queries.
+		BeforeDelete(context.Context) error
+	}
+
+	// QueryEvent - information about one repository query for QueryHook
+	QueryEvent struct {
+		Repo   Table  // name of table/repo
+		Method string // repository method, e.g. Create, FindBy, Iterate
+		Obj    Obj    // DTO of Create/Update methods, nil for others
+		Query  Query
+		Args   []Argument
+
+		Start    time.Time
+		Duration time.Duration // filled before After call
+		Rows     int64         // affected (exec) or fetched (select) rows, RowsUnknown if unknown, filled before After call
+		Err      error         // filled before After call
+	}
+
+	// QueryHook - interceptor of repository queries (logging, metrics, tracing),
+	// Before can return enriched context (e.g. with tracing span), the same context is passed to After.
+	QueryHook interface {
+		Before(context.Context, *QueryEvent) context.Context
+		After(context.Context, *QueryEvent)
+	}
+
 	PureSqlxConnection interface {
 		sqlx.QueryerContext
 		sqlx.ExecerContext
@@ -102,14 +171,19 @@ type (
 		Repo(DTO) Repository // TODO think about -> optional.Optional[Repository]
 		// TODO when Go in next versions will support generics in methods
 		// Repo[I ID, D DTO]() gRepository[I, D] // refactor to this NOW try use this ->
-		// repository.NewGen[I, DTO]](connector) -> return GRepository
+		// repo.For[I, DTO](connector) -> return cached GRepository (see repo.Register)
 
 		RepoByName(Table) Repository
 
+		Registry() *Registry // cached generic repositories of connector (see repo.For)
+
 		AutoCreate(context.Context, DTO) (int64, error)
 		AutoGet(context.Context, DTO) error
 		AutoUpdate(context.Context, DTO) (int64, error)
 		AutoDelete(context.Context, DTO) (int64, error)
+
+		AddQueryHooks(...QueryHook) // hooks are applied to all repositories of connector, even created before adding
+		QueryHook() QueryHook       // chain of all added hooks
 	}
 
 	// BaseRepositoryI - base method for all type Repo's
@@ -122,16 +196,19 @@ type (
 		Insert(context.Context, []Column, []Argument) (int64, error)
 		UpdateCustom(context.Context, map[string]any, Condition) (int64, error)
 
-		// TODO Upsert(context.Context, ID, any, []Column, []Argument, strategy[on_duplicate/nothing])(error)
+		// Upsert - insert or update on conflict by conflict columns, empty update columns -> do nothing on conflict
+		Upsert(ctx context.Context, cols []Column, args []Argument, conflict []Column, update []Column) (int64, error)
 	}
 
 	// Repository - methods for classic Repo's (non generics)
 	Repository interface {
 		BaseRepositoryI
 
-		Create(context.Context, any) (int64, error) // todo add one method for ID = string or move to generics API only
+		Create(context.Context, any) (int64, error)     // 0 for not integer pk (uuid, string), use CreateAndGetID
+		CreateAndGetID(context.Context, any, any) error // id of created row is set to last arg (pointer of any type)
 		Get(context.Context, ID, any) error
 		Update(context.Context, ID, any) (int64, error)
+		UpdateFields(context.Context, ID, any, ...Column) (int64, error) // map of columns or DTO + fields, see orm.Diff
 		Delete(context.Context, ID) (int64, error)
 
 		FindBy(context.Context, []Column, Condition, any) error
@@ -152,14 +229,30 @@ type (
 		Create(context.Context, D) (I, error)
 		Get(context.Context, I) (D, error)
 		Update(context.Context, I, D) (int64, error)
+		UpdateFields(context.Context, I, any, ...Column) (int64, error) // map of columns or DTO + fields, see orm.Diff
 		Delete(context.Context, I) (int64, error)
 
-		FindBy(context.Context, []Column, Condition) ([]D, error)
+		FindBy(context.Context, []Column, Condition, ...SelectOption) ([]D, error)
 		FindOneBy(context.Context, []Column, Condition) (D, error)
 
-		Select(context.Context, SelectBuilder) ([]D, error)
+		Select(context.Context, SelectBuilder, ...SelectOption) ([]D, error)
 		SelectWithPagePagination(context.Context, SelectBuilder, PagePaginationParams) ([]D, PagePaginationResults, error)
 		SelectWithCursorOnPKPagination(context.Context, SelectBuilder, CursorPaginationParams) ([]D, error)
+
+		Iterate(context.Context, SelectBuilder, func(D) error) error
+		IterateWithServerCursor(context.Context, SelectBuilder, uint64, func(D) error) error
+		Stream(context.Context, SelectBuilder) (<-chan D, <-chan error)
+	}
+
+	// JoinRepository - methods for generic join queries, query is built from composite DTO tags only
+	// (see orm.GetJoinParts), results are scanned into nested structs of composite DTO
+	JoinRepository[D any] interface {
+		Name() Table // Name of root (FROM) table
+
+		FindBy(context.Context, Condition) ([]D, error)
+		FindOneBy(context.Context, Condition) (D, error)
+
+		Select(context.Context, SelectBuilder) ([]D, error) // DTO columns, FROM and JOIN parts are added to builder
 	}
 )
 
@@ -176,16 +269,56 @@ type (
 	}
 
 	CursorPaginationParams struct {
-		Limit     uint64
-		Cursor    uint64
-		DescOrder bool
+		Limit       uint64
+		Cursor      uint64
+		CursorValue any // cursor for not integer pk (string, uuid), used instead of Cursor if not nil
+		DescOrder   bool
 	}
+
+	// SelectOptions - options of GRepository.FindBy and GRepository.Select
+	SelectOptions struct {
+		Preload []string // relation fields of DTO which are loaded after select, see Preload
+	}
+
+	SelectOption func(*SelectOptions)
 )
 
+// Preload - load relation fields of DTO (orm_belongs_to, orm_has_many tags, see orm.GetRelations) after select,
+// one batched `WHERE key IN (...)` query per relation.
+func Preload(fields ...string) SelectOption {
+	return func(o *SelectOptions) {
+		o.Preload = append(o.Preload, fields...)
+	}
+}
+
+// NewSelectOptions - SelectOptions with applied options.
+func NewSelectOptions(opts ...SelectOption) SelectOptions {
+	o := SelectOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// RowsUnknown - QueryEvent.Rows value if count of rows is unknown (e.g. GetRowsByQuery).
+const RowsUnknown = -1
+
 var (
 	ErrInvalidRepoEmptyRepo = errors.New("invalid repo (empty repo). Not registered?" +
 		" Check this usage connector.AddAllowsRepos(repos ...db.Table)")
 	ErrMismatchRowsCnt = errors.New("mismatch rows counts")
 	ErrZeroPageSize    = errors.New("zero value of params.PageSize")
 	ErrZeroLimitSize   = errors.New("zero value of params.Limit")
+	ErrZeroFetchSize   = errors.New("zero value of fetchSize")
+	ErrUnsupportedID   = errors.New("unsupported type of ID for LastInsertId, only integer and string types allowed")
+	ErrInvalidPK       = errors.New("invalid id for composite primary key, []any or map[string]any of all pk columns expected")
+	ErrCompositePK     = errors.New("not supported for composite primary key")
+	ErrNotCompositeDTO = errors.New("not composite DTO, at least two struct fields with orm_table_name and orm_alias expected")
+	ErrStopIteration   = errors.New("stop iteration") // return it from Iterate callback for break loop without error
+	ErrUnknownRelation = errors.New("unknown relation, field with orm_belongs_to or orm_has_many tag expected")
+	ErrNoTenant        = errors.New("tenant is not set in context, DTO of repository has orm_tenant column")
+	ErrTenantUpsert    = errors.New("upsert with update is not supported for tenant scoped repository by dialect")
+	ErrTenantUsing     = errors.New("outer join with USING of tenant scoped table, use ON condition in orm_join")
+	ErrNotSupported    = errors.New("not supported by repository of connector")
 )
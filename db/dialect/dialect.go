@@ -0,0 +1,127 @@
+// Package dialect - realizations of db.Dialect for Postgres, MySQL and SQLite.
+package dialect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/imperiuse/golib/db"
+)
+
+type (
+	postgres struct{}
+	mysql    struct{}
+	sqlite   struct{}
+)
+
+var (
+	Postgres db.Dialect = postgres{}
+	MySQL    db.Dialect = mysql{}
+	SQLite   db.Dialect = sqlite{}
+
+	Default = Postgres
+)
+
+// Of - return dialect of config (see db.DialectConfig), if config has not dialect or it is not set return Default.
+func Of(cfg db.Config) db.Dialect {
+	if dc, ok := cfg.(db.DialectConfig); ok {
+		if d := dc.Dialect(); d != nil {
+			return d
+		}
+	}
+
+	return Default
+}
+
+// PlaceholderFormat - return placeholder format of config, if not set return placeholder format of config dialect.
+func PlaceholderFormat(cfg db.Config) db.PlaceholderFormat {
+	if phf := cfg.PlaceholderFormat(); phf != nil {
+		return phf
+	}
+
+	return Of(cfg).PlaceholderFormat()
+}
+
+func (postgres) Name() string                            { return "postgres" }
+func (postgres) PlaceholderFormat() db.PlaceholderFormat { return squirrel.Dollar }
+func (postgres) SupportsReturning() bool                 { return true }
+func (postgres) SupportsSkipLocked() bool                { return true }
+
+// QuoteIdent - Postgres folds unquoted identifiers to lower case, so identifier is lower cased before quoting:
+// tables created without quotes (CREATE TABLE Users) are still found by name of DTO (Users -> "users").
+func (postgres) QuoteIdent(s string) string { return quote(strings.ToLower(s), `"`) }
+
+func (d postgres) Upsert(b db.InsertBuilder, conflict []db.Column, update []db.Column) db.InsertBuilder {
+	return onConflict(d, b, conflict, update)
+}
+
+func (mysql) Name() string                            { return "mysql" }
+func (mysql) PlaceholderFormat() db.PlaceholderFormat { return squirrel.Question }
+func (mysql) SupportsReturning() bool                 { return false }
+func (mysql) SupportsSkipLocked() bool                { return true } // since MySQL 8.0
+func (mysql) QuoteIdent(s string) string              { return quote(s, "`") }
+
+func (d mysql) Upsert(b db.InsertBuilder, _ []db.Column, update []db.Column) db.InsertBuilder {
+	if len(update) == 0 {
+		return b.Options("IGNORE")
+	}
+
+	set := make([]string, 0, len(update))
+	for _, c := range update {
+		set = append(set, fmt.Sprintf("%s = VALUES(%s)", d.QuoteIdent(c), d.QuoteIdent(c)))
+	}
+
+	return b.Suffix("ON DUPLICATE KEY UPDATE " + strings.Join(set, ", "))
+}
+
+func (sqlite) Name() string                            { return "sqlite" }
+func (sqlite) PlaceholderFormat() db.PlaceholderFormat { return squirrel.Question }
+func (sqlite) SupportsReturning() bool                 { return true }  // since SQLite 3.35 (go-sqlite3 >= 1.14.7)
+func (sqlite) SupportsSkipLocked() bool                { return false } // writer locks whole database
+func (sqlite) QuoteIdent(s string) string              { return quote(s, `"`) }
+
+func (d sqlite) Upsert(b db.InsertBuilder, conflict []db.Column, update []db.Column) db.InsertBuilder {
+	return onConflict(d, b, conflict, update)
+}
+
+// onConflict - ON CONFLICT (...) DO NOTHING / DO UPDATE SET c = EXCLUDED.c (Postgres and SQLite syntax).
+func onConflict(d db.Dialect, b db.InsertBuilder, conflict []db.Column, update []db.Column) db.InsertBuilder {
+	sb := strings.Builder{}
+	sb.WriteString("ON CONFLICT")
+
+	if len(conflict) > 0 {
+		quoted := make([]string, 0, len(conflict))
+		for _, c := range conflict {
+			quoted = append(quoted, d.QuoteIdent(c))
+		}
+
+		sb.WriteString(" (" + strings.Join(quoted, ", ") + ")")
+	}
+
+	if len(update) == 0 {
+		sb.WriteString(" DO NOTHING")
+
+		return b.Suffix(sb.String())
+	}
+
+	set := make([]string, 0, len(update))
+	for _, c := range update {
+		set = append(set, fmt.Sprintf("%s = EXCLUDED.%s", d.QuoteIdent(c), d.QuoteIdent(c)))
+	}
+
+	sb.WriteString(" DO UPDATE SET " + strings.Join(set, ", "))
+
+	return b.Suffix(sb.String())
+}
+
+// quote - quote identifier, dot separated parts (schema.table) are quoted separately, quote char inside is doubled.
+func quote(s string, q string) string {
+	parts := strings.Split(s, ".")
+	for i, p := range parts {
+		parts[i] = q + strings.ReplaceAll(p, q, q+q) + q
+	}
+
+	return strings.Join(parts, ".")
+}
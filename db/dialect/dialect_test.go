@@ -0,0 +1,108 @@
+package dialect
+
+import (
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/example/simple/config"
+)
+
+type configWithoutDialect struct{}
+
+func (configWithoutDialect) IsEnableValidationRepoNames() bool       { return false }
+func (configWithoutDialect) IsEnableReposCache() bool                { return false }
+func (configWithoutDialect) PlaceholderFormat() db.PlaceholderFormat { return nil }
+
+func Test_Of(t *testing.T) {
+	assert.Equal(t, Default, Of(configWithoutDialect{}))
+	assert.Equal(t, squirrel.Dollar, PlaceholderFormat(configWithoutDialect{}))
+
+	cfg := config.New(nil, false, false)
+	assert.Equal(t, Default, Of(cfg))
+	assert.Equal(t, squirrel.Dollar, PlaceholderFormat(cfg))
+
+	cfg = cfg.WithDialect(SQLite)
+	assert.Equal(t, SQLite, Of(cfg))
+	assert.Equal(t, squirrel.Question, PlaceholderFormat(cfg))
+
+	cfg = config.New(squirrel.AtP, false, false).WithDialect(MySQL)
+	assert.Equal(t, MySQL, Of(cfg))
+	assert.Equal(t, squirrel.AtP, PlaceholderFormat(cfg))
+}
+
+func Test_SupportsSkipLocked(t *testing.T) {
+	assert.True(t, Postgres.SupportsSkipLocked())
+	assert.True(t, MySQL.SupportsSkipLocked())
+	assert.False(t, SQLite.SupportsSkipLocked()) // no row locks
+}
+
+func Test_QuoteIdent(t *testing.T) {
+	assert.Equal(t, `"users"`, Postgres.QuoteIdent("users"))
+	assert.Equal(t, `"public"."users"`, Postgres.QuoteIdent("public.users"))
+	assert.Equal(t, `"users"`, Postgres.QuoteIdent("Users"))
+	assert.Equal(t, `"Users"`, SQLite.QuoteIdent("Users"))
+	assert.Equal(t, "`Users`", MySQL.QuoteIdent("Users"))
+	assert.Equal(t, `"we""ird"`, SQLite.QuoteIdent(`we"ird`))
+	assert.Equal(t, "`users`", MySQL.QuoteIdent("users"))
+	assert.Equal(t, "`we``ird`", MySQL.QuoteIdent("we`ird"))
+}
+
+func Test_Upsert(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dialect  db.Dialect
+		conflict []string
+		update   []string
+		expected string
+	}{
+		{
+			name:     "postgres do update",
+			dialect:  Postgres,
+			conflict: []string{"email"},
+			update:   []string{"name", "n"},
+			expected: `INSERT INTO users (email,name,n) VALUES ($1,$2,$3) ` +
+				`ON CONFLICT ("email") DO UPDATE SET "name" = EXCLUDED."name", "n" = EXCLUDED."n"`,
+		},
+		{
+			name:     "postgres do nothing",
+			dialect:  Postgres,
+			expected: `INSERT INTO users (email,name,n) VALUES ($1,$2,$3) ON CONFLICT DO NOTHING`,
+		},
+		{
+			name:     "sqlite do update",
+			dialect:  SQLite,
+			conflict: []string{"email"},
+			update:   []string{"name"},
+			expected: `INSERT INTO users (email,name,n) VALUES (?,?,?) ` +
+				`ON CONFLICT ("email") DO UPDATE SET "name" = EXCLUDED."name"`,
+		},
+		{
+			name:     "mysql on duplicate key update",
+			dialect:  MySQL,
+			conflict: []string{"email"},
+			update:   []string{"name"},
+			expected: "INSERT INTO users (email,name,n) VALUES (?,?,?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)",
+		},
+		{
+			name:     "mysql ignore",
+			dialect:  MySQL,
+			expected: "INSERT IGNORE INTO users (email,name,n) VALUES (?,?,?)",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			query, args, err := test.dialect.Upsert(
+				squirrel.Insert("users").Columns("email", "name", "n").Values("e", "x", 1).
+					PlaceholderFormat(test.dialect.PlaceholderFormat()),
+				test.conflict, test.update,
+			).ToSql()
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, query)
+			assert.Len(t, args, 3)
+		})
+	}
+}
@@ -0,0 +1,302 @@
+// Package drift - checker of schema drift between DTOs (db tags) and live database (information_schema.columns
+// of Postgres and MySQL, pragma_table_info of SQLite). Table and column names are compared case-insensitively.
+//
+// Usage at service startup (fail fast):
+//
+//	if _, err := drift.Check(ctx, connector, drift.Config{FailFast: true}, &dto.User[dto.ID]{}); err != nil {
+//		log.Fatal(err)
+//	}
+package drift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/dialect"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+type (
+	// Config - configuration of drift checker.
+	Config struct {
+		Schema      string      // db schema, default: DefaultSchema (Postgres), DATABASE() (MySQL), main (SQLite)
+		Dialect     orm.Dialect // dialect for derive sql types of DTO fields, default dialect of connector config
+		FailFast    bool        // stop on first table with drift
+		IgnoreExtra bool        // do not report columns which are present in db, but absent in DTO
+		IgnoreTypes bool        // do not compare column types
+	}
+
+	// TypeMismatch - DTO column type differs from db column type.
+	TypeMismatch struct {
+		Column   db.Column
+		Expected string // type of DTO field (orm_type tag or derived from go type)
+		Actual   string // information_schema.columns.data_type (declared type of column on SQLite)
+	}
+
+	// Report - drift report for one table.
+	Report struct {
+		Table          db.Table
+		TableMissing   bool
+		MissingColumns []db.Column // present in DTO, absent in db
+		ExtraColumns   []db.Column // present in db, absent in DTO
+		TypeMismatches []TypeMismatch
+	}
+
+	// Reports - drift reports for all checked tables.
+	Reports []Report
+
+	dbColumn struct {
+		Name     db.Column `db:"column_name"`
+		DataType string    `db:"data_type"`
+	}
+)
+
+// DefaultSchema - default schema of Postgres.
+const DefaultSchema = "public"
+
+var (
+	ErrSchemaDrift        = errors.New("schema drift between DTO and database")
+	ErrNoTableName        = errors.New("DTO has not table name")
+	ErrUnsupportedDialect = errors.New("drift check is not supported for dialect")
+
+	typeArgsRegexp = regexp.MustCompile(`\s*\(.*\)`)
+
+	// synonyms of types, all names are normalized to information_schema.columns.data_type names of Postgres
+	typeSynonyms = map[string]string{
+		"int":         "integer",
+		"int4":        "integer",
+		"serial":      "integer",
+		"int8":        "bigint",
+		"bigserial":   "bigint",
+		"int2":        "smallint",
+		"smallserial": "smallint",
+		"float4":      "real",
+		"float8":      "double precision",
+		"double":      "double precision",
+		"bool":        "boolean",
+		"varchar":     "character varying",
+		"char":        "character",
+		"decimal":     "numeric",
+		"timestamp":   "timestamp without time zone",
+		"timestamptz": "timestamp with time zone",
+		"time":        "time without time zone",
+		"timetz":      "time with time zone",
+	}
+)
+
+// HasDrift - is any drift in report.
+func (r Report) HasDrift() bool {
+	return r.TableMissing || len(r.MissingColumns) > 0 || len(r.ExtraColumns) > 0 || len(r.TypeMismatches) > 0
+}
+
+func (r Report) String() string {
+	if r.TableMissing {
+		return fmt.Sprintf("table %s: missing in db", r.Table)
+	}
+
+	parts := []string{}
+	if len(r.MissingColumns) > 0 {
+		parts = append(parts, "missing columns: "+strings.Join(r.MissingColumns, ", "))
+	}
+
+	if len(r.ExtraColumns) > 0 {
+		parts = append(parts, "extra columns: "+strings.Join(r.ExtraColumns, ", "))
+	}
+
+	for _, tm := range r.TypeMismatches {
+		parts = append(parts, fmt.Sprintf("column %s type: expected %s, actual %s", tm.Column, tm.Expected, tm.Actual))
+	}
+
+	if len(parts) == 0 {
+		return fmt.Sprintf("table %s: ok", r.Table)
+	}
+
+	return fmt.Sprintf("table %s: %s", r.Table, strings.Join(parts, "; "))
+}
+
+// HasDrift - is any drift in reports.
+func (rs Reports) HasDrift() bool {
+	for _, r := range rs {
+		if r.HasDrift() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rs Reports) String() string {
+	lines := make([]string, 0, len(rs))
+	for _, r := range rs {
+		if r.HasDrift() {
+			lines = append(lines, r.String())
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Check - compare DTOs columns with columns of database (dialect of connector config),
+// return reports for all checked tables and ErrSchemaDrift (wrapped with details) if any drift found.
+// In FailFast mode check stops on the first table with drift. Dialects except Postgres, MySQL and SQLite
+// are rejected with ErrUnsupportedDialect.
+func Check[C db.Config](ctx context.Context, connector db.Connector[C], cfg Config, dtos ...db.DTO) (Reports, error) {
+	d := dialect.Of(connector.Config())
+
+	if cfg.Dialect == "" {
+		cfg.Dialect = d.Name()
+	}
+
+	phf := dialect.PlaceholderFormat(connector.Config())
+
+	reports := make(Reports, 0, len(dtos))
+
+	for _, dto := range dtos {
+		table := orm.GetTableName(dto)
+		if table == "" {
+			table = dto.Repo()
+		}
+
+		if table == "" {
+			return reports, fmt.Errorf("[drift.Check] %T: %w", dto, ErrNoTableName)
+		}
+
+		defs, err := orm.GetColumnDefs(dto, cfg.Dialect)
+		if err != nil {
+			return reports, fmt.Errorf("[drift.Check] %s: %w", table, err)
+		}
+
+		query, args, err := columnsQuery(d.Name(), cfg.Schema, table, phf)
+		if err != nil {
+			return reports, fmt.Errorf("[drift.Check] %s: %w", table, err)
+		}
+
+		cols := make([]dbColumn, 0)
+		if err = sqlx.SelectContext(ctx, connector.Connection(), &cols, query, args...); err != nil {
+			return reports, fmt.Errorf("[drift.Check] %s: select columns: %w", table, err)
+		}
+
+		report := compare(table, defs, cols, cfg)
+		reports = append(reports, report)
+
+		if report.HasDrift() {
+			connector.Logger().Warn("[drift.Check]", zap.String("report", report.String()))
+
+			if cfg.FailFast {
+				return reports, fmt.Errorf("%w: %s", ErrSchemaDrift, report.String())
+			}
+		}
+	}
+
+	if reports.HasDrift() {
+		return reports, fmt.Errorf("%w:\n%s", ErrSchemaDrift, reports.String())
+	}
+
+	return reports, nil
+}
+
+// columnsQuery - query of columns (column_name, data_type) of table for dialect, table name is compared
+// case-insensitively (the same as unquoted identifiers of DDL).
+func columnsQuery(
+	dialectName string, schema string, table db.Table, phf db.PlaceholderFormat,
+) (db.Query, []db.Argument, error) {
+	switch dialectName {
+	case dialect.Postgres.Name(), dialect.MySQL.Name():
+		var schemaCond squirrel.Sqlizer = squirrel.Eq{"table_schema": schema}
+
+		switch {
+		case schema == "" && dialectName == dialect.MySQL.Name():
+			schemaCond = squirrel.Expr("table_schema = DATABASE()")
+		case schema == "":
+			schemaCond = squirrel.Eq{"table_schema": DefaultSchema}
+		}
+
+		return squirrel.Select("column_name", "data_type").
+			From("information_schema.columns").
+			Where(schemaCond).
+			Where(squirrel.Expr("lower(table_name) = ?", strings.ToLower(table))).
+			OrderBy("ordinal_position").
+			PlaceholderFormat(phf).
+			ToSql()
+	case dialect.SQLite.Name():
+		if schema == "" {
+			return "SELECT name AS column_name, type AS data_type FROM pragma_table_info(?) ORDER BY cid",
+				[]db.Argument{table}, nil
+		}
+
+		return "SELECT name AS column_name, type AS data_type FROM pragma_table_info(?, ?) ORDER BY cid",
+			[]db.Argument{table, schema}, nil
+	default:
+		return "", nil, fmt.Errorf("%s: %w", dialectName, ErrUnsupportedDialect)
+	}
+}
+
+func compare(table db.Table, defs []orm.ColumnDef, cols []dbColumn, cfg Config) Report {
+	report := Report{Table: table}
+
+	if len(cols) == 0 {
+		report.TableMissing = true
+
+		return report
+	}
+
+	actual := make(map[db.Column]string, len(cols)) // key - lower cased column name
+	for _, c := range cols {
+		actual[strings.ToLower(c.Name)] = c.DataType
+	}
+
+	expected := make(map[db.Column]bool, len(defs)) // key - lower cased column name
+	for _, d := range defs {
+		expected[strings.ToLower(d.Name)] = true
+
+		dataType, found := actual[strings.ToLower(d.Name)]
+		if !found {
+			report.MissingColumns = append(report.MissingColumns, d.Name)
+			continue
+		}
+
+		if !cfg.IgnoreTypes && NormalizeType(d.Type) != NormalizeType(dataType) {
+			report.TypeMismatches = append(report.TypeMismatches, TypeMismatch{
+				Column:   d.Name,
+				Expected: d.Type,
+				Actual:   dataType,
+			})
+		}
+	}
+
+	if !cfg.IgnoreExtra {
+		for _, c := range cols {
+			if !expected[strings.ToLower(c.Name)] {
+				report.ExtraColumns = append(report.ExtraColumns, c.Name)
+			}
+		}
+	}
+
+	sort.Strings(report.MissingColumns)
+	sort.Strings(report.ExtraColumns)
+
+	return report
+}
+
+// NormalizeType - normalize sql type name to Postgres information_schema.columns.data_type form,
+// e.g. `VARCHAR(255)` -> `character varying`, `INT` -> `integer`, `TIMESTAMP` -> `timestamp without time zone`.
+func NormalizeType(typ string) string {
+	t := strings.ToLower(strings.TrimSpace(typeArgsRegexp.ReplaceAllString(typ, "")))
+	t = strings.Join(strings.Fields(t), " ")
+
+	if s, found := typeSynonyms[t]; found {
+		return s
+	}
+
+	return t
+}
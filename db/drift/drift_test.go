@@ -0,0 +1,109 @@
+package drift
+
+import (
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/example/simple/dto"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+func Test_NormalizeType(t *testing.T) {
+	for typ, normalized := range map[string]string{
+		"INTEGER":                      "integer",
+		"int4":                         "integer",
+		"BIGSERIAL":                    "bigint",
+		"VARCHAR(255)":                 "character varying",
+		"character varying":            "character varying",
+		"TIMESTAMP":                    "timestamp without time zone",
+		"timestamp(3)  with time zone": "timestamp with time zone",
+		"TIMESTAMPTZ":                  "timestamp with time zone",
+		"NUMERIC(10, 2)":               "numeric",
+		"DOUBLE PRECISION":             "double precision",
+		"jsonb":                        "jsonb",
+	} {
+		assert.Equal(t, normalized, NormalizeType(typ), typ)
+	}
+}
+
+func Test_Compare(t *testing.T) {
+	defs, err := orm.GetColumnDefs(&dto.User[dto.ID]{}, orm.DialectPostgres)
+	assert.Nil(t, err)
+
+	cols := []dbColumn{
+		{Name: "id", DataType: "integer"},
+		{Name: "created_at", DataType: "timestamp without time zone"},
+		{Name: "updated_at", DataType: "timestamp with time zone"},
+		{Name: "name", DataType: "text"},
+		{Name: "email", DataType: "character varying"},
+		{Name: "role_id", DataType: "integer"},
+		{Name: "deleted_at", DataType: "timestamp without time zone"},
+		{Name: "avatar", DataType: "bytea"},
+	}
+
+	report := compare("Users", defs, cols, Config{})
+	assert.True(t, report.HasDrift())
+	assert.Equal(t, Report{
+		Table:          "Users",
+		MissingColumns: []string{"password"},
+		ExtraColumns:   []string{"avatar", "deleted_at"},
+		TypeMismatches: []TypeMismatch{
+			{Column: "updated_at", Expected: "TIMESTAMP", Actual: "timestamp with time zone"},
+			{Column: "email", Expected: "TEXT", Actual: "character varying"},
+		},
+	}, report)
+	assert.Equal(t, "table Users: missing columns: password; extra columns: avatar, deleted_at; "+
+		"column updated_at type: expected TIMESTAMP, actual timestamp with time zone; "+
+		"column email type: expected TEXT, actual character varying", report.String())
+
+	report = compare("Users", defs, cols, Config{IgnoreExtra: true, IgnoreTypes: true})
+	assert.Equal(t, Report{Table: "Users", MissingColumns: []string{"password"}}, report)
+
+	report = compare("Users", defs, append(cols[:4:4], dbColumn{Name: "email", DataType: "text"},
+		dbColumn{Name: "password", DataType: "text"}, dbColumn{Name: "role_id", DataType: "integer"}),
+		Config{IgnoreTypes: true})
+	assert.False(t, report.HasDrift())
+	assert.Equal(t, "table Users: ok", report.String())
+
+	report = compare("Users", defs, nil, Config{})
+	assert.True(t, report.HasDrift())
+	assert.Equal(t, "table Users: missing in db", report.String())
+
+	reports := Reports{report, {Table: "Roles"}}
+	assert.True(t, reports.HasDrift())
+	assert.Equal(t, "table Users: missing in db", reports.String())
+	assert.False(t, Reports{{Table: "Roles"}}.HasDrift())
+}
+
+func Test_ColumnsQuery(t *testing.T) {
+	query, args, err := columnsQuery("postgres", "", "Users", squirrel.Dollar)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT column_name, data_type FROM information_schema.columns "+
+		"WHERE table_schema = $1 AND lower(table_name) = $2 ORDER BY ordinal_position", query)
+	assert.Equal(t, []db.Argument{DefaultSchema, "users"}, args)
+
+	query, args, err = columnsQuery("mysql", "", "Users", squirrel.Question)
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT column_name, data_type FROM information_schema.columns "+
+		"WHERE table_schema = DATABASE() AND lower(table_name) = ? ORDER BY ordinal_position", query)
+	assert.Equal(t, []db.Argument{"users"}, args)
+
+	query, args, err = columnsQuery("sqlite", "", "Users", squirrel.Question)
+	assert.Nil(t, err)
+	assert.Contains(t, query, "FROM pragma_table_info(?)")
+	assert.Equal(t, []db.Argument{"Users"}, args)
+
+	_, _, err = columnsQuery("oracle", "", "Users", squirrel.Question)
+	assert.ErrorIs(t, err, ErrUnsupportedDialect)
+}
+
+func Test_CompareCaseInsensitive(t *testing.T) {
+	defs := []orm.ColumnDef{{Name: "id", Type: "INTEGER"}, {Name: "Name", Type: "TEXT"}}
+
+	report := compare("Users", defs, []dbColumn{{Name: "ID", DataType: "integer"}, {Name: "name", DataType: "text"}},
+		Config{})
+	assert.False(t, report.HasDrift(), report.String())
+}
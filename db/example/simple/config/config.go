@@ -7,6 +7,7 @@ import (
 // SimpleTestConfig - simple test config which implement db.Config
 type SimpleTestConfig struct {
 	phf                 db.PlaceholderFormat
+	dialect             db.Dialect
 	isEnabledValidation bool
 	isEnabledRepoCache  bool
 }
@@ -19,6 +20,16 @@ func New(phf db.PlaceholderFormat, isValidationEnable, isCacheEnable bool) Simpl
 	}
 }
 
+// WithDialect - return copy of config with sql dialect
+func (c SimpleTestConfig) WithDialect(d db.Dialect) SimpleTestConfig {
+	c.dialect = d
+	return c
+}
+
+func (c SimpleTestConfig) Dialect() db.Dialect {
+	return c.dialect
+}
+
 func (c SimpleTestConfig) PlaceholderFormat() db.PlaceholderFormat {
 	return c.phf
 }
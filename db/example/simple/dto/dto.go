@@ -1,11 +1,15 @@
 package dto
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/reflect/orm"
 )
 
+//go:generate go run github.com/imperiuse/golib/reflect/orm/cmd/ormgen -type=User,Role,Paginator
+
 // Various example of DTO's
 type (
 	ID = int
@@ -13,24 +17,24 @@ type (
 	NotDTO struct{}
 
 	BaseDTO[I db.ID] struct {
-		Id        I         `db:"id"          orm_use_in:"select"`
-		CreatedAt time.Time `db:"created_at"  orm_use_in:"select"`
-		UpdatedAt time.Time `db:"updated_at"  orm_use_in:"select,update"`
+		Id        I         `db:"id"          orm_use_in:"select"         orm_pk:"identity" orm_type:"INTEGER"`
+		CreatedAt time.Time `db:"created_at"  orm_use_in:"select"         orm_default:"CURRENT_TIMESTAMP"`
+		UpdatedAt time.Time `db:"updated_at"  orm_use_in:"select,update"  orm_default:"CURRENT_TIMESTAMP"`
 	}
 
 	User[I db.ID] struct {
 		BaseDTO[I]
 		Name     string `db:"name"     orm_use_in:"select,create,update"`
 		Email    string `db:"email"    orm_use_in:"select,create,update"`
-		Password string `db:"password" orm_use_in:"select,create,update"`
-		RoleID   I      `db:"role_id" orm_use_in:"select,create,update"`
+		Password string `db:"password" orm_use_in:"select,create,update" orm_sensitive:"true"`
+		RoleID   I      `db:"role_id"  orm_use_in:"select,create,update" orm_type:"INTEGER" orm_fk:"Roles (id) ON DELETE CASCADE"`
 		_        any    `orm_table_name:"Users" orm_alias:"u"`
 	}
 
 	Role[I db.ID] struct {
 		BaseDTO[I]
 		Name   string `db:"name"       orm_use_in:"select,create,update"`
-		Rights int    `db:"rights"     orm_use_in:"select,create,update"`
+		Rights int    `db:"rights"     orm_use_in:"select,create,update" orm_type:"INTEGER"`
 		_      any    `orm_table_name:"Roles" orm_alias:"r"`
 	}
 
@@ -44,7 +48,7 @@ type (
 	Paginator[I db.ID] struct {
 		BaseDTO[I]
 		Name string `db:"name"    orm_use_in:"select,create,update"`
-		N    int    `db:"n"       orm_use_in:"select,create,update"`
+		N    int    `db:"n"       orm_use_in:"select,create,update" orm_type:"INTEGER"`
 
 		_ any `orm_table_name:"Paginators"  orm_alias:"p"`
 	}
@@ -82,6 +86,9 @@ func (_ User[I]) Repo() db.Table {
 	return "Users"
 }
 
+// DSL - hand-written DDL of example tables (Postgres), key - table name.
+//
+// Deprecated: use DDL, it is generated from DTO tags, so it can't diverge from DTO.
 var DSL = map[string]string{
 	"Roles": `CREATE TABLE IF NOT EXISTS Roles
 (
@@ -111,3 +118,19 @@ name         TEXT        NOT NULL,
 n            INTEGER     NOT NULL
 );`,
 }
+
+// DDL - DDL of example tables (Postgres), generated from DTO tags (see orm.GetCreateTableDDL), key - table name.
+func DDL() (map[string]string, error) {
+	dsl := make(map[string]string, 3)
+
+	for _, obj := range []db.DTO{Role[ID]{}, User[ID]{}, Paginator[ID]{}} {
+		ddl, err := orm.GetCreateTableDDL(obj, orm.DialectPostgres)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", obj.Repo(), err)
+		}
+
+		dsl[obj.Repo()] = ddl
+	}
+
+	return dsl, nil
+}
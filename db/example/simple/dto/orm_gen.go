@@ -0,0 +1,82 @@
+// Code generated by ormgen; DO NOT EDIT.
+
+package dto
+
+import "github.com/imperiuse/golib/reflect/orm"
+
+// OrmGeneratedFor - see orm.Generated.
+func (User[I]) OrmGeneratedFor() any {
+	return User[I]{}
+}
+
+func (User[I]) Columns() []orm.Column {
+	return []orm.Column{"id", "created_at", "updated_at", "name", "email", "password", "role_id"}
+}
+
+func (d User[I]) CreateArgs() ([]orm.Column, []orm.Argument) {
+	return []orm.Column{"name", "email", "password", "role_id"}, []orm.Argument{d.Name, d.Email, d.Password, d.RoleID}
+}
+
+func (d User[I]) UpdateMap() map[orm.Column]orm.Argument {
+	return map[orm.Column]orm.Argument{
+		"updated_at": d.BaseDTO.UpdatedAt,
+		"name":       d.Name,
+		"email":      d.Email,
+		"password":   d.Password,
+		"role_id":    d.RoleID,
+	}
+}
+
+func (d *User[I]) ScanTargets() []any {
+	return []any{&d.BaseDTO.Id, &d.BaseDTO.CreatedAt, &d.BaseDTO.UpdatedAt, &d.Name, &d.Email, &d.Password, &d.RoleID}
+}
+
+// OrmGeneratedFor - see orm.Generated.
+func (Role[I]) OrmGeneratedFor() any {
+	return Role[I]{}
+}
+
+func (Role[I]) Columns() []orm.Column {
+	return []orm.Column{"id", "created_at", "updated_at", "name", "rights"}
+}
+
+func (d Role[I]) CreateArgs() ([]orm.Column, []orm.Argument) {
+	return []orm.Column{"name", "rights"}, []orm.Argument{d.Name, d.Rights}
+}
+
+func (d Role[I]) UpdateMap() map[orm.Column]orm.Argument {
+	return map[orm.Column]orm.Argument{
+		"updated_at": d.BaseDTO.UpdatedAt,
+		"name":       d.Name,
+		"rights":     d.Rights,
+	}
+}
+
+func (d *Role[I]) ScanTargets() []any {
+	return []any{&d.BaseDTO.Id, &d.BaseDTO.CreatedAt, &d.BaseDTO.UpdatedAt, &d.Name, &d.Rights}
+}
+
+// OrmGeneratedFor - see orm.Generated.
+func (Paginator[I]) OrmGeneratedFor() any {
+	return Paginator[I]{}
+}
+
+func (Paginator[I]) Columns() []orm.Column {
+	return []orm.Column{"id", "created_at", "updated_at", "name", "n"}
+}
+
+func (d Paginator[I]) CreateArgs() ([]orm.Column, []orm.Argument) {
+	return []orm.Column{"name", "n"}, []orm.Argument{d.Name, d.N}
+}
+
+func (d Paginator[I]) UpdateMap() map[orm.Column]orm.Argument {
+	return map[orm.Column]orm.Argument{
+		"updated_at": d.BaseDTO.UpdatedAt,
+		"name":       d.Name,
+		"n":          d.N,
+	}
+}
+
+func (d *Paginator[I]) ScanTargets() []any {
+	return []any{&d.BaseDTO.Id, &d.BaseDTO.CreatedAt, &d.BaseDTO.UpdatedAt, &d.Name, &d.N}
+}
@@ -0,0 +1,213 @@
+package fake
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/imperiuse/golib/db"
+)
+
+// Match - evaluate condition for row in memory, nil condition matches any row.
+// Supported: squirrel.Eq (slice value -> IN, nil -> IS NULL), NotEq, Gt, GtOrEq, Lt, LtOrEq, And, Or.
+// Table aliases of columns are ignored (u.id -> id).
+func Match(cond db.Condition, row Row) (bool, error) {
+	switch c := cond.(type) {
+	case nil:
+		return true, nil
+	case squirrel.Eq:
+		return matchEq(c, row, false)
+	case squirrel.NotEq:
+		return matchEq(squirrel.Eq(c), row, true)
+	case squirrel.Gt:
+		return matchCmp(c, row, func(r int) bool { return r > 0 })
+	case squirrel.GtOrEq:
+		return matchCmp(c, row, func(r int) bool { return r >= 0 })
+	case squirrel.Lt:
+		return matchCmp(c, row, func(r int) bool { return r < 0 })
+	case squirrel.LtOrEq:
+		return matchCmp(c, row, func(r int) bool { return r <= 0 })
+	case squirrel.And:
+		for _, part := range c {
+			ok, err := Match(part, row)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+
+		return true, nil
+	case squirrel.Or:
+		for _, part := range c {
+			ok, err := Match(part, row)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+
+		return len(c) == 0, nil
+	default:
+		return false, fmt.Errorf("%T: %w", cond, ErrUnsupportedCondition)
+	}
+}
+
+func matchEq(eq squirrel.Eq, row Row, not bool) (bool, error) {
+	for col, expected := range eq {
+		actual := row[columnName(col)]
+
+		ok, err := equalAny(actual, expected)
+		if err != nil {
+			return false, err
+		}
+
+		if ok == not {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// equalAny - actual == expected, or actual IN expected if expected is slice (except []byte).
+func equalAny(actual any, expected any) (bool, error) {
+	v := reflect.ValueOf(expected)
+	if expected != nil && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) &&
+		v.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < v.Len(); i++ {
+			ok, err := equalAny(actual, v.Index(i).Interface())
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+
+		return false, nil
+	}
+
+	expected, err := normalize(expected)
+	if err != nil {
+		return false, err
+	}
+
+	if actual == nil || expected == nil {
+		return actual == nil && expected == nil, nil
+	}
+
+	r, ok := compare(actual, expected)
+	if !ok {
+		return reflect.DeepEqual(actual, expected), nil
+	}
+
+	return r == 0, nil
+}
+
+func matchCmp(m map[string]any, row Row, fn func(int) bool) (bool, error) {
+	for col, expected := range m {
+		expected, err := normalize(expected)
+		if err != nil {
+			return false, err
+		}
+
+		actual := row[columnName(col)]
+		if actual == nil || expected == nil {
+			return false, nil // comparison with NULL is never true
+		}
+
+		r, ok := compare(actual, expected)
+		if !ok {
+			return false, fmt.Errorf("%s: compare %T with %T: %w", col, actual, expected, ErrUnsupportedCondition)
+		}
+
+		if !fn(r) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// compare - compare two normalized values (numbers, strings, []byte, time, bool), false if types are not comparable.
+func compare(a any, b any) (int, bool) {
+	if x, ok := a.(int64); ok {
+		if y, ok := b.(int64); ok {
+			return cmpOrdered(x, y), true
+		}
+	}
+
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			return cmpOrdered(fa, fb), true
+		}
+
+		return 0, false
+	}
+
+	switch x := a.(type) {
+	case string, []byte:
+		switch b.(type) {
+		case string, []byte:
+			return cmpOrdered(toString(x), toString(b)), true
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			switch {
+			case x.Before(y):
+				return -1, true
+			case x.After(y):
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			if x == y {
+				return 0, true
+			}
+
+			if y {
+				return -1, true
+			}
+
+			return 1, true
+		}
+	}
+
+	return 0, false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	default:
+		return 0, false
+	}
+}
+
+func toString(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case []byte:
+		return string(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func cmpOrdered[T int64 | float64 | string](a T, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
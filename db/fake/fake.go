@@ -0,0 +1,369 @@
+// Package fake - in-memory realization of db.Connector, db.Repository and db.GRepository for unit tests.
+//
+// Tables are stored in memory as ordered rows (column -> value), ids are autoincrement per table.
+// Conditions of FindBy, FindOneBy and UpdateCustom are evaluated in memory,
+// supported squirrel.Eq, NotEq, Gt, GtOrEq, Lt, LtOrEq, And and Or (see Match).
+// Methods which need raw sql (Select*, GetRowsByQuery, CountByQuery, joins, preload) return ErrNotSupported.
+// Connector implements repo.Backend, so repo.NewGen, repo.For and repo.Register of it return in-memory repositories.
+//
+// Like real repositories: pk columns are taken from orm_pk tags of DTO (Repo, NewGen; RepoByName uses id),
+// DTO is validated (validate tags), lifecycle hooks (package db/lifecycle) and query hooks of connector are called,
+// repositories of DTO with orm_tenant column are scoped by tenant of context.
+// Differences: there are no transactions (ctx of hooks has not transaction, row created before failed AfterCreate
+// is deleted), events of query hooks have not Query and Args.
+//
+// Usage:
+//
+//	c := fake.New(cfg, zap.NewNop())
+//	users := fake.NewGen[dto.ID, dto.User[dto.ID], config.Config](c) // same signature as repo.NewGen
+//	id, err := users.Create(ctx, dto.User[dto.ID]{Name: "Bob"})
+package fake
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx/reflectx"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/hook"
+	"github.com/imperiuse/golib/db/lifecycle"
+	"github.com/imperiuse/golib/db/repo"
+	"github.com/imperiuse/golib/db/repo/empty"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+const idColumn = "id"
+
+type (
+	// Row - one row of in-memory table.
+	Row = map[db.Column]any
+
+	// Connector - in-memory db.Connector.
+	Connector[C db.Config] struct {
+		cfg    C
+		logger db.Logger
+
+		mV                sync.RWMutex
+		validationRepoMap map[db.Table]any
+
+		hooks    *hook.Chain
+		store    *store
+		registry db.Registry
+	}
+
+	store struct {
+		m      sync.RWMutex
+		tables map[db.Table]*table
+	}
+
+	table struct {
+		rows   []Row
+		lastID int64
+	}
+)
+
+var (
+	ErrNotSupported         = db.ErrNotSupported
+	ErrUnsupportedCondition = errors.New("unsupported condition for fake repository")
+	ErrUnsupportedDest      = errors.New("unsupported dest, pointer to struct or to slice of structs expected")
+	ErrConvertValue         = errors.New("can't convert value to field type")
+
+	mapper = reflectx.NewMapperFunc("db", strings.ToLower) // same mapping rules as sqlx
+)
+
+// New - create in-memory connector (connection is nil, repositories are created by Repo/RepoByName, NewGen,
+// repo.NewGen and repo.For).
+func New[C db.Config](cfg C, logger db.Logger) *Connector[C] {
+	return &Connector[C]{
+		cfg:               cfg,
+		logger:            logger,
+		validationRepoMap: map[db.Table]any{},
+		hooks:             hook.NewChain(),
+		store:             &store{tables: map[db.Table]*table{}},
+	}
+}
+
+func (c *Connector[C]) Config() C {
+	return c.cfg
+}
+
+func (c *Connector[C]) Logger() db.Logger {
+	return c.logger
+}
+
+// Connection - fake connector has not real connection, always nil.
+func (c *Connector[C]) Connection() db.PureSqlxConnection {
+	return nil
+}
+
+func (c *Connector[C]) AddAllowsRepos(repos ...db.Table) {
+	c.mV.Lock()
+	defer c.mV.Unlock()
+
+	for _, v := range repos {
+		c.validationRepoMap[v] = new(any)
+	}
+}
+
+func (c *Connector[C]) GetAllowsRepos() []db.Table {
+	c.mV.RLock()
+	defer c.mV.RUnlock()
+
+	var l = make([]db.Table, 0, len(c.validationRepoMap))
+	for tableName := range c.validationRepoMap {
+		l = append(l, tableName)
+	}
+
+	return l
+}
+
+func (c *Connector[C]) IsAllowRepo(repo db.Table) bool {
+	c.mV.RLock()
+	defer c.mV.RUnlock()
+
+	_, found := c.validationRepoMap[repo]
+
+	return found
+}
+
+// Registry - cached generic repositories of connector (see repo.For).
+func (c *Connector[C]) Registry() *db.Registry {
+	return &c.registry
+}
+
+// Repo - in-memory repository with pk of DTO (orm_pk tags), scoped by tenant of context if DTO has orm_tenant
+// column (like in real connector).
+func (c *Connector[C]) Repo(dto db.DTO) db.Repository {
+	r := c.RepoByName(dto.Repo())
+	if fr, ok := r.(*repository); ok {
+		fr.pk = orm.GetPrimaryKeyColumns(dto)
+		fr.tenant = orm.GetTenantColumn(dto)
+	}
+
+	return r
+}
+
+// RepoByName - in-memory repository (pk is id, not scoped by tenant), validation of repo names works like
+// in real connector.
+func (c *Connector[C]) RepoByName(repoName db.Table) db.Repository {
+	if c.cfg.IsEnableValidationRepoNames() && !c.IsAllowRepo(repoName) {
+		return empty.Repo
+	}
+
+	return c.newRepository(repoName, []db.Column{idColumn}, "")
+}
+
+// BackendRepository - in-memory repository of DTO for generic repositories (see repo.Backend).
+func (c *Connector[C]) BackendRepository(dto db.DTO) repo.BackendRepository {
+	return c.newRepository(dto.Repo(), orm.GetPrimaryKeyColumns(dto), orm.GetTenantColumn(dto))
+}
+
+func (c *Connector[C]) newRepository(name db.Table, pk []db.Column, tenant db.Column) *repository {
+	return &repository{name: name, pk: pk, tenant: tenant, hook: c.hooks, store: c.store}
+}
+
+func (c *Connector[C]) AutoCreate(ctx context.Context, dto db.DTO) (int64, error) {
+	return c.Repo(dto).Create(ctx, dto)
+}
+
+func (c *Connector[C]) AutoGet(ctx context.Context, dto db.DTO) error {
+	return c.Repo(dto).Get(ctx, dto.Identity(), dto)
+}
+
+func (c *Connector[C]) AutoUpdate(ctx context.Context, dto db.DTO) (int64, error) {
+	return c.Repo(dto).Update(ctx, dto.Identity(), dto)
+}
+
+// AutoDelete - BeforeDelete hook of dto (if any) and delete like in real connector (without transaction).
+func (c *Connector[C]) AutoDelete(ctx context.Context, dto db.DTO) (int64, error) {
+	if err := lifecycle.BeforeDelete(ctx, dto); err != nil {
+		return repo.RowsAffectedUnknown, fmt.Errorf("[fake.AutoDelete] %w", err)
+	}
+
+	return c.Repo(dto).Delete(ctx, dto.Identity())
+}
+
+// AddQueryHooks - hooks are called around every operation of fake repositories (event has not query and args).
+func (c *Connector[C]) AddQueryHooks(hooks ...db.QueryHook) {
+	c.hooks.Add(hooks...)
+}
+
+func (c *Connector[C]) QueryHook() db.QueryHook {
+	return c.hooks
+}
+
+// Rows - copy of all rows of table (for assertions in tests).
+func (c *Connector[C]) Rows(tableName db.Table) []Row {
+	c.store.m.RLock()
+	defer c.store.m.RUnlock()
+
+	t, found := c.store.tables[tableName]
+	if !found {
+		return []Row{}
+	}
+
+	rows := make([]Row, 0, len(t.rows))
+	for _, r := range t.rows {
+		rows = append(rows, copyRow(r))
+	}
+
+	return rows
+}
+
+// Reset - drop all tables and reset ids.
+func (c *Connector[C]) Reset() {
+	c.store.m.Lock()
+	defer c.store.m.Unlock()
+
+	c.store.tables = map[db.Table]*table{}
+}
+
+// table - return table by name, create it if absent, call under lock.
+func (s *store) table(name db.Table) *table {
+	t, found := s.tables[name]
+	if !found {
+		t = &table{rows: []Row{}}
+		s.tables[name] = t
+	}
+
+	return t
+}
+
+// insert - insert row, if row has not value of single pk column, next autoincrement id is set.
+func (t *table) insert(row Row, pk []db.Column) {
+	if len(pk) == 1 {
+		if id, found := row[pk[0]]; found && id != nil {
+			if n, ok := id.(int64); ok && n > t.lastID {
+				t.lastID = n
+			}
+		} else {
+			t.lastID++
+			row[pk[0]] = t.lastID
+		}
+	}
+
+	t.rows = append(t.rows, row)
+}
+
+// normalize - convert value to driver.Value like database/sql does (ints -> int64, Valuer -> Value(), nil pointers -> nil).
+func normalize(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+func newRow(cols []db.Column, vals []db.Argument) (Row, error) {
+	row := make(Row, len(cols))
+
+	for i, c := range cols {
+		var v any
+		if i < len(vals) {
+			v = vals[i]
+		}
+
+		nv, err := normalize(v)
+		if err != nil {
+			return nil, err
+		}
+
+		row[c] = nv
+	}
+
+	return row, nil
+}
+
+func copyRow(r Row) Row {
+	c := make(Row, len(r))
+	for k, v := range r {
+		c[k] = v
+	}
+
+	return c
+}
+
+// scanRow - set struct fields (by db tags) from row values, if cols is not empty only these columns are set.
+func scanRow(row Row, cols []db.Column, dest reflect.Value) error {
+	sm := mapper.TypeMap(dest.Type())
+
+	for col, val := range row {
+		if len(cols) > 0 && !hasColumn(cols, col) {
+			continue
+		}
+
+		fi, found := sm.Names[col]
+		if !found {
+			continue
+		}
+
+		if err := setValue(reflectx.FieldByIndexes(dest, fi.Index), val); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasColumn(cols []db.Column, col db.Column) bool {
+	for _, c := range cols {
+		if c == "*" || c == col || columnName(c) == col {
+			return true
+		}
+	}
+
+	return false
+}
+
+// columnName - column name without table alias (u.id -> id).
+func columnName(c db.Column) db.Column {
+	if i := strings.LastIndex(c, "."); i >= 0 {
+		return c[i+1:]
+	}
+
+	return c
+}
+
+// setValue - set driver value to field like sql.Rows.Scan does (Scanner, convertible types, ints and strings).
+func setValue(field reflect.Value, val any) error {
+	if scanner, ok := field.Addr().Interface().(interface{ Scan(any) error }); ok {
+		return scanner.Scan(val)
+	}
+
+	if val == nil {
+		field.Set(reflect.Zero(field.Type()))
+
+		return nil
+	}
+
+	if field.Kind() == reflect.Pointer {
+		p := reflect.New(field.Type().Elem())
+		if err := setValue(p.Elem(), val); err != nil {
+			return err
+		}
+
+		field.Set(p)
+
+		return nil
+	}
+
+	v := reflect.ValueOf(val)
+
+	switch {
+	case field.Kind() == reflect.String && v.Kind() != reflect.String && !(v.Kind() == reflect.Slice &&
+		v.Type().Elem().Kind() == reflect.Uint8):
+		field.SetString(toString(val))
+	case v.Type().ConvertibleTo(field.Type()):
+		field.Set(v.Convert(field.Type()))
+	default:
+		return ErrConvertValue
+	}
+
+	return nil
+}
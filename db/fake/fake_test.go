@@ -0,0 +1,384 @@
+package fake
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/example/simple/config"
+	"github.com/imperiuse/golib/db/example/simple/dto"
+	"github.com/imperiuse/golib/db/genrepo/emptygen"
+	"github.com/imperiuse/golib/db/hook"
+	"github.com/imperiuse/golib/db/repo"
+	"github.com/imperiuse/golib/db/repo/empty"
+	"github.com/imperiuse/golib/db/tenant"
+	"github.com/imperiuse/golib/reflect/validate"
+)
+
+func Test_Match(t *testing.T) {
+	row := Row{"id": int64(1), "name": "Bob", "n": int64(5), "email": nil}
+
+	testCases := []struct {
+		cond     db.Condition
+		expected bool
+	}{
+		{nil, true},
+		{squirrel.Eq{"id": 1}, true},
+		{squirrel.Eq{"u.id": 1}, true},
+		{squirrel.Eq{"id": 2}, false},
+		{squirrel.Eq{"id": []int{2, 1}}, true},
+		{squirrel.Eq{"email": nil}, true},
+		{squirrel.NotEq{"name": "Bob"}, false},
+		{squirrel.Gt{"n": 4}, true},
+		{squirrel.Gt{"n": 5.5}, false},
+		{squirrel.GtOrEq{"n": 5}, true},
+		{squirrel.Lt{"name": "Carl"}, true},
+		{squirrel.LtOrEq{"n": 4}, false},
+		{squirrel.Gt{"email": 1}, false},
+		{squirrel.And{squirrel.Eq{"name": "Bob"}, squirrel.Lt{"n": 10}}, true},
+		{squirrel.And{squirrel.Eq{"name": "Bob"}, squirrel.Lt{"n": 1}}, false},
+		{squirrel.Or{squirrel.Eq{"name": "Alice"}, squirrel.Gt{"n": 1}}, true},
+		{squirrel.Or{squirrel.Eq{"name": "Alice"}, squirrel.Gt{"n": 10}}, false},
+	}
+
+	for _, test := range testCases {
+		ok, err := Match(test.cond, row)
+		assert.Nil(t, err, test.cond)
+		assert.Equal(t, test.expected, ok, test.cond)
+	}
+
+	_, err := Match(squirrel.Expr("id = ?", 1), row)
+	assert.True(t, errors.Is(err, ErrUnsupportedCondition))
+
+	_, err = Match(squirrel.Gt{"name": 1}, row)
+	assert.True(t, errors.Is(err, ErrUnsupportedCondition))
+}
+
+func Test_Repository(t *testing.T) {
+	ctx := context.Background()
+	c := New(config.New(nil, false, false), zap.NewNop())
+
+	assert.Nil(t, c.Connection())
+
+	r := c.Repo(dto.Role[dto.ID]{})
+	assert.Equal(t, dto.Role[dto.ID]{}.Repo(), r.Name())
+
+	id, err := r.Create(ctx, dto.Role[dto.ID]{Name: "Admin", Rights: 7})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), id)
+
+	id, err = c.AutoCreate(ctx, &dto.Role[dto.ID]{Name: "User", Rights: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), id)
+
+	role := dto.Role[dto.ID]{BaseDTO: dto.BaseDTO[dto.ID]{Id: 1}}
+	assert.Nil(t, c.AutoGet(ctx, &role))
+	assert.Equal(t, "Admin", role.Name)
+	assert.Equal(t, 7, role.Rights)
+
+	assert.Equal(t, sql.ErrNoRows, r.Get(ctx, 100, &role))
+	assert.Equal(t, ErrUnsupportedDest, r.Get(ctx, 1, role))
+
+	cnt, err := r.Update(ctx, 1, dto.Role[dto.ID]{Name: "Root", Rights: 15})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	cnt, err = r.UpdateCustom(ctx, map[string]any{"rights": 0}, squirrel.Lt{"rights": 10})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	roles := []*dto.Role[dto.ID]{}
+	assert.Nil(t, r.FindBy(ctx, []db.Column{"id", "name"}, squirrel.Gt{"id": 0}, &roles))
+	assert.Len(t, roles, 2)
+	assert.Equal(t, "Root", roles[0].Name)
+	assert.Equal(t, 0, roles[0].Rights) // not selected column
+	assert.Equal(t, 2, roles[1].ID())
+
+	cnt, err = r.Upsert(ctx, []db.Column{"id", "name", "rights"}, []db.Argument{2, "Guest", 0}, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), cnt)
+
+	cnt, err = r.Upsert(ctx, []db.Column{"id", "name", "rights"}, []db.Argument{2, "Guest", 0},
+		[]db.Column{"id"}, []db.Column{"name"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	cnt, err = r.Insert(ctx, []db.Column{"name", "rights"}, []db.Argument{"Moderator", 3})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+	assert.Len(t, c.Rows(r.Name()), 3)
+
+	assert.Nil(t, r.FindOneBy(ctx, []db.Column{"*"}, squirrel.Eq{"name": "Guest"}, &role))
+	assert.Equal(t, 2, role.ID())
+
+	cnt, err = c.AutoDelete(ctx, &role)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	cnt, err = r.Delete(ctx, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), cnt)
+
+	assert.Equal(t, ErrNotSupported, r.Select(ctx, squirrel.Select("*"), &roles))
+
+	c.Reset()
+	assert.Len(t, c.Rows(r.Name()), 0)
+}
+
+func Test_GRepository(t *testing.T) {
+	ctx := context.Background()
+	c := New(config.New(nil, false, false), zap.NewNop())
+
+	r := NewGen[dto.ID, dto.User[dto.ID], config.SimpleTestConfig](c)
+	assert.Equal(t, dto.User[dto.ID]{}.Repo(), r.Name())
+
+	for i, name := range []string{"Alice", "Bob", "Carl"} {
+		id, err := r.Create(ctx, dto.User[dto.ID]{Name: name, RoleID: i % 2})
+		assert.Nil(t, err)
+		assert.Equal(t, i+1, id)
+	}
+
+	u, err := r.Get(ctx, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "Bob", u.Name)
+
+	_, err = r.Get(ctx, 10)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	users, err := r.FindBy(ctx, []db.Column{"*"}, squirrel.Or{squirrel.Eq{"role_id": 0}, squirrel.Eq{"name": "Bob"}})
+	assert.Nil(t, err)
+	assert.Len(t, users, 3)
+
+	u, err = r.FindOneBy(ctx, []db.Column{"*"}, squirrel.And{squirrel.Eq{"role_id": 0}, squirrel.Gt{"id": 1}})
+	assert.Nil(t, err)
+	assert.Equal(t, "Carl", u.Name)
+
+	cnt, err := r.Update(ctx, u.ID(), dto.User[dto.ID]{Name: "Charlie"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	cnt, err = r.UpdateFields(ctx, u.ID(), dto.User[dto.ID]{Name: "Chuck", Email: "c@mail.com"}, "Email")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	cnt, err = r.Delete(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	users, err = r.FindBy(ctx, []db.Column{"*"}, nil)
+	assert.Nil(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "Charlie", users[1].Name)
+	assert.Equal(t, "c@mail.com", users[1].Email)
+
+	_, err = r.Select(ctx, squirrel.Select("*"))
+	assert.Equal(t, ErrNotSupported, err)
+
+	_, errCh := r.Stream(ctx, squirrel.Select("*"))
+	assert.Equal(t, ErrNotSupported, <-errCh)
+}
+
+func Test_Validation(t *testing.T) {
+	c := New(config.New(nil, true, false), zap.NewNop())
+
+	assert.Equal(t, empty.Repo, c.Repo(dto.User[dto.ID]{}))
+	assert.Equal(t, emptygen.NewGen[dto.ID, dto.User[dto.ID]](),
+		NewGen[dto.ID, dto.User[dto.ID], config.SimpleTestConfig](c))
+
+	c.AddAllowsRepos(dto.User[dto.ID]{}.Repo())
+	assert.True(t, c.IsAllowRepo(dto.User[dto.ID]{}.Repo()))
+	assert.Equal(t, []db.Table{dto.User[dto.ID]{}.Repo()}, c.GetAllowsRepos())
+	assert.NotEqual(t, empty.Repo, c.Repo(dto.User[dto.ID]{}))
+}
+
+type Note struct {
+	Id       int64  `db:"id"        orm_use_in:"select" orm_pk:"identity"`
+	TenantID int64  `db:"tenant_id" orm_use_in:"select,create,update" orm_tenant:"true"`
+	Text     string `db:"text"      orm_use_in:"select,create,update"`
+}
+
+func (n Note) Repo() db.Table  { return "notes" }
+func (n Note) Identity() db.ID { return n.Id }
+func (n Note) ID() int64       { return n.Id }
+
+func Test_TenantScope(t *testing.T) {
+	ctx := context.Background()
+	c := New(config.New(nil, false, false), zap.NewNop())
+
+	r := NewGen[int64, Note, config.SimpleTestConfig](c)
+	ctx1, ctx2 := tenant.NewContext(ctx, 1), tenant.NewContext(ctx, 2)
+
+	_, err := r.Create(ctx, Note{Text: "no tenant"})
+	assert.ErrorIs(t, err, db.ErrNoTenant)
+	_, err = r.FindBy(ctx, []db.Column{"*"}, nil)
+	assert.ErrorIs(t, err, db.ErrNoTenant)
+
+	id1, err := r.Create(ctx1, Note{Text: "a", TenantID: 2}) // tenant of context wins
+	assert.Nil(t, err)
+	_, err = r.Create(ctx2, Note{Text: "b"})
+	assert.Nil(t, err)
+
+	notes, err := r.FindBy(ctx1, []db.Column{"*"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []Note{{Id: id1, TenantID: 1, Text: "a"}}, notes)
+
+	_, err = r.Get(ctx2, id1)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	n, err := r.Update(ctx1, id1, Note{Text: "a2", TenantID: 2}) // row can't be moved to other tenant
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	cols := []db.Column{"id", "tenant_id", "text"}
+	n, err = r.Upsert(ctx2, cols, []db.Argument{id1, 2, "y"}, []db.Column{"id"}, []db.Column{"tenant_id", "text"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), n)
+
+	note, err := r.Get(ctx1, id1)
+	assert.Nil(t, err)
+	assert.Equal(t, Note{Id: id1, TenantID: 1, Text: "a2"}, note)
+
+	n, err = r.Delete(ctx2, id1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), n)
+
+	_, err = c.Repo(Note{}).Insert(ctx2, []db.Column{"text"}, []db.Argument{"c"})
+	assert.Nil(t, err)
+
+	notes = []Note{}
+	assert.Nil(t, c.Repo(Note{}).FindBy(ctx2, []db.Column{"*"}, nil, &notes))
+	assert.Len(t, notes, 2)
+	assert.Len(t, c.Rows("notes"), 3) // RepoByName is not scoped
+}
+
+// Item - DTO with not id pk, validation and lifecycle hooks.
+type Item struct {
+	Code  string `db:"code"  orm_use_in:"select,create" orm_pk:"true"`
+	Name  string `db:"name"  orm_use_in:"select,create,update" validate:"required"`
+	Reads int    `db:"-"` // incremented by AfterGet
+}
+
+var errHook = errors.New("hook error")
+
+func (i Item) Repo() db.Table  { return "items" }
+func (i Item) Identity() db.ID { return i.Code }
+func (i Item) ID() string      { return i.Code }
+
+func (i *Item) BeforeCreate(context.Context) error {
+	i.Name = strings.TrimSpace(i.Name)
+	return nil
+}
+
+func (i *Item) AfterCreate(context.Context) error {
+	if i.Name == "fail" {
+		return errHook
+	}
+
+	return nil
+}
+
+func (i *Item) BeforeUpdate(context.Context) error {
+	i.Name += "!"
+	return nil
+}
+
+func (i *Item) AfterGet(context.Context) error {
+	i.Reads++
+	return nil
+}
+
+func (i *Item) BeforeDelete(context.Context) error {
+	if i.Code == "root" {
+		return errHook
+	}
+
+	return nil
+}
+
+func Test_LikeRealRepository(t *testing.T) {
+	ctx := context.Background()
+	c := New(config.New(nil, false, false), zap.NewNop())
+
+	var methods []string
+	c.AddQueryHooks(hook.AfterFunc(func(_ context.Context, e *db.QueryEvent) {
+		methods = append(methods, e.Method)
+	}))
+
+	r := NewGen[string, Item, config.SimpleTestConfig](c)
+
+	code, err := r.Create(ctx, Item{Code: "a", Name: " Apple "})
+	assert.Nil(t, err)
+	assert.Equal(t, "a", code)
+
+	_, err = r.Create(ctx, Item{Code: "b", Name: "  "}) // validated after BeforeCreate
+	assert.ErrorIs(t, err, validate.ErrRequired)
+
+	_, err = r.Create(ctx, Item{Code: "c", Name: "fail"})
+	assert.ErrorIs(t, err, errHook)
+
+	_, err = r.Create(ctx, Item{Code: "root", Name: "Root"})
+	assert.Nil(t, err)
+	assert.Equal(t, []Row{{"code": "a", "name": "Apple"}, {"code": "root", "name": "Root"}}, c.Rows("items"))
+
+	item, err := r.Get(ctx, "a") // pk column is code (orm_pk), not id
+	assert.Nil(t, err)
+	assert.Equal(t, Item{Code: "a", Name: "Apple", Reads: 1}, item)
+
+	n, err := r.Update(ctx, "a", Item{Name: "Apricot"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	items, err := r.FindBy(ctx, []db.Column{"*"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []Item{{Code: "a", Name: "Apricot!", Reads: 1}, {Code: "root", Name: "Root", Reads: 1}}, items)
+
+	_, err = r.Delete(ctx, "root")
+	assert.ErrorIs(t, err, errHook)
+
+	_, err = c.AutoDelete(ctx, &Item{Code: "root"})
+	assert.ErrorIs(t, err, errHook)
+
+	n, err = r.Delete(ctx, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	assert.Equal(t, []string{"Create", "Create", "Create", "Get", "Update", "FindBy", "Get", "Get", "Delete"}, methods)
+}
+
+func Test_RepoConstructors(t *testing.T) {
+	ctx := context.Background()
+	c := New(config.New(nil, false, false), zap.NewNop())
+
+	for _, r := range []db.GRepository[dto.ID, dto.User[dto.ID]]{
+		repo.NewGen[dto.ID, dto.User[dto.ID], config.SimpleTestConfig](c),
+		repo.Register[dto.ID, dto.User[dto.ID], config.SimpleTestConfig](c),
+		repo.For[dto.ID, dto.User[dto.ID], config.SimpleTestConfig](c),
+	} {
+		_, err := r.Create(ctx, dto.User[dto.ID]{Name: "Bob"})
+		assert.Nil(t, err)
+
+		_, err = r.FindBy(ctx, []db.Column{"*"}, nil, db.Preload("Role"))
+		assert.ErrorIs(t, err, ErrNotSupported)
+	}
+
+	users, err := repo.For[dto.ID, dto.User[dto.ID], config.SimpleTestConfig](c).FindBy(ctx, []db.Column{"*"}, nil)
+	assert.Nil(t, err)
+	assert.Len(t, users, 3)
+	assert.Len(t, c.Rows(dto.User[dto.ID]{}.Repo()), 3)
+
+	err = repo.For[dto.ID, dto.User[dto.ID], config.SimpleTestConfig](c).Iterate(ctx, squirrel.Select("*"), func(dto.User[dto.ID]) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrNotSupported) // raw sql
+
+	_, err = repo.Join[dto.UsersRole[dto.ID], config.SimpleTestConfig](c).FindBy(ctx, nil)
+	assert.ErrorIs(t, err, ErrNotSupported)
+}
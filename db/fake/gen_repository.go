@@ -0,0 +1,18 @@
+package fake
+
+import (
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/genrepo/emptygen"
+	"github.com/imperiuse/golib/db/repo"
+)
+
+// NewGen - in-memory db.GRepository, signature is the same as repo.NewGen (repo.NewGen, repo.For and repo.Register
+// of fake connector return the same repository, see repo.Backend),
+// connector must be created by fake.New (for other connectors empty generic repo is returned).
+func NewGen[I db.ID, D db.GDTO[I], C db.Config](connector db.Connector[C]) db.GRepository[I, D] {
+	if _, ok := connector.(*Connector[C]); !ok {
+		return emptygen.NewGen[I, D]()
+	}
+
+	return repo.NewGen[I, D](connector)
+}
@@ -0,0 +1,580 @@
+package fake
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/hook"
+	"github.com/imperiuse/golib/db/lifecycle"
+	"github.com/imperiuse/golib/db/repo"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+type repository struct {
+	name   db.Table
+	pk     []db.Column // primary key columns (orm_pk tags of DTO, see orm.GetPrimaryKeyColumns)
+	tenant db.Column   // orm_tenant column of DTO, empty - not scoped (see package db/tenant)
+	hook   db.QueryHook
+	store  *store
+}
+
+// run - fn between Before and After of query hooks of connector (event has not query and args).
+func (r *repository) run(ctx context.Context, method string, obj any, fn func(context.Context) (int64, error)) error {
+	return hook.Run(ctx, r.hook, &db.QueryEvent{Repo: r.name, Method: method, Obj: obj}, fn)
+}
+
+// pkCondition - condition by primary key (scoped by tenant), see repo.PKCondition.
+func (r *repository) pkCondition(ctx context.Context, method string, id db.ID) (db.Condition, error) {
+	cond, err := repo.PKCondition(r.pk, id)
+	if err != nil {
+		return nil, fmt.Errorf("[fake.%s] %w", method, err)
+	}
+
+	return r.scope(ctx, method, cond)
+}
+
+func (r *repository) Name() db.Table {
+	return r.name
+}
+
+func (r *repository) GetRowsByQuery(context.Context, db.SelectBuilder) (*sql.Rows, error) {
+	return nil, ErrNotSupported
+}
+
+func (r *repository) CountByQuery(context.Context, db.SelectBuilder) (uint64, error) {
+	return 0, ErrNotSupported
+}
+
+func (r *repository) Insert(ctx context.Context, columns []db.Column, values []db.Argument) (int64, error) {
+	row, err := newRow(columns, values)
+	if err != nil {
+		return repo.RowsAffectedUnknown, err
+	}
+
+	if err = r.scopeRow(ctx, "Insert", row); err != nil {
+		return repo.RowsAffectedUnknown, err
+	}
+
+	err = r.run(ctx, "Insert", nil, func(context.Context) (int64, error) {
+		r.store.m.Lock()
+		defer r.store.m.Unlock()
+
+		r.store.table(r.name).insert(row, r.pk)
+
+		return 1, nil
+	})
+	if err != nil {
+		return repo.RowsAffectedUnknown, err
+	}
+
+	return 1, nil
+}
+
+func (r *repository) UpdateCustom(ctx context.Context, set map[string]any, cond db.Condition) (int64, error) {
+	cond, err := r.scope(ctx, "UpdateCustom", cond)
+	if err != nil {
+		return repo.RowsAffectedUnknown, err
+	}
+
+	return r.update(ctx, "UpdateCustom", nil, set, cond)
+}
+
+func (r *repository) Upsert(
+	ctx context.Context, columns []db.Column, values []db.Argument, conflict []db.Column, update []db.Column,
+) (int64, error) {
+	row, err := newRow(columns, values)
+	if err != nil {
+		return repo.RowsAffectedUnknown, err
+	}
+
+	if err = r.scopeRow(ctx, "Upsert", row); err != nil {
+		return repo.RowsAffectedUnknown, err
+	}
+
+	if len(conflict) == 0 {
+		conflict = r.pk
+	}
+
+	var n int64 = repo.RowsAffectedUnknown
+
+	err = r.run(ctx, "Upsert", nil, func(context.Context) (int64, error) {
+		r.store.m.Lock()
+		defer r.store.m.Unlock()
+
+		n = r.upsert(row, conflict, update)
+
+		return n, nil
+	})
+
+	return n, err
+}
+
+// upsert - insert row or update columns of conflicted row, call under lock.
+func (r *repository) upsert(row Row, conflict []db.Column, update []db.Column) int64 {
+	t := r.store.table(r.name)
+
+	for _, existed := range t.rows {
+		if !conflicts(existed, row, conflict) {
+			continue
+		}
+
+		if len(update) == 0 || (r.tenant != "" && !conflicts(existed, row, []db.Column{r.tenant})) {
+			return 0 // do nothing, row of other tenant is not updated
+		}
+
+		for _, c := range update {
+			if c != r.tenant {
+				existed[c] = row[c]
+			}
+		}
+
+		return 1
+	}
+
+	t.insert(row, r.pk)
+
+	return 1
+}
+
+// Create - like in repo, SerialUnknown for not integer pk (see repo.IsIntegerPK).
+func (r *repository) Create(ctx context.Context, obj any) (int64, error) {
+	if !repo.IsIntegerPK(r.pk, obj) {
+		return repo.SerialUnknown, r.createAndGetID(ctx, "Create", obj, new(any))
+	}
+
+	var id = int64(repo.SerialUnknown)
+
+	err := r.createAndGetID(ctx, "Create", obj, &id)
+
+	return id, err
+}
+
+func (r *repository) CreateAndGetID(ctx context.Context, obj any, id any) error {
+	return r.createAndGetID(ctx, "CreateAndGetID", obj, id)
+}
+
+// CreateAs - CreateAndGetID reported to query hooks as method (see repo.BackendRepository).
+func (r *repository) CreateAs(ctx context.Context, method string, obj any, id any) error {
+	return r.createAndGetID(ctx, method, obj, id)
+}
+
+// createAndGetID - BeforeCreate hook, validation, insert and AfterCreate hook like in repo, but without transaction:
+// created row is deleted if AfterCreate fails. For composite pk id is not set.
+func (r *repository) createAndGetID(ctx context.Context, method string, obj any, id any) error {
+	data := lifecycle.Addressable(obj)
+
+	if err := lifecycle.BeforeCreate(ctx, data); err != nil {
+		return fmt.Errorf("[fake.%s] %w", method, err)
+	}
+
+	if err := lifecycle.Validate(data); err != nil {
+		return fmt.Errorf("[fake.%s] %w", method, err)
+	}
+
+	row, err := r.create(ctx, method, obj, data)
+	if err != nil {
+		return err
+	}
+
+	rowID := r.rowID(row)
+	if rowID != nil {
+		r.setCreatedID(data, rowID)
+	}
+
+	if err = lifecycle.AfterCreate(ctx, data); err != nil {
+		r.remove(row)
+
+		return fmt.Errorf("[fake.%s] %w", method, err)
+	}
+
+	if len(r.pk) > 1 {
+		return nil
+	}
+
+	v := reflect.ValueOf(id)
+	if v.Kind() != reflect.Pointer || v.IsNil() || setValue(v.Elem(), rowID) != nil {
+		return db.ErrUnsupportedID
+	}
+
+	return nil
+}
+
+func (r *repository) Get(ctx context.Context, id db.ID, dest any) error {
+	cond, err := r.pkCondition(ctx, "Get", id)
+	if err != nil {
+		return err
+	}
+
+	return r.findDest(ctx, "Get", nil, cond, 1, dest)
+}
+
+// Update - BeforeUpdate hook and validation of DTO like in repo (without transaction).
+func (r *repository) Update(ctx context.Context, id db.ID, obj any) (int64, error) {
+	cond, err := r.pkCondition(ctx, "Update", id)
+	if err != nil {
+		return repo.RowsAffectedUnknown, err
+	}
+
+	data := lifecycle.Addressable(obj)
+
+	if err = lifecycle.BeforeUpdate(ctx, data); err != nil {
+		return repo.RowsAffectedUnknown, fmt.Errorf("[fake.Update] %w", err)
+	}
+
+	if err = lifecycle.Validate(data); err != nil {
+		return repo.RowsAffectedUnknown, fmt.Errorf("[fake.Update] %w", err)
+	}
+
+	return r.update(ctx, "Update", obj, orm.GetDataForUpdate(data), cond)
+}
+
+// UpdateFields - the same rules as repo UpdateFields: map of columns or DTO + fields.
+func (r *repository) UpdateFields(ctx context.Context, id db.ID, set any, fields ...db.Column) (int64, error) {
+	cond, err := r.pkCondition(ctx, "UpdateFields", id)
+	if err != nil {
+		return repo.RowsAffectedUnknown, err
+	}
+
+	sm, ok := set.(map[string]any)
+	if !ok {
+		var err error
+		if sm, err = orm.GetDataForUpdateFields(set, fields...); err != nil {
+			return repo.RowsAffectedUnknown, err
+		}
+	}
+
+	if len(sm) == 0 {
+		return 0, nil
+	}
+
+	return r.update(ctx, "UpdateFields", nil, sm, cond)
+}
+
+func (r *repository) Delete(ctx context.Context, id db.ID) (int64, error) {
+	cond, err := r.pkCondition(ctx, "Delete", id)
+	if err != nil {
+		return repo.RowsAffectedUnknown, err
+	}
+
+	var cnt int64 = repo.RowsAffectedUnknown
+
+	err = r.run(ctx, "Delete", nil, func(context.Context) (int64, error) {
+		r.store.m.Lock()
+		defer r.store.m.Unlock()
+
+		t := r.store.table(r.name)
+
+		rows := make([]Row, 0, len(t.rows))
+		for _, row := range t.rows {
+			ok, err := Match(cond, row)
+			if err != nil {
+				return db.RowsUnknown, err
+			}
+
+			if !ok {
+				rows = append(rows, row)
+			}
+		}
+
+		cnt = int64(len(t.rows) - len(rows))
+		t.rows = rows
+
+		return cnt, nil
+	})
+
+	return cnt, err
+}
+
+func (r *repository) FindBy(ctx context.Context, columns []db.Column, cond db.Condition, dest any) error {
+	cond, err := r.scope(ctx, "FindBy", cond)
+	if err != nil {
+		return err
+	}
+
+	return r.findDest(ctx, "FindBy", columns, cond, 0, dest)
+}
+
+func (r *repository) FindOneBy(ctx context.Context, columns []db.Column, cond db.Condition, dest any) error {
+	cond, err := r.scope(ctx, "FindOneBy", cond)
+	if err != nil {
+		return err
+	}
+
+	return r.findDest(ctx, "FindOneBy", columns, cond, 1, dest)
+}
+
+func (r *repository) FindByWithInnerJoin(context.Context, []db.Column, db.Alias, db.Join, db.Condition, any) error {
+	return ErrNotSupported
+}
+
+func (r *repository) FindOneByWithInnerJoin(context.Context, []db.Column, db.Alias, db.Join, db.Condition, any) error {
+	return ErrNotSupported
+}
+
+func (r *repository) Select(context.Context, db.SelectBuilder, any) error {
+	return ErrNotSupported
+}
+
+func (r *repository) SelectWithPagePagination(
+	context.Context, db.SelectBuilder, db.PagePaginationParams, any,
+) (db.PagePaginationResults, error) {
+	return db.PagePaginationResults{}, ErrNotSupported
+}
+
+func (r *repository) SelectWithCursorOnPKPagination(
+	context.Context, db.SelectBuilder, db.CursorPaginationParams, any,
+) error {
+	return ErrNotSupported
+}
+
+// create - insert row from create columns (orm_use_in:"create") of data (addressable obj), return inserted row.
+func (r *repository) create(ctx context.Context, method string, obj any, data any) (Row, error) {
+	cols, vals := orm.GetDataForCreate(data)
+
+	keys, err := orm.GetGeneratedKeys(data) // uuid pk, generated on client
+	if err != nil {
+		return nil, err
+	}
+
+	row, err := newRow(cols, vals)
+	if err != nil {
+		return nil, err
+	}
+
+	for c, v := range keys {
+		if row[c], err = normalize(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = r.scopeRow(ctx, method, row); err != nil {
+		return nil, err
+	}
+
+	err = r.run(ctx, method, obj, func(context.Context) (int64, error) {
+		r.store.m.Lock()
+		defer r.store.m.Unlock()
+
+		r.store.table(r.name).insert(row, r.pk)
+
+		return 1, nil
+	})
+
+	return row, err
+}
+
+// rowID - value of pk column of row, nil for composite pk.
+func (r *repository) rowID(row Row) any {
+	if len(r.pk) != 1 {
+		return nil
+	}
+
+	r.store.m.RLock()
+	defer r.store.m.RUnlock()
+
+	return row[r.pk[0]]
+}
+
+// setCreatedID - set id of created row to zero pk field of DTO (for AfterCreate hook), like repo does.
+func (r *repository) setCreatedID(data any, id any) {
+	v, found := orm.GetColumnValue(reflect.ValueOf(data), r.pk[0])
+	if found && v.CanAddr() && v.IsZero() {
+		_ = setValue(v, id) // pk field of other type is left
+	}
+}
+
+// remove - delete inserted row (instead of rollback of transaction).
+func (r *repository) remove(row Row) {
+	r.store.m.Lock()
+	defer r.store.m.Unlock()
+
+	t := r.store.table(r.name)
+
+	for i := range t.rows {
+		if reflect.ValueOf(t.rows[i]).Pointer() == reflect.ValueOf(row).Pointer() {
+			t.rows = append(t.rows[:i], t.rows[i+1:]...)
+
+			return
+		}
+	}
+}
+
+// findDest - rows matched by condition (limit == 0 -> all rows) scanned to dest, then AfterGet hook of every row.
+func (r *repository) findDest(
+	ctx context.Context, method string, columns []db.Column, cond db.Condition, limit int, dest any,
+) error {
+	var rows []Row
+
+	err := r.run(ctx, method, nil, func(context.Context) (n int64, err error) {
+		rows, err = r.find(cond, limit)
+
+		return int64(len(rows)), err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = fillDest(rows, columns, dest); err != nil {
+		return err
+	}
+
+	if limit == 1 {
+		err = lifecycle.AfterGet(ctx, dest)
+	} else {
+		err = lifecycle.AfterGetAll(ctx, dest)
+	}
+
+	if err != nil {
+		return fmt.Errorf("[fake.%s] %w", method, err)
+	}
+
+	return nil
+}
+
+// find - copies of rows matched by condition in insertion order, limit == 0 -> all rows.
+func (r *repository) find(cond db.Condition, limit int) ([]Row, error) {
+	r.store.m.RLock()
+	defer r.store.m.RUnlock()
+
+	rows := []Row{}
+
+	t, found := r.store.tables[r.name]
+	if !found {
+		return rows, nil
+	}
+
+	for _, row := range t.rows {
+		ok, err := Match(cond, row)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			rows = append(rows, copyRow(row))
+		}
+
+		if limit > 0 && len(rows) == limit {
+			break
+		}
+	}
+
+	return rows, nil
+}
+
+func (r *repository) update(
+	ctx context.Context, method string, obj any, set map[string]any, cond db.Condition,
+) (int64, error) {
+	set = r.scopeSet(set)
+	values := make(Row, len(set))
+
+	for c, v := range set {
+		nv, err := normalize(v)
+		if err != nil {
+			return repo.RowsAffectedUnknown, err
+		}
+
+		values[c] = nv
+	}
+
+	var cnt int64 = repo.RowsAffectedUnknown
+
+	err := r.run(ctx, method, obj, func(context.Context) (int64, error) {
+		r.store.m.Lock()
+		defer r.store.m.Unlock()
+
+		n := int64(0)
+
+		for _, row := range r.store.table(r.name).rows {
+			ok, err := Match(cond, row)
+			if err != nil {
+				return db.RowsUnknown, err
+			}
+
+			if !ok {
+				continue
+			}
+
+			for c, v := range values {
+				row[c] = v
+			}
+
+			n++
+		}
+
+		cnt = n
+
+		return n, nil
+	})
+
+	return cnt, err
+}
+
+func conflicts(existed Row, row Row, conflict []db.Column) bool {
+	for _, c := range conflict {
+		v, found := row[c]
+		if !found || v == nil {
+			return false
+		}
+
+		if ok, _ := equalAny(existed[c], v); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fillDest - scan rows to dest: pointer to struct (first row, sql.ErrNoRows if rows are empty)
+// or pointer to slice of structs (or pointers to structs).
+func fillDest(rows []Row, columns []db.Column, dest any) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return ErrUnsupportedDest
+	}
+
+	v = v.Elem()
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if len(rows) == 0 {
+			return sql.ErrNoRows
+		}
+
+		return scanRow(rows[0], columns, v)
+	case reflect.Slice:
+		elemType := v.Type().Elem()
+
+		isPtr := elemType.Kind() == reflect.Pointer
+		if isPtr {
+			elemType = elemType.Elem()
+		}
+
+		if elemType.Kind() != reflect.Struct {
+			return ErrUnsupportedDest
+		}
+
+		res := reflect.MakeSlice(v.Type(), 0, len(rows))
+
+		for _, row := range rows {
+			elem := reflect.New(elemType)
+			if err := scanRow(row, columns, elem.Elem()); err != nil {
+				return err
+			}
+
+			if isPtr {
+				res = reflect.Append(res, elem)
+			} else {
+				res = reflect.Append(res, elem.Elem())
+			}
+		}
+
+		v.Set(res)
+
+		return nil
+	default:
+		return ErrUnsupportedDest
+	}
+}
@@ -0,0 +1,68 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/tenant"
+)
+
+// tenantID - tenant of ctx (normalized like values of rows), nil if repository is not scoped,
+// db.ErrNoTenant if ctx has not tenant.
+func (r *repository) tenantID(ctx context.Context, method string) (any, error) {
+	if r.tenant == "" {
+		return nil, nil
+	}
+
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("[fake.%s] %w", method, db.ErrNoTenant)
+	}
+
+	return normalize(id)
+}
+
+// scope - cond AND tenant condition (the same scoping as repositories of package db/repo).
+func (r *repository) scope(ctx context.Context, method string, cond db.Condition) (db.Condition, error) {
+	id, err := r.tenantID(ctx, method)
+	if err != nil || id == nil {
+		return cond, err
+	}
+
+	if cond == nil {
+		return squirrel.Eq{r.tenant: id}, nil
+	}
+
+	return squirrel.And{cond, squirrel.Eq{r.tenant: id}}, nil
+}
+
+// scopeRow - set tenant of ctx to inserted row.
+func (r *repository) scopeRow(ctx context.Context, method string, row Row) error {
+	id, err := r.tenantID(ctx, method)
+	if err != nil || id == nil {
+		return err
+	}
+
+	row[r.tenant] = id
+
+	return nil
+}
+
+// scopeSet - set of update without tenant column, so row can't be moved to other tenant.
+func (r *repository) scopeSet(set map[string]any) map[string]any {
+	if _, found := set[r.tenant]; !found || r.tenant == "" {
+		return set
+	}
+
+	sm := make(map[string]any, len(set))
+	for c, v := range set {
+		if c != r.tenant {
+			sm[c] = v
+		}
+	}
+
+	return sm
+}
@@ -0,0 +1,190 @@
+// Package filter - typed DSL of query conditions (db.Condition) for DTO, columns are validated against db tags of DTO
+// on construction, so rename of column is not silently broken query.
+//
+//	f := filter.And(
+//		filter.Field[User]("email").ILike("%@example.com"),
+//		filter.Not(filter.Field[User]("role_id").In(1, 2)),
+//	)
+//	users.Select(ctx, squirrel.Select("*").From("Users").Where(f)) // error of construction is returned by ToSql
+//
+// Conditions and ordering can be parsed from URL query too (see Parse).
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+type (
+	// Filter - condition on columns of DTO D, implements db.Condition (squirrel.Sqlizer).
+	Filter[D any] struct {
+		cond db.Condition
+		err  error
+	}
+
+	// Column - column of DTO D validated by Field.
+	Column[D any] struct {
+		name db.Column
+		typ  reflect.Type // go type of DTO field
+		err  error
+	}
+
+	not struct {
+		cond db.Condition
+	}
+)
+
+var (
+	ErrUnknownColumn = errors.New("unknown column of DTO")
+	ErrEmptyIn       = errors.New("empty list of values for IN")
+)
+
+// Field - column of DTO D by db tag (embedded structs without db tag are walked too).
+func Field[D any](column db.Column) Column[D] {
+	t := reflect.TypeOf((*D)(nil)).Elem()
+
+	v, found := orm.GetColumnValue(reflect.New(t), column)
+	if !found {
+		return Column[D]{name: column, err: fmt.Errorf("%s.%s: %w", t, column, ErrUnknownColumn)}
+	}
+
+	return Column[D]{name: column, typ: v.Type()}
+}
+
+// Name - name of column.
+func (c Column[D]) Name() db.Column {
+	return c.name
+}
+
+// Err - error of validation of column, nil if column is present in DTO.
+func (c Column[D]) Err() error {
+	return c.err
+}
+
+func (c Column[D]) Eq(v any) Filter[D]      { return c.filter(squirrel.Eq{c.name: v}) }
+func (c Column[D]) NotEq(v any) Filter[D]   { return c.filter(squirrel.NotEq{c.name: v}) }
+func (c Column[D]) Gt(v any) Filter[D]      { return c.filter(squirrel.Gt{c.name: v}) }
+func (c Column[D]) Gte(v any) Filter[D]     { return c.filter(squirrel.GtOrEq{c.name: v}) }
+func (c Column[D]) Lt(v any) Filter[D]      { return c.filter(squirrel.Lt{c.name: v}) }
+func (c Column[D]) Lte(v any) Filter[D]     { return c.filter(squirrel.LtOrEq{c.name: v}) }
+func (c Column[D]) Like(v any) Filter[D]    { return c.filter(squirrel.Like{c.name: v}) }
+func (c Column[D]) NotLike(v any) Filter[D] { return c.filter(squirrel.NotLike{c.name: v}) }
+func (c Column[D]) IsNull() Filter[D]       { return c.filter(squirrel.Eq{c.name: nil}) }
+func (c Column[D]) NotNull() Filter[D]      { return c.filter(squirrel.NotEq{c.name: nil}) }
+
+// ILike - case-insensitive LIKE (postgres only, use Like for other dialects).
+func (c Column[D]) ILike(v any) Filter[D] { return c.filter(squirrel.ILike{c.name: v}) }
+
+// In - column IN (values...), empty values is error (condition is always false, usually it is a bug of caller).
+func (c Column[D]) In(values ...any) Filter[D] {
+	if len(values) == 0 {
+		return Filter[D]{err: fmt.Errorf("%s: %w", c.name, ErrEmptyIn)}
+	}
+
+	return c.filter(squirrel.Eq{c.name: values})
+}
+
+// Asc - ordering by column, for SelectBuilder.OrderBy.
+func (c Column[D]) Asc() string { return c.name + " ASC" }
+
+// Desc - descending ordering by column, for SelectBuilder.OrderBy.
+func (c Column[D]) Desc() string { return c.name + " DESC" }
+
+func (c Column[D]) filter(cond db.Condition) Filter[D] {
+	if c.err != nil {
+		return Filter[D]{err: c.err}
+	}
+
+	return Filter[D]{cond: cond}
+}
+
+// And - all filters are true, empty filter (zero value) is skipped.
+func And[D any](filters ...Filter[D]) Filter[D] {
+	and, err := conditions(filters)
+	if err != nil || len(and) == 0 {
+		return Filter[D]{err: err}
+	}
+
+	if len(and) == 1 {
+		return Filter[D]{cond: and[0]}
+	}
+
+	return Filter[D]{cond: squirrel.And(and)}
+}
+
+// Or - at least one of filters is true, empty filter (zero value) is skipped.
+func Or[D any](filters ...Filter[D]) Filter[D] {
+	or, err := conditions(filters)
+	if err != nil || len(or) == 0 {
+		return Filter[D]{err: err}
+	}
+
+	if len(or) == 1 {
+		return Filter[D]{cond: or[0]}
+	}
+
+	return Filter[D]{cond: squirrel.Or(or)}
+}
+
+// Not - negation of filter.
+func Not[D any](f Filter[D]) Filter[D] {
+	if f.err != nil || f.cond == nil {
+		return f
+	}
+
+	return Filter[D]{cond: not{cond: f.cond}}
+}
+
+func conditions[D any](filters []Filter[D]) ([]db.Condition, error) {
+	conds := make([]db.Condition, 0, len(filters))
+
+	for _, f := range filters {
+		if f.err != nil {
+			return nil, f.err
+		}
+
+		if f.cond != nil {
+			conds = append(conds, f.cond)
+		}
+	}
+
+	return conds, nil
+}
+
+// IsEmpty - filter has not any condition (and error).
+func (f Filter[D]) IsEmpty() bool {
+	return f.cond == nil && f.err == nil
+}
+
+// Err - error of construction of filter (unknown column, invalid value and etc.).
+func (f Filter[D]) Err() error {
+	return f.err
+}
+
+// ToSql - implementation of squirrel.Sqlizer, error of construction is returned here.
+func (f Filter[D]) ToSql() (string, []any, error) {
+	if f.err != nil {
+		return "", nil, f.err
+	}
+
+	if f.cond == nil {
+		return "", nil, nil
+	}
+
+	return f.cond.ToSql()
+}
+
+func (n not) ToSql() (string, []any, error) {
+	sql, args, err := n.cond.ToSql()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("NOT (%s)", sql), args, nil
+}
@@ -0,0 +1,125 @@
+package filter
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/imperiuse/golib/db/example/simple/dto"
+)
+
+type User = dto.User[dto.ID]
+
+func Test_Filter(t *testing.T) {
+	f := And(
+		Field[User]("email").ILike("%@example.com"),
+		Or(Field[User]("name").Eq("Bob"), Field[User]("created_at").IsNull()),
+		Not(Field[User]("role_id").In(1, 2)),
+		Filter[User]{}, // empty filter is skipped
+	)
+
+	sql, args, err := f.ToSql()
+	assert.Nil(t, err)
+	assert.Equal(t, "(email ILIKE ? AND (name = ? OR created_at IS NULL) AND NOT (role_id IN (?,?)))", sql)
+	assert.Equal(t, []any{"%@example.com", "Bob", 1, 2}, args)
+
+	sql, _, err = squirrel.Select("*").From("Users").Where(Field[User]("id").Gte(5)).ToSql()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM Users WHERE id >= ?", sql)
+
+	assert.True(t, And[User]().IsEmpty())
+	assert.True(t, Not(Filter[User]{}).IsEmpty())
+	assert.Equal(t, "name DESC", Field[User]("name").Desc())
+}
+
+func Test_FilterErrors(t *testing.T) {
+	c := Field[User]("mail")
+	assert.ErrorIs(t, c.Err(), ErrUnknownColumn)
+	assert.Equal(t, "dto.User[int].mail: unknown column of DTO", c.Err().Error())
+
+	f := Or(Field[User]("name").Eq("Bob"), Not(c.Like("x")))
+	assert.ErrorIs(t, f.Err(), ErrUnknownColumn)
+
+	_, _, err := squirrel.Select("*").From("Users").Where(f).ToSql()
+	assert.ErrorIs(t, err, ErrUnknownColumn)
+
+	_, _, err = Field[User]("id").In().ToSql()
+	assert.ErrorIs(t, err, ErrEmptyIn)
+
+	assert.ErrorIs(t, Field[User]("-").Err(), ErrUnknownColumn) // db:"-" is not column
+}
+
+func Test_Parse(t *testing.T) {
+	values, err := url.ParseQuery(
+		"name__ilike=%25bo%25&role_id__in=1,2&id__gt=5&id__lte=10&email=a@b.c&email=d@e.f" +
+			"&created_at__gte=2022-01-02T03:04:05Z&updated_at__isnull=false&sort=-created_at,name")
+	assert.Nil(t, err)
+
+	q, err := Parse[User](values)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"created_at DESC", "name ASC"}, q.OrderBy)
+
+	sql, args, err := q.Apply(squirrel.Select("*").From("Users")).ToSql()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM Users WHERE (created_at >= ? AND email IN (?,?) AND id > ? AND id <= ? "+
+		"AND name ILIKE ? AND role_id IN (?,?) AND updated_at IS NOT NULL) ORDER BY created_at DESC, name ASC", sql)
+	assert.Equal(t, []any{
+		time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), "a@b.c", "d@e.f", int64(5), int64(10), "%bo%", int64(1), int64(2),
+	}, args)
+
+	q, err = Parse[User](url.Values{})
+	assert.Nil(t, err)
+	sql, _, _ = q.Apply(squirrel.Select("*").From("Users")).ToSql()
+	assert.Equal(t, "SELECT * FROM Users", sql)
+}
+
+func Test_ParseErrors(t *testing.T) {
+	for query, expected := range map[string]error{
+		"nick=Bob":             ErrUnknownColumn,
+		"sort=-nick":           ErrUnknownColumn,
+		"name__between=1":      ErrUnknownOperator,
+		"id__gt=five":          ErrInvalidValue,
+		"role_id__in=":         ErrInvalidValue,
+		"name__isnull=maybe":   ErrInvalidValue,
+		"created_at=yesterday": ErrInvalidValue,
+		"password__like=a%25":  ErrForbiddenColumn, // orm_sensitive
+		"sort=password":        ErrForbiddenColumn,
+		"page=2":               ErrUnknownColumn, // not ignored by Parse
+	} {
+		values, err := url.ParseQuery(query)
+		assert.Nil(t, err)
+
+		_, err = Parse[User](values)
+		assert.ErrorIs(t, err, expected, query)
+	}
+}
+
+func Test_ParseWith(t *testing.T) {
+	opts := Options{Columns: []string{"name", "id"}, Ignore: []string{"page", "limit"}, SortKey: "order"}
+
+	values, err := url.ParseQuery("name=Bob&page=2&limit=10&order=-id")
+	assert.Nil(t, err)
+
+	q, err := ParseWith[User](values, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"id DESC"}, q.OrderBy)
+
+	sql, args, err := q.Apply(squirrel.Select("*").From("Users")).ToSql()
+	assert.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM Users WHERE name = ? ORDER BY id DESC", sql)
+	assert.Equal(t, []any{"Bob"}, args)
+
+	for _, query := range []string{"email=a@b.c", "order=email", "password=x", "sort=id"} {
+		values, err = url.ParseQuery(query)
+		assert.Nil(t, err)
+
+		_, err = ParseWith[User](values, opts)
+		assert.NotNil(t, err, query)
+	}
+
+	_, err = ParseWith[User](url.Values{"password": {"x"}}, Options{Columns: []string{"password"}})
+	assert.ErrorIs(t, err, ErrForbiddenColumn) // sensitive column can't be allowed
+}
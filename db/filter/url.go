@@ -0,0 +1,311 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+// URL query format (all conditions are joined by AND, columns are validated against DTO):
+//
+//	?name=Bob                  - name = 'Bob' (several values -> IN)
+//	?name__ilike=%25bo%25      - operator after `__`: eq, ne, gt, gte, lt, lte, like, ilike, in (comma separated), isnull
+//	?n__gt=5&n__lte=10         - values are converted to go type of DTO field (numbers, bool, time in RFC3339)
+//	?sort=-created_at,name     - ordering, `-` prefix is DESC
+//
+// Query is untrusted input: sensitive columns (orm_sensitive, see orm.IsSensitiveField) are never filtered or sorted,
+// Options.Columns restricts columns to allowlist, Options.Ignore skips keys which are not filters (page, limit).
+//
+// Usage:
+//
+//	q, err := filter.ParseWith[User](r.URL.Query(), filter.Options{Columns: []db.Column{"name", "n"}, Ignore: []string{"page"}})
+//	users.Select(ctx, q.Apply(squirrel.Select("*").From("Users")))
+
+type (
+	// Query - condition and ordering parsed from URL query.
+	Query[D any] struct {
+		Where   Filter[D]
+		OrderBy []string
+	}
+
+	// Options - settings of ParseWith.
+	Options struct {
+		Columns []db.Column // allowlist of filtered and sorted columns, empty - all not sensitive columns of DTO
+		Ignore  []string    // keys of URL query which are not filters (e.g. page, limit), they are skipped
+		SortKey string      // key of ordering, SortKey if empty
+	}
+)
+
+const (
+	// SortKey - reserved key of URL query for ordering.
+	SortKey = "sort"
+
+	opSeparator = "__"
+)
+
+var (
+	ErrUnknownOperator = errors.New("unknown filter operator")
+	ErrInvalidValue    = errors.New("invalid filter value")
+	ErrForbiddenColumn = errors.New("column is not allowed in URL query")
+)
+
+// Parse - parse URL query to condition and ordering for DTO D (see format above), all not sensitive columns of DTO
+// are allowed, only SortKey is reserved (use ParseWith for allowlist of columns and ignored keys).
+func Parse[D any](values url.Values) (Query[D], error) {
+	return ParseWith[D](values, Options{})
+}
+
+// ParseWith - the same as Parse, but columns and keys of URL query are checked by opts.
+func ParseWith[D any](values url.Values, opts Options) (Query[D], error) {
+	if opts.SortKey == "" {
+		opts.SortKey = SortKey
+	}
+
+	q := Query[D]{OrderBy: []string{}}
+	allowed := allowedColumns[D](opts.Columns)
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys) // stable order of conditions and args
+
+	filters := []Filter[D]{}
+
+	for _, key := range keys {
+		if contains(opts.Ignore, key) {
+			continue
+		}
+
+		if key == opts.SortKey {
+			columns := splitList(values[key])
+			for _, column := range columns {
+				if err := allowed(strings.TrimPrefix(column, "-")); err != nil {
+					return Query[D]{}, err
+				}
+			}
+
+			orderBy, err := OrderBy[D](columns...)
+			if err != nil {
+				return Query[D]{}, err
+			}
+
+			q.OrderBy = append(q.OrderBy, orderBy...)
+
+			continue
+		}
+
+		f, err := parseFilter[D](key, values[key], allowed)
+		if err != nil {
+			return Query[D]{}, err
+		}
+
+		filters = append(filters, f)
+	}
+
+	q.Where = And(filters...)
+
+	return q, q.Where.Err()
+}
+
+// OrderBy - ordering by columns of DTO D, `-` prefix of column is DESC.
+func OrderBy[D any](columns ...string) ([]string, error) {
+	orderBy := make([]string, 0, len(columns))
+
+	for _, column := range columns {
+		c := Field[D](strings.TrimPrefix(column, "-"))
+		if c.Err() != nil {
+			return nil, c.Err()
+		}
+
+		if strings.HasPrefix(column, "-") {
+			orderBy = append(orderBy, c.Desc())
+		} else {
+			orderBy = append(orderBy, c.Asc())
+		}
+	}
+
+	return orderBy, nil
+}
+
+// Apply - add condition and ordering to select builder.
+func (q Query[D]) Apply(builder db.SelectBuilder) db.SelectBuilder {
+	if !q.Where.IsEmpty() {
+		builder = builder.Where(q.Where)
+	}
+
+	if len(q.OrderBy) > 0 {
+		builder = builder.OrderBy(q.OrderBy...)
+	}
+
+	return builder
+}
+
+// allowedColumns - check of column of URL query: sensitive columns of DTO D and columns out of not empty allowlist
+// are forbidden.
+func allowedColumns[D any](allowlist []db.Column) func(db.Column) error {
+	var dto D
+
+	sensitive := map[db.Column]bool{}
+
+	for _, c := range orm.Describe(&dto).Columns {
+		if c.Sensitive {
+			sensitive[c.Name] = true
+			sensitive[c.Alias+"."+c.Name] = true
+		}
+	}
+
+	return func(column db.Column) error {
+		if sensitive[column] || (len(allowlist) > 0 && !contains(allowlist, column)) {
+			return fmt.Errorf("%s: %w", column, ErrForbiddenColumn)
+		}
+
+		return nil
+	}
+}
+
+func parseFilter[D any](key string, raw []string, allowed func(db.Column) error) (Filter[D], error) {
+	column, op := key, "eq"
+	if i := strings.LastIndex(key, opSeparator); i > 0 {
+		column, op = key[:i], key[i+len(opSeparator):]
+	}
+
+	if err := allowed(column); err != nil {
+		return Filter[D]{}, err
+	}
+
+	c := Field[D](column)
+	if c.Err() != nil {
+		return Filter[D]{}, c.Err()
+	}
+
+	switch op {
+	case "in":
+		raw = splitList(raw)
+	case "isnull":
+		return parseIsNull(c, key, raw)
+	}
+
+	values := make([]any, 0, len(raw))
+
+	for _, s := range raw {
+		v, err := convert(s, c.typ)
+		if err != nil {
+			return Filter[D]{}, fmt.Errorf("%s=%q: %w", key, s, err)
+		}
+
+		values = append(values, v)
+	}
+
+	if len(values) == 0 {
+		return Filter[D]{}, fmt.Errorf("%s: %w", key, ErrInvalidValue)
+	}
+
+	if op == "eq" || op == "in" {
+		if len(values) == 1 && op == "eq" {
+			return c.Eq(values[0]), nil
+		}
+
+		return c.In(values...), nil
+	}
+
+	ops := map[string]func(any) Filter[D]{
+		"ne": c.NotEq, "gt": c.Gt, "gte": c.Gte, "lt": c.Lt, "lte": c.Lte,
+		"like": c.Like, "ilike": c.ILike,
+	}
+
+	fn, found := ops[op]
+	if !found {
+		return Filter[D]{}, fmt.Errorf("%s: %w", key, ErrUnknownOperator)
+	}
+
+	filters := make([]Filter[D], 0, len(values))
+	for _, v := range values {
+		filters = append(filters, fn(v))
+	}
+
+	return And(filters...), nil
+}
+
+func parseIsNull[D any](c Column[D], key string, raw []string) (Filter[D], error) {
+	if len(raw) != 1 {
+		return Filter[D]{}, fmt.Errorf("%s: %w", key, ErrInvalidValue)
+	}
+
+	isNull, err := strconv.ParseBool(raw[0])
+	if err != nil {
+		return Filter[D]{}, fmt.Errorf("%s=%q: %w", key, raw[0], ErrInvalidValue)
+	}
+
+	if isNull {
+		return c.IsNull(), nil
+	}
+
+	return c.NotNull(), nil
+}
+
+// convert - convert value of URL query to go type of DTO field (pointer is dereferenced), string for other types.
+func convert(s string, t reflect.Type) (any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var (
+		v   any
+		err error
+	)
+
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		v, err = time.Parse(time.RFC3339, s)
+	case t.Kind() == reflect.Bool:
+		v, err = strconv.ParseBool(s)
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		v, err = strconv.ParseInt(s, 10, 64)
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		v, err = strconv.ParseUint(s, 10, 64)
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		v, err = strconv.ParseFloat(s, 64)
+	default:
+		v = s
+	}
+
+	if err != nil {
+		return nil, ErrInvalidValue
+	}
+
+	return v, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitList(values []string) []string {
+	list := []string{}
+
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+
+	return list
+}
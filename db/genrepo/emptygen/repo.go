@@ -11,10 +11,16 @@ const nameGen = "emptyGenRepo"
 
 type gRepo[I db.ID, D db.GDTO[I]] struct{}
 
+type joinRepo[D any] struct{}
+
 func NewGen[I db.ID, D db.GDTO[I]]() db.GRepository[I, D] {
 	return &gRepo[I, D]{}
 }
 
+func NewJoin[D any]() db.JoinRepository[D] {
+	return &joinRepo[D]{}
+}
+
 func (g *gRepo[I, D]) Name() db.Table {
 	return nameGen
 }
@@ -35,6 +41,10 @@ func (g *gRepo[I, D]) UpdateCustom(context.Context, map[string]any, db.Condition
 	return 0, db.ErrInvalidRepoEmptyRepo
 }
 
+func (g *gRepo[I, D]) Upsert(context.Context, []db.Column, []db.Argument, []db.Column, []db.Column) (int64, error) {
+	return 0, db.ErrInvalidRepoEmptyRepo
+}
+
 func (g *gRepo[I, D]) Create(context.Context, D) (I, error) {
 	return *new(I), db.ErrInvalidRepoEmptyRepo
 }
@@ -47,11 +57,15 @@ func (g *gRepo[I, D]) Update(context.Context, I, D) (int64, error) {
 	return 0, db.ErrInvalidRepoEmptyRepo
 }
 
+func (g *gRepo[I, D]) UpdateFields(context.Context, I, any, ...db.Column) (int64, error) {
+	return 0, db.ErrInvalidRepoEmptyRepo
+}
+
 func (g *gRepo[I, D]) Delete(context.Context, I) (int64, error) {
 	return 0, db.ErrInvalidRepoEmptyRepo
 }
 
-func (g *gRepo[I, D]) FindBy(context.Context, []db.Column, db.Condition) ([]D, error) {
+func (g *gRepo[I, D]) FindBy(context.Context, []db.Column, db.Condition, ...db.SelectOption) ([]D, error) {
 	return *new([]D), db.ErrInvalidRepoEmptyRepo
 }
 
@@ -67,7 +81,7 @@ func (g *gRepo[I, D]) FindOneByWithInnerJoin(context.Context, []db.Column, db.Al
 	return *new(D), db.ErrInvalidRepoEmptyRepo
 }
 
-func (g *gRepo[I, D]) Select(context.Context, db.SelectBuilder) ([]D, error) {
+func (g *gRepo[I, D]) Select(context.Context, db.SelectBuilder, ...db.SelectOption) ([]D, error) {
 	return *new([]D), db.ErrInvalidRepoEmptyRepo
 }
 
@@ -78,3 +92,36 @@ func (g *gRepo[I, D]) SelectWithPagePagination(context.Context, db.SelectBuilder
 func (g *gRepo[I, D]) SelectWithCursorOnPKPagination(context.Context, db.SelectBuilder, db.CursorPaginationParams) ([]D, error) {
 	return *new([]D), db.ErrInvalidRepoEmptyRepo
 }
+
+func (g *gRepo[I, D]) Iterate(context.Context, db.SelectBuilder, func(D) error) error {
+	return db.ErrInvalidRepoEmptyRepo
+}
+
+func (g *gRepo[I, D]) IterateWithServerCursor(context.Context, db.SelectBuilder, uint64, func(D) error) error {
+	return db.ErrInvalidRepoEmptyRepo
+}
+
+func (g *gRepo[I, D]) Stream(context.Context, db.SelectBuilder) (<-chan D, <-chan error) {
+	ch, errCh := make(chan D), make(chan error, 1)
+	errCh <- db.ErrInvalidRepoEmptyRepo
+	close(ch)
+	close(errCh)
+
+	return ch, errCh
+}
+
+func (j *joinRepo[D]) Name() db.Table {
+	return nameGen
+}
+
+func (j *joinRepo[D]) FindBy(context.Context, db.Condition) ([]D, error) {
+	return *new([]D), db.ErrInvalidRepoEmptyRepo
+}
+
+func (j *joinRepo[D]) FindOneBy(context.Context, db.Condition) (D, error) {
+	return *new(D), db.ErrInvalidRepoEmptyRepo
+}
+
+func (j *joinRepo[D]) Select(context.Context, db.SelectBuilder) ([]D, error) {
+	return *new([]D), db.ErrInvalidRepoEmptyRepo
+}
@@ -15,3 +15,18 @@ func InsertAndGetLastID(ctx context.Context, lastInsertID any, query string, arg
 		return t.QueryRowContext(ctx, query, args...).Scan(lastInsertID)
 	}
 }
+
+// ExecAndGetLastInsertID helper which Usefully for db without RETURNING support (MySQL, SQLite)
+// query := `INSERT INTO table (col1, col2) VALUES (?, ?)` -> sql.Result.LastInsertId().
+func ExecAndGetLastInsertID(ctx context.Context, lastInsertID *int64, query string, args ...any) transaction.TxFn {
+	return func(t *sqlx.Tx) error {
+		res, err := t.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		*lastInsertID, err = res.LastInsertId()
+
+		return err
+	}
+}
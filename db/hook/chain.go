@@ -0,0 +1,97 @@
+// Package hook - db.QueryHook chain and built-in hooks: zap logging (sensitive fields are masked), slow queries,
+// latency histograms.
+//
+// Usage:
+//
+//	latency := hook.NewLatency()
+//	connector.AddQueryHooks(
+//		hook.NewLogger(logger, zapcore.DebugLevel, false),
+//		hook.NewSlowQuery(logger, 100*time.Millisecond),
+//		latency,
+//	)
+package hook
+
+import (
+	"context"
+	"sync"
+
+	"github.com/imperiuse/golib/db"
+)
+
+type (
+	// Chain - thread safe list of hooks, Before are called in order of adding, After in reverse order.
+	Chain struct {
+		m     sync.RWMutex
+		hooks []db.QueryHook
+	}
+
+	// AfterFunc - hook which is called only after query.
+	AfterFunc func(context.Context, *db.QueryEvent)
+
+	chainKey struct{}
+)
+
+// NewChain - create chain of hooks.
+func NewChain(hooks ...db.QueryHook) *Chain {
+	c := &Chain{}
+	c.Add(hooks...)
+
+	return c
+}
+
+// Add - add hooks to the end of chain (nil hooks are skipped).
+func (c *Chain) Add(hooks ...db.QueryHook) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	for _, h := range hooks {
+		if h != nil {
+			c.hooks = append(c.hooks, h)
+		}
+	}
+}
+
+// Len - count of hooks in chain.
+func (c *Chain) Len() int {
+	c.m.RLock()
+	defer c.m.RUnlock()
+
+	return len(c.hooks)
+}
+
+func (c *Chain) Before(ctx context.Context, e *db.QueryEvent) context.Context {
+	c.m.RLock()
+	hooks := c.hooks // hooks are only appended, so slice header is a consistent snapshot
+	c.m.RUnlock()
+
+	if len(hooks) == 0 {
+		return ctx
+	}
+
+	for _, h := range hooks {
+		ctx = h.Before(ctx, e)
+	}
+
+	return context.WithValue(ctx, chainKey{}, hooks) // After calls the same hooks even if new were added
+}
+
+func (c *Chain) After(ctx context.Context, e *db.QueryEvent) {
+	hooks, ok := ctx.Value(chainKey{}).([]db.QueryHook)
+	if !ok {
+		c.m.RLock()
+		hooks = c.hooks
+		c.m.RUnlock()
+	}
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].After(ctx, e)
+	}
+}
+
+func (f AfterFunc) Before(ctx context.Context, _ *db.QueryEvent) context.Context {
+	return ctx
+}
+
+func (f AfterFunc) After(ctx context.Context, e *db.QueryEvent) {
+	f(ctx, e)
+}
@@ -0,0 +1,142 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/imperiuse/golib/db"
+)
+
+type (
+	orderHook struct {
+		name  string
+		calls *[]string
+	}
+
+	ctxKey struct{}
+
+	secret struct {
+		Name     string
+		Password string `log:"-"`
+	}
+)
+
+func (h orderHook) Before(ctx context.Context, _ *db.QueryEvent) context.Context {
+	*h.calls = append(*h.calls, "before "+h.name)
+
+	return context.WithValue(ctx, ctxKey{}, h.name)
+}
+
+func (h orderHook) After(ctx context.Context, _ *db.QueryEvent) {
+	*h.calls = append(*h.calls, "after "+h.name+" ctx "+ctx.Value(ctxKey{}).(string))
+}
+
+func Test_Chain(t *testing.T) {
+	calls := []string{}
+
+	c := NewChain(orderHook{name: "a", calls: &calls}, nil)
+	c.Add(orderHook{name: "b", calls: &calls})
+	assert.Equal(t, 2, c.Len())
+
+	e := &db.QueryEvent{}
+	ctx := c.Before(context.Background(), e)
+
+	c.Add(orderHook{name: "c", calls: &calls}) // added during query, not called in After
+	c.After(ctx, e)
+
+	assert.Equal(t, []string{"before a", "before b", "after b ctx b", "after a ctx b"}, calls)
+
+	ctx = NewChain().Before(context.Background(), e)
+	assert.Equal(t, context.Background(), ctx)
+
+	var got *db.QueryEvent
+
+	AfterFunc(func(_ context.Context, e *db.QueryEvent) { got = e }).After(ctx, e)
+	assert.Equal(t, e, got)
+}
+
+func Test_Logger(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	h := NewLogger(zap.New(core), zapcore.DebugLevel, false)
+
+	e := &db.QueryEvent{
+		Repo:     "Users",
+		Method:   "Create",
+		Obj:      secret{Name: "bob", Password: "p@ssw0rd"},
+		Query:    "INSERT INTO Users (name,password) VALUES ($1,$2)",
+		Args:     []any{"bob", "p@ssw0rd"},
+		Duration: time.Millisecond,
+		Rows:     1,
+	}
+
+	h.After(h.Before(context.Background(), e), e)
+
+	assert.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, "[repo.Create]", entry.Message)
+	assert.Equal(t, zapcore.DebugLevel, entry.Level)
+	assert.Equal(t, "Users", entry.ContextMap()["repo"])
+	assert.Equal(t, map[string]any{"Name": "bob", "Password": "***"}, entry.ContextMap()["obj"])
+	assert.NotContains(t, entry.ContextMap(), "args")
+
+	e.Err = errors.New("boom")
+	NewLogger(zap.New(core), zapcore.DebugLevel, true).After(context.Background(), e)
+
+	assert.Equal(t, 2, logs.Len())
+	entry = logs.All()[1]
+	assert.Equal(t, zapcore.ErrorLevel, entry.Level)
+	assert.Equal(t, "boom", entry.ContextMap()["error"])
+	assert.Contains(t, entry.ContextMap(), "args")
+
+	core, logs = observer.New(zapcore.InfoLevel)
+	e.Err = nil
+	NewLogger(zap.New(core), zapcore.DebugLevel, false).After(context.Background(), e)
+	assert.Equal(t, 0, logs.Len())
+}
+
+func Test_SlowQuery(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	h := NewSlowQuery(zap.New(core), 100*time.Millisecond)
+
+	h.After(context.Background(), &db.QueryEvent{Repo: "Users", Method: "Get", Duration: 10 * time.Millisecond})
+	assert.Equal(t, 0, logs.Len())
+
+	h.After(context.Background(), &db.QueryEvent{Repo: "Users", Method: "Get", Duration: time.Second})
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, zapcore.WarnLevel, logs.All()[0].Level)
+	assert.Equal(t, "Get", logs.All()[0].ContextMap()["method"])
+}
+
+func Test_Latency(t *testing.T) {
+	l := NewLatency(10*time.Millisecond, time.Millisecond)
+
+	for _, d := range []time.Duration{time.Microsecond, time.Millisecond, 5 * time.Millisecond, time.Second} {
+		l.After(context.Background(), &db.QueryEvent{Repo: "Users", Duration: d})
+	}
+
+	l.After(context.Background(), &db.QueryEvent{Repo: "Roles", Duration: 2 * time.Millisecond})
+
+	s := l.Snapshot()
+	assert.Len(t, s, 2)
+
+	users := s["Users"]
+	assert.Equal(t, []time.Duration{time.Millisecond, 10 * time.Millisecond}, users.Buckets)
+	assert.Equal(t, []uint64{2, 1, 1}, users.Counts)
+	assert.Equal(t, uint64(4), users.Count)
+	assert.Equal(t, (time.Microsecond+6*time.Millisecond+time.Second)/4, users.Mean())
+
+	assert.Equal(t, []uint64{0, 1, 0}, s["Roles"].Counts)
+	assert.Equal(t, time.Duration(0), Histogram{}.Mean())
+
+	assert.Equal(t, DefaultBuckets, NewLatency().buckets)
+}
@@ -0,0 +1,92 @@
+package hook
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/imperiuse/golib/db"
+)
+
+type (
+	// Latency - hook which collects per table (repo) latency histograms of queries.
+	Latency struct {
+		m       sync.Mutex
+		buckets []time.Duration
+		tables  map[db.Table]*Histogram
+	}
+
+	// Histogram - latency histogram, Counts[i] - count of queries with duration <= Buckets[i]
+	// (and > Buckets[i-1]), last element of Counts - count of queries longer than last bucket.
+	Histogram struct {
+		Buckets []time.Duration
+		Counts  []uint64
+		Count   uint64
+		Sum     time.Duration
+	}
+)
+
+// DefaultBuckets - default upper bounds of latency buckets.
+var DefaultBuckets = []time.Duration{
+	time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond, time.Second, 5 * time.Second,
+}
+
+// NewLatency - create latency hook with buckets upper bounds (DefaultBuckets if empty).
+func NewLatency(buckets ...time.Duration) *Latency {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	b := append([]time.Duration{}, buckets...)
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+
+	return &Latency{buckets: b, tables: map[db.Table]*Histogram{}}
+}
+
+func (l *Latency) Before(ctx context.Context, _ *db.QueryEvent) context.Context {
+	return ctx
+}
+
+func (l *Latency) After(_ context.Context, e *db.QueryEvent) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	h, found := l.tables[e.Repo]
+	if !found {
+		h = &Histogram{Buckets: l.buckets, Counts: make([]uint64, len(l.buckets)+1)}
+		l.tables[e.Repo] = h
+	}
+
+	h.Counts[sort.Search(len(l.buckets), func(i int) bool { return e.Duration <= l.buckets[i] })]++
+	h.Count++
+	h.Sum += e.Duration
+}
+
+// Snapshot - copy of histograms of all tables.
+func (l *Latency) Snapshot() map[db.Table]Histogram {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	s := make(map[db.Table]Histogram, len(l.tables))
+	for t, h := range l.tables {
+		s[t] = Histogram{
+			Buckets: h.Buckets,
+			Counts:  append([]uint64{}, h.Counts...),
+			Count:   h.Count,
+			Sum:     h.Sum,
+		}
+	}
+
+	return s
+}
+
+// Mean - average latency.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	return h.Sum / time.Duration(h.Count)
+}
@@ -0,0 +1,90 @@
+package hook
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+type (
+	logger struct {
+		logger  db.Logger
+		level   zapcore.Level
+		logArgs bool
+	}
+
+	slowQuery struct {
+		logger    db.Logger
+		threshold time.Duration
+	}
+)
+
+// NewLogger - hook which logs every query with level (errors are logged with Error level).
+// Obj of event is logged with masked sensitive fields (tags orm_sensitive:"true" or log:"-", see orm.Sensitive),
+// query args are logged only if logArgs == true (args are not redacted, they can contain sensitive values).
+func NewLogger(l db.Logger, level zapcore.Level, logArgs bool) db.QueryHook {
+	return &logger{logger: l, level: level, logArgs: logArgs}
+}
+
+// NewSlowQuery - hook which logs (Warn level) queries executed longer than threshold.
+func NewSlowQuery(l db.Logger, threshold time.Duration) db.QueryHook {
+	return &slowQuery{logger: l, threshold: threshold}
+}
+
+func (l *logger) Before(ctx context.Context, _ *db.QueryEvent) context.Context {
+	return ctx
+}
+
+func (l *logger) After(_ context.Context, e *db.QueryEvent) {
+	level := l.level
+	if e.Err != nil {
+		level = zapcore.ErrorLevel
+	}
+
+	ce := l.logger.Check(level, "[repo."+e.Method+"]")
+	if ce == nil {
+		return
+	}
+
+	fields := append(eventFields(e), zap.Int64("rows", e.Rows))
+
+	if e.Obj != nil {
+		fields = append(fields, orm.LogField("obj", e.Obj))
+	}
+
+	if l.logArgs {
+		fields = append(fields, zap.Any("args", e.Args))
+	}
+
+	if e.Err != nil {
+		fields = append(fields, zap.Error(e.Err))
+	}
+
+	ce.Write(fields...)
+}
+
+func (s *slowQuery) Before(ctx context.Context, _ *db.QueryEvent) context.Context {
+	return ctx
+}
+
+func (s *slowQuery) After(_ context.Context, e *db.QueryEvent) {
+	if e.Duration < s.threshold {
+		return
+	}
+
+	s.logger.Warn("[hook.SlowQuery]", append(eventFields(e), zap.Duration("threshold", s.threshold))...)
+}
+
+func eventFields(e *db.QueryEvent) []zap.Field {
+	return []zap.Field{
+		zap.String("repo", e.Repo),
+		zap.String("method", e.Method),
+		zap.String("query", e.Query),
+		zap.Duration("duration", e.Duration),
+	}
+}
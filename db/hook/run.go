@@ -0,0 +1,28 @@
+package hook
+
+import (
+	"context"
+	"time"
+
+	"github.com/imperiuse/golib/db"
+)
+
+// Run - call fn (execution of query, return count of affected or fetched rows) between h.Before and h.After,
+// event is filled by start time, duration, rows and error of fn. Nil h => only fn is called.
+func Run(ctx context.Context, h db.QueryHook, e *db.QueryEvent, fn func(context.Context) (int64, error)) error {
+	if h == nil {
+		_, err := fn(ctx)
+
+		return err
+	}
+
+	e.Start = time.Now()
+	ctx = h.Before(ctx, e)
+
+	e.Rows, e.Err = fn(ctx)
+	e.Duration = time.Since(e.Start)
+
+	h.After(ctx, e)
+
+	return e.Err
+}
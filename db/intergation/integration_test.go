@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"fmt"
 	"math/rand"
+	"sync"
 	"testing"
+	"testing/fstest"
 
 	"go.uber.org/zap"
 
@@ -19,9 +21,11 @@ import (
 
 	"github.com/imperiuse/golib/db"
 	"github.com/imperiuse/golib/db/connector"
+	"github.com/imperiuse/golib/db/drift"
 	"github.com/imperiuse/golib/db/example/simple/config"
 	"github.com/imperiuse/golib/db/example/simple/dto"
 	"github.com/imperiuse/golib/db/genrepo/emptygen"
+	"github.com/imperiuse/golib/db/migrate"
 	"github.com/imperiuse/golib/db/repo"
 	"github.com/imperiuse/golib/db/repo/empty"
 	"github.com/imperiuse/golib/reflect/orm"
@@ -86,6 +90,9 @@ func (suite *RepositoryTestSuit) SetupSuite() {
 	orm.InitMetaTagInfoCache(a...)
 	orm.InitMetaTagInfoCache(&dto.BaseDTO[dto.ID]{}, &dto.UsersRole[dto.ID]{})
 
+	dsl, err := dto.DDL()
+	suite.Require().Nil(err)
+
 	tables := []string{}
 	// create table
 	for _, obj := range DTOs {
@@ -93,7 +100,7 @@ func (suite *RepositoryTestSuit) SetupSuite() {
 		assert.NotEqual(suite.T(), "", table)
 		tables = append(tables, table)
 
-		_, err = dbConn.ExecContext(suite.ctx, dto.DSL[table])
+		_, err = dbConn.ExecContext(suite.ctx, dsl[table])
 		assert.Nil(suite.T(), err)
 	}
 
@@ -162,13 +169,18 @@ func initTables() {
 		panic(err)
 	}
 
+	dsl, err := dto.DDL()
+	if err != nil {
+		panic(err)
+	}
+
 	tables := []string{}
 	// create table
 	for _, obj := range DTOs {
 		table := orm.GetTableName(obj)
 		tables = append(tables, table)
 
-		_, _ = dbConn.ExecContext(context.Background(), dto.DSL[table])
+		_, _ = dbConn.ExecContext(context.Background(), dsl[table])
 	}
 
 	// Refresh DB
@@ -935,3 +947,253 @@ func (suite *RepositoryTestSuit) Test_SelectWithCursorPagination() {
 		}
 	}
 }
+
+func (suite *RepositoryTestSuit) Test_Iterate() {
+	t := suite.T()
+
+	for _, c := range []db.Connector[config.SimpleTestConfig]{
+		suite.connector,
+		suite.connectorWithValidation,
+		suite.connectorWithValidationAndCache,
+	} {
+		r := repo.NewGen[dto.ID, dto.Paginator[dto.ID]](c)
+
+		sum, cnt := 0, 0
+		err := r.Iterate(suite.ctx, squirrel.Select("*").OrderBy("id ASC"), func(p dto.Paginator[dto.ID]) error {
+			sum, cnt = sum+p.N, cnt+1
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 200, cnt)
+		assert.Equal(t, 200*201/2, sum)
+
+		cnt = 0
+		err = r.Iterate(suite.ctx, squirrel.Select("*").OrderBy("id ASC"), func(p dto.Paginator[dto.ID]) error {
+			if cnt++; cnt == 10 {
+				return db.ErrStopIteration
+			}
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 10, cnt)
+
+		errFn := fmt.Errorf("some error")
+		err = r.Iterate(suite.ctx, squirrel.Select("*"), func(p dto.Paginator[dto.ID]) error { return errFn })
+		assert.ErrorIs(t, err, errFn)
+
+		ctx, cancel := context.WithCancel(suite.ctx)
+		cancel()
+		err = r.Iterate(ctx, squirrel.Select("*"), func(p dto.Paginator[dto.ID]) error { return nil })
+		assert.ErrorIs(t, err, context.Canceled)
+
+		// server side cursor way
+		for _, fetchSize := range []uint64{1, 7, 50, 200, 1000} {
+			sum, cnt = 0, 0
+			err = r.IterateWithServerCursor(suite.ctx, squirrel.Select("*").Where(squirrel.Gt{"n": 100}),
+				fetchSize, func(p dto.Paginator[dto.ID]) error {
+					sum, cnt = sum+p.N, cnt+1
+					return nil
+				})
+			assert.Nil(t, err)
+			assert.Equal(t, 100, cnt)
+			assert.Equal(t, 200*201/2-100*101/2, sum)
+		}
+
+		cnt = 0
+		err = r.IterateWithServerCursor(suite.ctx, squirrel.Select("*"), 3, func(p dto.Paginator[dto.ID]) error {
+			if cnt++; cnt == 5 {
+				return db.ErrStopIteration
+			}
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 5, cnt)
+
+		assert.Equal(t, db.ErrZeroFetchSize, r.IterateWithServerCursor(suite.ctx, squirrel.Select("*"), 0,
+			func(p dto.Paginator[dto.ID]) error { return nil }))
+	}
+}
+
+func (suite *RepositoryTestSuit) Test_Stream() {
+	t := suite.T()
+
+	for _, c := range []db.Connector[config.SimpleTestConfig]{
+		suite.connector,
+		suite.connectorWithValidation,
+		suite.connectorWithValidationAndCache,
+	} {
+		ch, errCh := repo.NewGen[dto.ID, dto.Paginator[dto.ID]](c).Stream(suite.ctx, squirrel.Select("*"))
+
+		cnt := 0
+		for range ch {
+			cnt++
+		}
+		assert.Equal(t, 200, cnt)
+		assert.Nil(t, <-errCh)
+
+		ctx, cancel := context.WithCancel(suite.ctx)
+		ch, errCh = repo.NewGen[dto.ID, dto.Paginator[dto.ID]](c).Stream(ctx, squirrel.Select("*"))
+		<-ch
+		cancel()
+		for range ch { // drain
+		}
+		assert.ErrorIs(t, <-errCh, context.Canceled)
+	}
+
+	ch, errCh := repo.NewGen[dto.ID, notRegisterDTO[dto.ID]](suite.connectorWithValidation).
+		Stream(suite.ctx, squirrel.Select("*"))
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.Equal(t, db.ErrInvalidRepoEmptyRepo, <-errCh)
+}
+
+func (suite *RepositoryTestSuit) Test_Join() {
+	t := suite.T()
+
+	roleID, err := repo.NewGen[dto.ID, dto.Role[dto.ID]](suite.connector).Create(suite.ctx, dto.Role[dto.ID]{
+		Name:   "JoinRole",
+		Rights: 7,
+	})
+	assert.Nil(t, err)
+
+	userID, err := repo.NewGen[dto.ID, dto.User[dto.ID]](suite.connector).Create(suite.ctx, dto.User[dto.ID]{
+		Name:     "JoinUser",
+		Email:    "join@mail.com",
+		Password: "p@ssw0rd",
+		RoleID:   roleID,
+	})
+	assert.Nil(t, err)
+
+	defer func() {
+		_, err = suite.connector.RepoByName(dto.User[dto.ID]{}.Repo()).Delete(suite.ctx, userID)
+		assert.Nil(t, err)
+		_, err = suite.connector.RepoByName(dto.Role[dto.ID]{}.Repo()).Delete(suite.ctx, roleID)
+		assert.Nil(t, err)
+	}()
+
+	for _, c := range []db.Connector[config.SimpleTestConfig]{
+		suite.connector,
+		suite.connectorWithValidation,
+		suite.connectorWithValidationAndCache,
+	} {
+		r := repo.Join[dto.UsersRole[dto.ID]](c)
+		assert.Equal(t, dto.User[dto.ID]{}.Repo(), r.Name())
+
+		ur, err := r.FindOneBy(suite.ctx, squirrel.Eq{"u.id": userID})
+		assert.Nil(t, err)
+		assert.Equal(t, userID, ur.User.ID())
+		assert.Equal(t, "JoinUser", ur.User.Name)
+		assert.Equal(t, roleID, ur.Role.ID())
+		assert.Equal(t, "JoinRole", ur.Role.Name)
+		assert.Equal(t, 7, ur.Role.Rights)
+
+		url, err := r.FindBy(suite.ctx, squirrel.Eq{"r.name": "JoinRole"})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(url))
+		assert.Equal(t, userID, url[0].User.ID())
+
+		url, err = r.Select(suite.ctx, squirrel.Select().Where(squirrel.Eq{"r.id": roleID}).OrderBy("u.id").Limit(10))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(url))
+		assert.Equal(t, roleID, url[0].Role.ID())
+
+		_, err = r.FindOneBy(suite.ctx, squirrel.Eq{"u.id": -1})
+		assert.Equal(t, sql.ErrNoRows, err)
+	}
+
+	r := repo.Join[dto.UsersRole[dto.ID]](connector.New[config.SimpleTestConfig](
+		config.New(squirrel.Dollar, true, false), zap.NewNop(), suite.db))
+	assert.Equal(t, emptygen.NewJoin[dto.UsersRole[dto.ID]](), r)
+}
+
+func (suite *RepositoryTestSuit) Test_Migrate() {
+	t := suite.T()
+
+	fsys := fstest.MapFS{
+		"0001_create_migrate_test.up.sql":   {Data: []byte("CREATE TABLE migrate_test (id INTEGER PRIMARY KEY);")},
+		"0001_create_migrate_test.down.sql": {Data: []byte("DROP TABLE migrate_test;")},
+		"0002_fill_migrate_test.up.sql":     {Data: []byte("INSERT INTO migrate_test VALUES (1), (2);")},
+		"0002_fill_migrate_test.down.sql":   {Data: []byte("DELETE FROM migrate_test;")},
+	}
+
+	cfg := migrate.Config{TableName: "schema_migrations_test"}
+	defer func() {
+		_, _ = suite.db.ExecContext(suite.ctx, "DROP TABLE IF EXISTS migrate_test, schema_migrations_test;")
+	}()
+
+	dry, err := migrate.New(suite.logger, suite.db, fsys, migrate.Config{TableName: cfg.TableName, DryRun: true})
+	assert.Nil(t, err)
+	planned, err := dry.Up(suite.ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(planned))
+
+	m, err := migrate.New(suite.logger, suite.db, fsys, cfg)
+	assert.Nil(t, err)
+
+	// concurrent runners apply every migration exactly once
+	wg := sync.WaitGroup{}
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := m.Up(suite.ctx)
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	cnt := 0
+	assert.Nil(t, suite.db.GetContext(suite.ctx, &cnt, "SELECT count(1) FROM migrate_test"))
+	assert.Equal(t, 2, cnt)
+
+	st, err := m.Status(suite.ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(st))
+	assert.True(t, st[0].Applied && st[1].Applied)
+
+	done, err := m.Down(suite.ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), done[0].Version)
+
+	assert.Nil(t, suite.db.GetContext(suite.ctx, &cnt, "SELECT count(1) FROM migrate_test"))
+	assert.Equal(t, 0, cnt)
+
+	fsys["0001_create_migrate_test.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE migrate_test (id BIGINT);")}
+	m, err = migrate.New(suite.logger, suite.db, fsys, cfg)
+	assert.Nil(t, err)
+
+	_, err = m.Up(suite.ctx)
+	assert.ErrorIs(t, err, migrate.ErrChecksumMismatch)
+
+	st, err = m.Status(suite.ctx)
+	assert.Nil(t, err)
+	assert.True(t, st[0].ChecksumMismatch)
+	assert.False(t, st[1].Applied)
+}
+
+type driftUser[I db.ID] struct {
+	dto.User[I]
+	Phone string `db:"phone" orm_use_in:"select"`
+}
+
+func (suite *RepositoryTestSuit) Test_Drift() {
+	t := suite.T()
+
+	reports, err := drift.Check(suite.ctx, suite.connector, drift.Config{}, DTOs...)
+	assert.Nil(t, err)
+	assert.Equal(t, len(DTOs), len(reports))
+	assert.False(t, reports.HasDrift())
+
+	reports, err = drift.Check(suite.ctx, suite.connector, drift.Config{FailFast: true},
+		&driftUser[dto.ID]{}, &notRegisterDTO[dto.ID]{}, &dto.Role[dto.ID]{})
+	assert.ErrorIs(t, err, drift.ErrSchemaDrift)
+	assert.Equal(t, 1, len(reports))
+	assert.Equal(t, []db.Column{"phone"}, reports[0].MissingColumns)
+
+	reports, err = drift.Check(suite.ctx, suite.connector, drift.Config{},
+		&driftUser[dto.ID]{}, &notRegisterDTO[dto.ID]{}, &dto.Role[dto.ID]{})
+	assert.ErrorIs(t, err, drift.ErrSchemaDrift)
+	assert.Equal(t, 3, len(reports))
+	assert.True(t, reports[1].TableMissing)
+	assert.False(t, reports[2].HasDrift())
+}
@@ -0,0 +1,985 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	_ "github.com/mattn/go-sqlite3" // for sqlite3 driver import.
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/connector"
+	"github.com/imperiuse/golib/db/dialect"
+	"github.com/imperiuse/golib/db/drift"
+	"github.com/imperiuse/golib/db/example/simple/config"
+	"github.com/imperiuse/golib/db/example/simple/dto"
+	"github.com/imperiuse/golib/db/filter"
+	"github.com/imperiuse/golib/db/hook"
+	"github.com/imperiuse/golib/db/repo"
+	"github.com/imperiuse/golib/db/tenant"
+	"github.com/imperiuse/golib/db/transaction"
+	"github.com/imperiuse/golib/reflect/orm"
+	"github.com/imperiuse/golib/reflect/validate"
+)
+
+type (
+	// Token - DTO with uuid pk generated on client.
+	Token struct {
+		UID  uuid.UUID `db:"uid"  orm_use_in:"select" orm_pk:"uuid"`
+		Name string    `db:"name" orm_use_in:"select,create,update"`
+		Note *string   `db:"note" orm_use_in:"select,update"`
+		_    any       `orm_table_name:"Tokens"`
+	}
+
+	// Member - DTO with composite pk.
+	Member struct {
+		GroupID int64  `db:"group_id" orm_use_in:"select,create" orm_pk:"true"`
+		UserID  int64  `db:"user_id"  orm_use_in:"select,create" orm_pk:"true"`
+		Role    string `db:"role"     orm_use_in:"select,create,update"`
+		_       any    `orm_table_name:"Members"`
+	}
+
+	// Event - DTO with embedded pointer struct and JSON columns.
+	Event struct {
+		*dto.BaseDTO[dto.ID]
+		Kind    string         `db:"kind"    orm_use_in:"select,create,update"`
+		Payload map[string]any `db:"payload" orm_use_in:"select,create,update" orm_json:"true"`
+		Tags    []string       `db:"tags"    orm_use_in:"select,create,update" orm_json:"true"`
+		Amount  sql.NullInt64  `db:"amount"  orm_use_in:"select,create,update"`
+		_       any            `orm_table_name:"Events"`
+	}
+
+	// Author and Book - DTO without db tags, columns and tables are derived by naming strategy (orm_naming tag).
+	Author struct {
+		Id       int64  `orm_use_in:"select" orm_pk:"identity" orm_type:"INTEGER"`
+		FullName string `orm_use_in:"select,create,update"`
+		_        any    `orm_naming:"snake" orm_alias:"a"`
+	}
+
+	Book struct {
+		Id       int64  `orm_use_in:"select"        orm_pk:"identity" orm_type:"INTEGER"`
+		AuthorID int64  `orm_use_in:"select,create"`
+		Title    string `orm_use_in:"select,create,update"`
+		_        any    `orm_naming:"snake" orm_alias:"b"`
+	}
+
+	// Account - DTO with lifecycle hooks.
+	Account struct {
+		Id      int64  `db:"id"      orm_use_in:"select"        orm_pk:"identity" orm_type:"INTEGER"`
+		Email   string `db:"email"   orm_use_in:"select,create,update" validate:"email"` // normalized by BeforeCreate
+		Version int64  `db:"version" orm_use_in:"select,create,update"`
+		Nick    string `db:"nick"    orm_use_in:"select,create,update" validate:"max=8,regexp=^[a-z]*$"`
+		Domain  string `db:"-"` // filled by AfterGet
+		_       any    `orm_table_name:"Accounts"`
+	}
+
+	// Note - DTO scoped by tenant of context (orm_tenant column).
+	Note struct {
+		Id       int64  `db:"id"        orm_use_in:"select" orm_pk:"identity" orm_type:"INTEGER"`
+		TenantID int64  `db:"tenant_id" orm_use_in:"select,create,update" orm_tenant:"true"`
+		Text     string `db:"text"      orm_use_in:"select,create,update"`
+		_        any    `orm_table_name:"Notes"`
+	}
+
+	// Order - DTO of table and column named by sql keywords (identifiers of generated queries are quoted).
+	Order struct {
+		Id    int64  `db:"id"    orm_use_in:"select" orm_pk:"identity"`
+		Group string `db:"group" orm_use_in:"select,create,update"`
+		_     any    `orm_table_name:"order"`
+	}
+
+	AuthorBook struct {
+		Author `db:"a" orm_alias:"a"`
+		Book   `db:"b" orm_alias:"b" orm_join:"a.id = b.author_id"`
+	}
+)
+
+func (t Token) Repo() db.Table    { return "Tokens" }
+func (t Token) Identity() db.ID   { return t.UID }
+func (t Token) ID() uuid.UUID     { return t.UID }
+func (m Member) Repo() db.Table   { return "Members" }
+func (m Member) Identity() db.ID  { return []any{m.GroupID, m.UserID} }
+func (m Member) ID() [2]int64     { return [2]int64{m.GroupID, m.UserID} }
+func (e Event) Repo() db.Table    { return "Events" }
+func (a Account) Repo() db.Table  { return "Accounts" }
+func (a Account) Identity() db.ID { return a.Id }
+func (a Account) ID() int64       { return a.Id }
+func (a Author) Repo() db.Table   { return "author" }
+func (a Author) Identity() db.ID  { return a.Id }
+func (a Author) ID() int64        { return a.Id }
+func (b Book) Repo() db.Table     { return "book" }
+func (b Book) Identity() db.ID    { return b.Id }
+func (b Book) ID() int64          { return b.Id }
+func (n Note) Repo() db.Table     { return "Notes" }
+func (n Note) Identity() db.ID    { return n.Id }
+func (n Note) ID() int64          { return n.Id }
+func (o Order) Repo() db.Table    { return "order" }
+func (o Order) Identity() db.ID   { return o.Id }
+func (o Order) ID() int64         { return o.Id }
+
+var errForbidden = errors.New("forbidden")
+
+func (a *Account) BeforeCreate(context.Context) error {
+	a.Email = strings.ToLower(strings.TrimSpace(a.Email))
+	if a.Email == "" {
+		return errForbidden
+	}
+
+	return nil
+}
+
+// AfterCreate - owner membership is created in the same transaction, error rolls back created account.
+func (a *Account) AfterCreate(ctx context.Context) error {
+	tx, ok := transaction.TxFromContext(ctx)
+	if !ok || strings.HasPrefix(a.Email, "rollback") {
+		return errForbidden
+	}
+
+	_, err := tx.ExecContext(ctx, "INSERT INTO Members (group_id, user_id, role) VALUES (?, ?, 'owner')", a.Id, a.Id)
+
+	return err
+}
+
+func (a *Account) BeforeUpdate(context.Context) error {
+	a.Version++
+	return nil
+}
+
+func (a *Account) AfterGet(context.Context) error {
+	_, a.Domain, _ = strings.Cut(a.Email, "@")
+	return nil
+}
+
+func (a *Account) BeforeDelete(context.Context) error {
+	if a.Email == "admin@example.com" {
+		return errForbidden
+	}
+
+	return nil
+}
+
+// newConnector - in-memory sqlite db with example tables (DDL generated from DTO tags for sqlite dialect).
+func newConnector(t *testing.T) db.Connector[config.SimpleTestConfig] {
+	t.Helper()
+
+	return newConnectorDSN(t, "file::memory:?cache=shared&_foreign_keys=on")
+}
+
+// newConnectorDSN - sqlite db of dsn with example tables.
+func newConnectorDSN(t *testing.T, dsn string) db.Connector[config.SimpleTestConfig] {
+	t.Helper()
+
+	dbConn, err := sqlx.Connect("sqlite3", dsn)
+	require.Nil(t, err)
+
+	dbConn.SetMaxOpenConns(1) // one connection -> one in-memory database for all queries and transactions
+
+	t.Cleanup(func() { _ = dbConn.Close() })
+
+	for _, obj := range []db.DTO{dto.Role[dto.ID]{}, dto.User[dto.ID]{}, dto.Paginator[dto.ID]{}, Token{}, Member{}, Event{}, Author{}, Book{}, Account{}, Note{}} {
+		ddl, err := orm.GetCreateTableDDL(obj, orm.DialectSQLite)
+		require.Nil(t, err)
+
+		_, err = dbConn.Exec(ddl)
+		require.Nil(t, err, ddl)
+	}
+
+	return connector.New(config.New(nil, false, false).WithDialect(dialect.SQLite), zap.NewNop(), dbConn)
+}
+
+func Test_Repository(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	r := c.RepoByName(dto.Role[dto.ID]{}.Repo())
+
+	id, err := r.Create(ctx, dto.Role[dto.ID]{Name: "Admin", Rights: 7})
+	assert.Nil(t, err)
+	assert.NotEqual(t, int64(0), id)
+
+	role := dto.Role[dto.ID]{}
+	assert.Nil(t, r.Get(ctx, id, &role))
+	assert.Equal(t, int(id), role.ID())
+	assert.Equal(t, "Admin", role.Name)
+	assert.Equal(t, 7, role.Rights)
+	assert.False(t, role.CreatedAt.IsZero())
+
+	cnt, err := r.Update(ctx, id, dto.Role[dto.ID]{Name: "Root", Rights: 15})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	roles := []dto.Role[dto.ID]{}
+	assert.Nil(t, r.FindBy(ctx, []db.Column{"*"}, squirrel.Eq{"name": "Root"}, &roles))
+	assert.Len(t, roles, 1)
+	assert.Equal(t, 15, roles[0].Rights)
+
+	cnt, err = r.Delete(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	err = r.Get(ctx, id, &role)
+	assert.NotNil(t, err)
+}
+
+func Test_GRepository(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	r := repo.NewGen[dto.ID, dto.Paginator[dto.ID]](c)
+
+	ids := make([]dto.ID, 0, 10)
+	for i := 1; i <= 10; i++ {
+		id, err := r.Create(ctx, dto.Paginator[dto.ID]{Name: "p", N: i})
+		assert.Nil(t, err)
+		assert.NotEqual(t, 0, id)
+
+		ids = append(ids, id)
+	}
+
+	p, err := r.Get(ctx, ids[4])
+	assert.Nil(t, err)
+	assert.Equal(t, 5, p.N)
+
+	ps, err := r.FindBy(ctx, []db.Column{"*"}, squirrel.Gt{"n": 7})
+	assert.Nil(t, err)
+	assert.Len(t, ps, 3)
+
+	ps, res, err := r.SelectWithPagePagination(ctx,
+		squirrel.Select("*").From(r.Name()).OrderBy("n"), db.PagePaginationParams{PageNumber: 2, PageSize: 3})
+	assert.Nil(t, err)
+	assert.Len(t, ps, 3)
+	assert.Equal(t, 4, ps[0].N)
+	assert.Equal(t, uint64(4), res.CntPages)
+
+	ps, err = r.SelectWithCursorOnPKPagination(ctx,
+		squirrel.Select("*").From(r.Name()), db.CursorPaginationParams{Limit: 2, Cursor: uint64(ids[1])})
+	assert.Nil(t, err)
+	assert.Len(t, ps, 2)
+	assert.Equal(t, 3, ps[0].N)
+
+	sum := 0
+	assert.Nil(t, r.Iterate(ctx, squirrel.Select("*").From(r.Name()), func(p dto.Paginator[dto.ID]) error {
+		sum += p.N
+		return nil
+	}))
+	assert.Equal(t, 55, sum)
+}
+
+func Test_Upsert(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	r := repo.NewGen[dto.ID, dto.Role[dto.ID]](c)
+
+	cols := []db.Column{"id", "name", "rights"}
+
+	cnt, err := r.Upsert(ctx, cols, []db.Argument{1, "User", 1}, []db.Column{"id"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	cnt, err = r.Upsert(ctx, cols, []db.Argument{1, "Guest", 0}, []db.Column{"id"}, nil) // do nothing
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), cnt)
+
+	cnt, err = r.Upsert(ctx, cols, []db.Argument{1, "Admin", 7}, []db.Column{"id"}, []db.Column{"name", "rights"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	role, err := r.Get(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "Admin", role.Name)
+	assert.Equal(t, 7, role.Rights)
+}
+
+func Test_QuotedIdentifiers(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	_, err := c.Connection().ExecContext(ctx, `CREATE TABLE "order" (id INTEGER PRIMARY KEY AUTOINCREMENT, "group" TEXT)`)
+	require.Nil(t, err)
+
+	r := repo.NewGen[int64, Order](c)
+
+	id, err := r.Create(ctx, Order{Group: "a"})
+	require.Nil(t, err)
+	assert.NotEqual(t, int64(0), id)
+
+	cnt, err := r.Update(ctx, id, Order{Group: "b"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	o, err := r.Get(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", o.Group)
+
+	cnt, err = r.Delete(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+}
+
+func Test_Join(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	roleID, err := repo.NewGen[dto.ID, dto.Role[dto.ID]](c).Create(ctx, dto.Role[dto.ID]{Name: "JoinRole", Rights: 7})
+	assert.Nil(t, err)
+
+	userID, err := repo.NewGen[dto.ID, dto.User[dto.ID]](c).Create(ctx, dto.User[dto.ID]{
+		Name:     "JoinUser",
+		Email:    "join@mail.com",
+		Password: "p@ssw0rd",
+		RoleID:   roleID,
+	})
+	assert.Nil(t, err)
+
+	ur, err := repo.Join[dto.UsersRole[dto.ID]](c).FindOneBy(ctx, squirrel.Eq{"u.id": userID})
+	assert.Nil(t, err)
+	assert.Equal(t, "JoinUser", ur.User.Name)
+	assert.Equal(t, roleID, ur.Role.ID())
+	assert.Equal(t, "JoinRole", ur.Role.Name)
+}
+
+func Test_QueryHooks(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	r := repo.NewGen[dto.ID, dto.Role[dto.ID]](c) // created before hooks are added
+
+	events := []db.QueryEvent{}
+	latency := hook.NewLatency()
+
+	c.AddQueryHooks(hook.AfterFunc(func(_ context.Context, e *db.QueryEvent) {
+		events = append(events, *e)
+	}), latency)
+
+	role := dto.Role[dto.ID]{Name: "Hooked", Rights: 1}
+
+	id, err := r.Create(ctx, role)
+	assert.Nil(t, err)
+
+	_, err = r.FindBy(ctx, []db.Column{"*"}, squirrel.Eq{"name": "Hooked"})
+	assert.Nil(t, err)
+
+	_, err = c.RepoByName(r.Name()).Delete(ctx, id)
+	assert.Nil(t, err)
+
+	_, err = r.Get(ctx, id)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.Len(t, events, 4)
+
+	assert.Equal(t, "Create", events[0].Method)
+	assert.Equal(t, r.Name(), events[0].Repo)
+	assert.Equal(t, role, events[0].Obj)
+	assert.Equal(t, int64(1), events[0].Rows)
+	assert.Contains(t, events[0].Query, `INSERT INTO "Roles" ("name","rights") VALUES (?,?) RETURNING "id"`)
+
+	assert.Equal(t, "FindBy", events[1].Method)
+	assert.Equal(t, int64(1), events[1].Rows)
+	assert.Equal(t, []any{"Hooked"}, events[1].Args)
+
+	assert.Equal(t, "Delete", events[2].Method)
+	assert.Equal(t, int64(1), events[2].Rows)
+
+	assert.Equal(t, "Get", events[3].Method)
+	assert.ErrorIs(t, events[3].Err, sql.ErrNoRows)
+
+	assert.Equal(t, uint64(4), latency.Snapshot()[r.Name()].Count)
+}
+
+func Test_StmtCache(t *testing.T) {
+	ctx := context.Background()
+
+	dbConn, err := sqlx.Connect("sqlite3", ":memory:")
+	require.Nil(t, err)
+
+	dbConn.SetMaxOpenConns(1)
+
+	t.Cleanup(func() { _ = dbConn.Close() })
+
+	ddl, err := orm.GetCreateTableDDL(dto.Role[dto.ID]{}, orm.DialectSQLite)
+	require.Nil(t, err)
+
+	_, err = dbConn.Exec(ddl)
+	require.Nil(t, err)
+
+	c, conn := connector.NewWithStmtCache(config.New(nil, false, false).WithDialect(dialect.SQLite),
+		zap.NewNop(), dbConn, 0)
+	defer conn.Invalidate()
+
+	r := repo.NewGen[dto.ID, dto.Role[dto.ID]](c)
+
+	for i := 0; i < 3; i++ {
+		id, err := r.Create(ctx, dto.Role[dto.ID]{Name: "Cached", Rights: i})
+		assert.Nil(t, err)
+
+		role, err := r.Get(ctx, id)
+		assert.Nil(t, err)
+		assert.Equal(t, i, role.Rights)
+	}
+
+	s := conn.Stats()
+	assert.Equal(t, uint64(1), s.Misses) // create is executed inside transaction, not cached
+	assert.Equal(t, uint64(2), s.Hits)
+	assert.Equal(t, 1, s.Size)
+}
+
+func Test_UUIDPrimaryKey(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	r := c.Repo(Token{})
+
+	n, err := r.Create(ctx, Token{Name: "int64"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(repo.SerialUnknown), n) // uuid can't be returned as int64
+
+	var uid uuid.UUID
+	assert.Nil(t, r.CreateAndGetID(ctx, Token{Name: "t1"}, &uid))
+	assert.NotEqual(t, uuid.Nil, uid)
+
+	token := Token{}
+	assert.Nil(t, r.Get(ctx, uid, &token))
+	assert.Equal(t, uid, token.UID)
+	assert.Equal(t, "t1", token.Name)
+
+	g := repo.NewGen[uuid.UUID, Token](c)
+
+	given := uuid.New()
+	id, err := g.Create(ctx, Token{UID: given, Name: "t2"})
+	assert.Nil(t, err)
+	assert.Equal(t, given, id)
+
+	cnt, err := g.Update(ctx, id, Token{Name: "t3"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	token, err = g.Get(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, "t3", token.Name)
+
+	tokens, err := g.SelectWithCursorOnPKPagination(ctx, squirrel.Select("*"),
+		db.CursorPaginationParams{Limit: 10, CursorValue: uuid.Nil})
+	assert.Nil(t, err)
+	assert.Len(t, tokens, 3)
+
+	cnt, err = g.Delete(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+}
+
+func Test_CompositePrimaryKey(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	r := c.Repo(Member{})
+
+	_, err := r.Create(ctx, Member{GroupID: 1, UserID: 2, Role: "owner"})
+	assert.Nil(t, err)
+
+	_, err = r.Create(ctx, Member{GroupID: 1, UserID: 3, Role: "guest"})
+	assert.Nil(t, err)
+
+	m := Member{}
+	assert.Nil(t, r.Get(ctx, []any{1, 3}, &m))
+	assert.Equal(t, "guest", m.Role)
+
+	cnt, err := r.Update(ctx, map[string]any{"group_id": 1, "user_id": 3}, Member{Role: "admin"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	assert.Nil(t, c.AutoGet(ctx, &m))
+	assert.Equal(t, "admin", m.Role)
+
+	cnt, err = c.AutoDelete(ctx, Member{GroupID: 1, UserID: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	err = r.Get(ctx, 1, &m)
+	assert.ErrorIs(t, err, db.ErrInvalidPK)
+}
+
+func Test_UpdateFields(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	roleID, err := repo.NewGen[dto.ID, dto.Role[dto.ID]](c).Create(ctx, dto.Role[dto.ID]{Name: "PatchRole"})
+	assert.Nil(t, err)
+
+	r := repo.NewGen[dto.ID, dto.User[dto.ID]](c)
+
+	id, err := r.Create(ctx, dto.User[dto.ID]{Name: "Patch", Email: "patch@mail.com", Password: "p1", RoleID: roleID})
+	assert.Nil(t, err)
+
+	loaded, err := r.Get(ctx, id)
+	assert.Nil(t, err)
+
+	modified := loaded
+	modified.Email = "new@mail.com"
+
+	cnt, err := r.UpdateFields(ctx, id, orm.Diff(loaded, modified))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	cnt, err = r.UpdateFields(ctx, id, orm.Diff(modified, modified)) // nothing changed, no query
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), cnt)
+
+	cnt, err = r.UpdateFields(ctx, id, dto.User[dto.ID]{Name: "Patched", Password: "p2"}, "Name")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	_, err = r.UpdateFields(ctx, id, dto.User[dto.ID]{}, "unknown")
+	assert.ErrorIs(t, err, orm.ErrUnknownUpdateField)
+
+	u, err := r.Get(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, "Patched", u.Name)
+	assert.Equal(t, "new@mail.com", u.Email)
+	assert.Equal(t, "p1", u.Password)
+
+	note := "note"
+	tokens := repo.NewGen[uuid.UUID, Token](c)
+
+	uid, err := tokens.Create(ctx, Token{Name: "t"})
+	assert.Nil(t, err)
+
+	cnt, err = c.Repo(Token{}).UpdateFields(ctx, uid, map[string]any{"note": note})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	token, err := tokens.Get(ctx, uid)
+	assert.Nil(t, err)
+	assert.Equal(t, &note, token.Note)
+
+	withoutNote := token
+	withoutNote.Note = nil
+
+	cnt, err = tokens.UpdateFields(ctx, uid, orm.Diff(token, withoutNote)) // explicit NULL
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), cnt)
+
+	token, err = tokens.Get(ctx, uid)
+	assert.Nil(t, err)
+	assert.Nil(t, token.Note)
+}
+
+type (
+	UserWithRole struct {
+		dto.User[dto.ID]
+		Role *dto.Role[dto.ID] `db:"-" orm_belongs_to:"role_id"`
+	}
+
+	RoleWithUsers struct {
+		dto.Role[dto.ID]
+		Users []dto.User[dto.ID] `db:"-" orm_has_many:"role_id"`
+	}
+
+	AccountWithMember struct {
+		Account
+		Member *Member `db:"-" orm_belongs_to:"version"`
+	}
+)
+
+func Test_Preload(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	roles := repo.NewGen[dto.ID, RoleWithUsers](c)
+	users := repo.NewGen[dto.ID, UserWithRole](c)
+
+	roleIDs := make([]dto.ID, 0, 3)
+	for _, name := range []string{"Admin", "Guest", "Nobody"} {
+		id, err := roles.Create(ctx, RoleWithUsers{Role: dto.Role[dto.ID]{Name: name}})
+		assert.Nil(t, err)
+
+		roleIDs = append(roleIDs, id)
+	}
+
+	for i, name := range []string{"Alice", "Bob", "Carl"} {
+		_, err := users.Create(ctx, UserWithRole{User: dto.User[dto.ID]{Name: name, RoleID: roleIDs[i%2]}})
+		assert.Nil(t, err)
+	}
+
+	queries := []db.Table{}
+	c.AddQueryHooks(hook.AfterFunc(func(_ context.Context, e *db.QueryEvent) { queries = append(queries, e.Repo) }))
+
+	us, err := users.FindBy(ctx, []db.Column{"*"}, nil, db.Preload("Role"))
+	assert.Nil(t, err)
+	assert.Equal(t, []db.Table{"Users", "Roles"}, queries) // select of users + one select of all roles
+	assert.Len(t, us, 3)
+
+	for i, u := range us {
+		assert.NotNil(t, u.Role)
+		assert.Equal(t, roleIDs[i%2], u.Role.ID())
+		assert.Equal(t, u.RoleID, u.Role.ID())
+	}
+
+	rs, err := roles.Select(ctx, squirrel.Select("*").OrderBy("id"), db.Preload("Users"))
+	assert.Nil(t, err)
+	assert.Len(t, rs, 3)
+	assert.Equal(t, []string{"Alice", "Carl"}, []string{rs[0].Users[0].Name, rs[0].Users[1].Name})
+	assert.Len(t, rs[1].Users, 1)
+	assert.NotNil(t, rs[2].Users)
+	assert.Len(t, rs[2].Users, 0)
+
+	_, err = roles.FindBy(ctx, []db.Column{"*"}, nil, db.Preload("Unknown"))
+	assert.ErrorIs(t, err, db.ErrUnknownRelation)
+
+	accounts := repo.NewGen[int64, AccountWithMember](c)
+	_, err = accounts.Create(ctx, AccountWithMember{Account: Account{Email: "a@b.c", Nick: "a"}})
+	require.Nil(t, err)
+
+	_, err = accounts.FindBy(ctx, []db.Column{"*"}, nil, db.Preload("Member"))
+	assert.ErrorIs(t, err, db.ErrCompositePK) // relation to composite pk is rejected
+}
+
+func Test_Filter(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	r := repo.NewGen[dto.ID, dto.Paginator[dto.ID]](c)
+
+	for i := 1; i <= 10; i++ {
+		_, err := r.Create(ctx, dto.Paginator[dto.ID]{Name: fmt.Sprintf("p%d", i%3), N: i})
+		assert.Nil(t, err)
+	}
+
+	q, err := filter.Parse[dto.Paginator[dto.ID]](url.Values{"n__gt": {"5"}, "name__like": {"p1%"}, "sort": {"-n"}})
+	assert.Nil(t, err)
+
+	ps, err := r.Select(ctx, q.Apply(squirrel.Select("*").From(r.Name())))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{10, 7}, []int{ps[0].N, ps[1].N})
+	assert.Len(t, ps, 2)
+
+	n := filter.Field[dto.Paginator[dto.ID]]("n")
+	ps, err = r.FindBy(ctx, []db.Column{"*"}, filter.Or(n.Lte(2), filter.Not(n.Lt(10))))
+	assert.Nil(t, err)
+	assert.Len(t, ps, 3)
+
+	_, err = r.FindBy(ctx, []db.Column{"*"}, filter.Field[dto.Paginator[dto.ID]]("num").Eq(1))
+	assert.ErrorIs(t, err, filter.ErrUnknownColumn)
+}
+
+func Test_JSONAndPointerEmbed(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	r := repo.NewGen[dto.ID, Event](c)
+
+	id, err := r.Create(ctx, Event{Kind: "signup", Payload: map[string]any{"a": 1}, Tags: []string{"x", "y"}})
+	assert.Nil(t, err)
+
+	e, err := r.Get(ctx, id)
+	assert.Nil(t, err)
+	assert.NotNil(t, e.BaseDTO) // nil embedded pointer is allocated on scan
+	assert.Equal(t, id, e.ID())
+	assert.False(t, e.CreatedAt.IsZero())
+	assert.Equal(t, map[string]any{"a": float64(1)}, e.Payload)
+	assert.Equal(t, []string{"x", "y"}, e.Tags)
+	assert.False(t, e.Amount.Valid)
+
+	nilID, err := r.Create(ctx, Event{Kind: "empty", Amount: sql.NullInt64{Int64: 5, Valid: true}})
+	assert.Nil(t, err)
+
+	old := e
+	e.Payload = map[string]any{"b": "c"}
+	n, err := r.UpdateFields(ctx, id, orm.Diff(old, e))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	es, err := r.FindBy(ctx, []db.Column{"*"}, squirrel.Eq{"id": []dto.ID{id, nilID}})
+	assert.Nil(t, err)
+	assert.Len(t, es, 2)
+	assert.Equal(t, map[string]any{"b": "c"}, es[0].Payload)
+	assert.Nil(t, es[1].Payload) // NULL
+	assert.Nil(t, es[1].Tags)
+	assert.Equal(t, int64(5), es[1].Amount.Int64)
+
+	kinds := []string{}
+	assert.Nil(t, r.Iterate(ctx, squirrel.Select("*").From(r.Name()).OrderBy("id"), func(e Event) error {
+		kinds = append(kinds, e.Kind)
+		return nil
+	}))
+	assert.Equal(t, []string{"signup", "empty"}, kinds)
+
+	_, err = r.Get(ctx, 100)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func Test_NamingStrategy(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	authors := repo.NewGen[int64, Author](c)
+	books := repo.NewGen[int64, Book](c)
+
+	id, err := authors.Create(ctx, Author{FullName: "Leo Tolstoy"})
+	assert.Nil(t, err)
+
+	_, err = books.Create(ctx, Book{AuthorID: id, Title: "War and Peace"})
+	assert.Nil(t, err)
+
+	a, err := authors.Get(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, Author{Id: id, FullName: "Leo Tolstoy"}, a)
+
+	bs, err := books.FindBy(ctx, []db.Column{"*"}, squirrel.Eq{"author_id": id})
+	assert.Nil(t, err)
+	assert.Len(t, bs, 1)
+	assert.Equal(t, "War and Peace", bs[0].Title)
+
+	j := repo.Join[AuthorBook](c)
+
+	ab, err := j.FindOneBy(ctx, squirrel.Eq{"a.id": id})
+	assert.Nil(t, err)
+	assert.Equal(t, "Leo Tolstoy", ab.FullName)
+	assert.Equal(t, "War and Peace", ab.Title)
+
+	abs, err := j.FindBy(ctx, squirrel.Eq{"b.author_id": id})
+	assert.Nil(t, err)
+	assert.Len(t, abs, 1)
+	assert.Equal(t, id, abs[0].Book.AuthorID)
+}
+
+func Test_LifecycleHooks(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	r := repo.NewGen[int64, Account](c)
+	members := repo.NewGen[[2]int64, Member](c)
+
+	id, err := r.Create(ctx, Account{Email: " Bob@Example.COM "})
+	assert.Nil(t, err)
+
+	a, err := r.Get(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, Account{Id: id, Email: "bob@example.com", Domain: "example.com"}, a)
+
+	m, err := members.FindOneBy(ctx, []db.Column{"*"}, squirrel.Eq{"user_id": id}) // created by AfterCreate
+	assert.Nil(t, err)
+	assert.Equal(t, "owner", m.Role)
+
+	_, err = r.Create(ctx, Account{Email: " "})
+	assert.ErrorIs(t, err, errForbidden)
+
+	_, err = r.Create(ctx, Account{Email: "rollback@example.com"})
+	assert.ErrorIs(t, err, errForbidden)
+
+	cnt, err := r.CountByQuery(ctx, squirrel.Select("count(1)").From("Accounts"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), cnt) // account of failed AfterCreate is rolled back
+
+	n, err := r.Update(ctx, id, a)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	a, err = r.FindOneBy(ctx, []db.Column{"*"}, squirrel.Eq{"id": id})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), a.Version)
+	assert.Equal(t, "example.com", a.Domain)
+
+	adminID, err := r.Create(ctx, Account{Email: "admin@example.com"})
+	assert.Nil(t, err)
+
+	n, err = r.Delete(ctx, adminID)
+	assert.ErrorIs(t, err, errForbidden)
+	assert.Equal(t, int64(0), n)
+
+	n, err = c.AutoDelete(ctx, &Account{Id: adminID, Email: "admin@example.com"}) // classic path calls hook too
+	assert.ErrorIs(t, err, errForbidden)
+	assert.Equal(t, int64(0), n)
+
+	all, err := r.FindBy(ctx, []db.Column{"*"}, nil) // AfterGet is called for every row of all read methods
+	assert.Nil(t, err)
+	assert.Len(t, all, 2)
+
+	selected, err := r.Select(ctx, squirrel.Select("*").OrderBy("id"))
+	assert.Nil(t, err)
+	all = append(all, selected...)
+
+	assert.Nil(t, r.Iterate(ctx, squirrel.Select("*"), func(a Account) error {
+		all = append(all, a)
+		return nil
+	}))
+
+	var classic []Account
+	assert.Nil(t, c.Repo(Account{}).FindBy(ctx, []db.Column{"*"}, nil, &classic))
+	all = append(all, classic...)
+
+	for _, a := range all {
+		assert.Equal(t, "example.com", a.Domain, a.Email)
+	}
+
+	n, err = r.Delete(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = r.Delete(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), n)
+}
+
+func Test_TransactionOfOtherConnector(t *testing.T) {
+	ctx := context.Background()
+	c1 := newConnectorDSN(t, "file:tx1?mode=memory&cache=shared")
+	c2 := newConnectorDSN(t, "file:tx2?mode=memory&cache=shared")
+
+	r1 := repo.NewGen[int, dto.Role[dto.ID]](c1)
+	r2 := repo.NewGen[int, dto.Role[dto.ID]](c2)
+
+	err := transaction.InTransaction(ctx, c1.Connection(), func(ctx context.Context, _ *sqlx.Tx) error {
+		if _, err := r1.Create(ctx, dto.Role[dto.ID]{Name: "first"}); err != nil {
+			return err
+		}
+
+		if _, err := r2.Create(ctx, dto.Role[dto.ID]{Name: "second"}); err != nil { // not in transaction of c1
+			return err
+		}
+
+		_, ok := transaction.TxFromContextFor(ctx, c1.Connection())
+		assert.True(t, ok)
+
+		_, ok = transaction.TxFromContextFor(ctx, c2.Connection())
+		assert.False(t, ok)
+
+		return errForbidden // rollback of c1 transaction only
+	})
+	assert.ErrorIs(t, err, errForbidden)
+
+	cnt, err := r1.CountByQuery(ctx, squirrel.Select("count(1)").From("Roles"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), cnt)
+
+	roles, err := r2.FindBy(ctx, []db.Column{"*"}, nil)
+	assert.Nil(t, err)
+	require.Len(t, roles, 1)
+	assert.Equal(t, "second", roles[0].Name)
+}
+
+func Test_Validation(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	r := repo.NewGen[int64, Account](c)
+
+	queries := 0
+	c.AddQueryHooks(hook.AfterFunc(func(context.Context, *db.QueryEvent) { queries++ }))
+
+	_, err := r.Create(ctx, Account{Email: "bob@example.com", Nick: "Not Valid Nick"})
+	assert.ErrorIs(t, err, validate.ErrValidation)
+
+	var ve *validate.Error
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, []validate.FieldError{{Field: "Nick", Rule: "max=8", Err: validate.ErrMax}}, ve.Fields)
+
+	id, err := r.Create(ctx, Account{Email: " Bob@Example.com ", Nick: "bob"}) // validated after BeforeCreate
+	assert.Nil(t, err)
+
+	_, err = r.Update(ctx, id, Account{Email: "bob@example.com", Nick: "Bob"})
+	assert.ErrorIs(t, err, validate.ErrPattern)
+	assert.Equal(t, 1, queries) // only insert of valid DTO
+
+	a, err := r.Get(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, "bob@example.com", a.Email)
+	assert.Equal(t, "bob", a.Nick)
+	assert.Equal(t, int64(0), a.Version)
+}
+
+func Test_TenantScope(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	r := repo.NewGen[int64, Note](c)
+	ctx1, ctx2 := tenant.NewContext(ctx, int64(1)), tenant.NewContext(ctx, int64(2))
+
+	_, err := r.Create(ctx, Note{Text: "no tenant"})
+	assert.ErrorIs(t, err, db.ErrNoTenant)
+	_, err = r.FindBy(ctx, []db.Column{"*"}, nil)
+	assert.ErrorIs(t, err, db.ErrNoTenant)
+
+	id1, err := r.Create(ctx1, Note{Text: "a", TenantID: 2}) // tenant of context wins
+	assert.Nil(t, err)
+	_, err = r.Create(ctx2, Note{Text: "b"})
+	assert.Nil(t, err)
+
+	notes, err := r.FindBy(ctx1, []db.Column{"*"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []Note{{Id: id1, TenantID: 1, Text: "a"}}, notes)
+
+	_, err = r.Get(ctx2, id1)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	n, err := r.Update(ctx2, id1, Note{Text: "x"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), n)
+
+	n, err = r.Update(ctx1, id1, Note{Text: "a2", TenantID: 2}) // row can't be moved to other tenant
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	note, err := r.Get(ctx1, id1)
+	assert.Nil(t, err)
+	assert.Equal(t, Note{Id: id1, TenantID: 1, Text: "a2"}, note)
+
+	// conflict key is unique across tenants: row of other tenant is not updated, tenant column is not updated
+	cols := []db.Column{"id", "tenant_id", "text"}
+	n, err = r.Upsert(ctx2, cols, []db.Argument{id1, 2, "y"}, []db.Column{"id"}, []db.Column{"tenant_id", "text"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), n)
+
+	n, err = r.Upsert(ctx1, cols, []db.Argument{id1, 2, "a3"}, []db.Column{"id"}, []db.Column{"tenant_id", "text"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	note, err = r.Get(ctx1, id1)
+	assert.Nil(t, err)
+	assert.Equal(t, Note{Id: id1, TenantID: 1, Text: "a3"}, note)
+
+	n, err = r.Delete(ctx2, id1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), n)
+
+	// repositories of connector are scoped by DTO, RepoByName is not scoped
+	_, err = c.Repo(Note{}).Insert(ctx2, []db.Column{"text"}, []db.Argument{"c"})
+	assert.Nil(t, err)
+
+	cnt, err := c.Repo(Note{}).CountByQuery(ctx2, squirrel.Select("count(1)"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), cnt)
+
+	cnt, err = c.RepoByName("Notes").CountByQuery(ctx, squirrel.Select("count(1)"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), cnt)
+}
+
+func Test_Drift(t *testing.T) {
+	ctx := context.Background()
+	c := newConnector(t)
+
+	reports, err := drift.Check(ctx, c, drift.Config{}, Token{}, Member{}, Note{})
+	assert.Nil(t, err, reports.String())
+	assert.Len(t, reports, 3)
+
+	_, err = c.Connection().ExecContext(ctx, "ALTER TABLE Notes ADD COLUMN extra TEXT")
+	require.Nil(t, err)
+
+	reports, err = drift.Check(ctx, c, drift.Config{}, Note{}, Order{})
+	assert.ErrorIs(t, err, drift.ErrSchemaDrift)
+	assert.Equal(t, []db.Column{"extra"}, reports[0].ExtraColumns)
+	assert.True(t, reports[1].TableMissing)
+}
@@ -0,0 +1,128 @@
+// Package lifecycle - validation and lifecycle hooks of DTO (db.BeforeCreateHook, db.AfterCreateHook,
+// db.BeforeUpdateHook, db.AfterGetHook, db.BeforeDeleteHook), shared by repositories of db/repo and db/fake.
+//
+// Hooks are detected by type assertion of obj, so pass pointer to DTO (see Addressable) for pointer receivers.
+// Errors of hooks are wrapped by name of hook, e.g. "BeforeCreate: <error of hook>".
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/reflect/validate"
+)
+
+// Addressable - pointer to copy of obj if obj is not pointer, so hooks with pointer receiver can be called
+// and orm can walk embedded DTO structs.
+func Addressable(obj any) any {
+	v := reflect.ValueOf(obj)
+	if !v.IsValid() || v.Kind() == reflect.Pointer {
+		return obj
+	}
+
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+
+	return p.Interface()
+}
+
+// Validate - check validate tags of DTO (see reflect/validate), objects which are not structs are not validated.
+func Validate(obj any) error {
+	if err := validate.Struct(obj); err != nil && !errors.Is(err, validate.ErrNotStruct) {
+		return err
+	}
+
+	return nil
+}
+
+// HasCreateHooks - obj implements db.BeforeCreateHook or db.AfterCreateHook.
+func HasCreateHooks(obj any) bool {
+	_, before := obj.(db.BeforeCreateHook)
+	_, after := obj.(db.AfterCreateHook)
+
+	return before || after
+}
+
+func BeforeCreate(ctx context.Context, obj any) error {
+	if h, ok := obj.(db.BeforeCreateHook); ok {
+		if err := h.BeforeCreate(ctx); err != nil {
+			return fmt.Errorf("BeforeCreate: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func AfterCreate(ctx context.Context, obj any) error {
+	if h, ok := obj.(db.AfterCreateHook); ok {
+		if err := h.AfterCreate(ctx); err != nil {
+			return fmt.Errorf("AfterCreate: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func BeforeUpdate(ctx context.Context, obj any) error {
+	if h, ok := obj.(db.BeforeUpdateHook); ok {
+		if err := h.BeforeUpdate(ctx); err != nil {
+			return fmt.Errorf("BeforeUpdate: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func BeforeDelete(ctx context.Context, obj any) error {
+	if h, ok := obj.(db.BeforeDeleteHook); ok {
+		if err := h.BeforeDelete(ctx); err != nil {
+			return fmt.Errorf("BeforeDelete: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func AfterGet(ctx context.Context, obj any) error {
+	if h, ok := obj.(db.AfterGetHook); ok {
+		if err := h.AfterGet(ctx); err != nil {
+			return fmt.Errorf("AfterGet: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// AfterGetAll - AfterGet for every row of target (pointer to []D or []*D).
+func AfterGetAll(ctx context.Context, target any) error {
+	v := reflect.Indirect(reflect.ValueOf(target))
+	if v.Kind() != reflect.Slice || !hasAfterGet(v.Type().Elem()) {
+		return nil
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		row := v.Index(i)
+		if row.Kind() != reflect.Pointer {
+			row = row.Addr()
+		}
+
+		if row.IsNil() {
+			continue
+		}
+
+		if err := AfterGet(ctx, row.Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasAfterGet(t reflect.Type) bool {
+	hook := reflect.TypeOf((*db.AfterGetHook)(nil)).Elem()
+
+	return t.Implements(hook) || (t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(hook))
+}
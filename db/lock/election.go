@@ -0,0 +1,94 @@
+package lock
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// Callbacks - callbacks of leader election.
+	Callbacks struct {
+		// OnElected - called (in own goroutine) when instance becomes leader, ctx is done when leadership is lost,
+		// so work of leader must be stopped on it: lock is held until OnElected returns.
+		OnElected func(ctx context.Context)
+		// OnRevoked - called when leadership is lost (lease is not renewed or Elect is finished), after OnElected
+		// returned.
+		OnRevoked func()
+	}
+)
+
+// Elect - leader election on lease of lock of key: instance which holds lock is leader, it renews lease every TTL/3,
+// other instances (and leader which lost lease) try to acquire lock every RetryInterval, errors of backend are
+// retried the same way. Blocks until ctx is done, lock is released on return.
+func (l *Locker) Elect(ctx context.Context, key string, cb Callbacks) {
+	for {
+		lease, ok, err := l.TryLock(ctx, key)
+		if err == nil && ok {
+			l.lead(ctx, lease, cb)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(l.cfg.RetryInterval):
+		}
+	}
+}
+
+// lead - be leader until lease is lost or ctx is done. Lock is released after OnElected returned, lease is renewed
+// while it is stopping, so other instance is not elected during graceful stop of work of leader (except lease which
+// is lost already).
+func (l *Locker) lead(ctx context.Context, lease Lease, cb Callbacks) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	elected := make(chan struct{})
+
+	go func() {
+		defer close(elected)
+
+		if cb.OnElected != nil {
+			cb.OnElected(leaderCtx)
+		}
+	}()
+
+	defer func() {
+		if cb.OnRevoked != nil {
+			cb.OnRevoked()
+		}
+
+		_ = lease.Unlock(context.Background()) // ctx can be done already, ErrLeaseLost if lease is lost
+	}()
+
+	ticker := time.NewTicker(l.cfg.TTL / 3)
+	defer ticker.Stop()
+
+	lost := false
+	stop, finished := leaderCtx.Done(), elected
+
+	for stop != nil || finished != nil {
+		select {
+		case <-stop: // ctx is done or lease is lost, wait for OnElected
+			stop = nil
+		case <-finished: // OnElected returned, leader stays leader until ctx is done or lease is lost
+			finished = nil
+		case <-ticker.C:
+			if lost {
+				continue
+			}
+
+			if err := l.renew(lease); err != nil {
+				lost = true
+				cancel()
+			}
+		}
+	}
+
+	cancel()
+}
+
+// renew - renew lease, ctx of Elect is not used (lease is renewed during graceful stop of leader).
+func (l *Locker) renew(lease Lease) error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.cfg.TTL)
+	defer cancel()
+
+	return lease.Renew(ctx, l.cfg.TTL)
+}
@@ -0,0 +1,110 @@
+// Package lock - distributed locks and leader election over database.
+//
+// Backend acquires leases of locks by key: Postgres (advisory locks, NewPostgres) or memory (NewMemory, for tests
+// and single process). Locker gives TryLock and blocking Lock, Elect runs leader election on lease of lock:
+//
+//	locker := lock.New(lock.NewPostgres(dbConn), lock.Config{}) // dbConn - *sqlx.DB
+//
+//	lease, ok, err := locker.TryLock(ctx, "cron:report") // only one replica runs the job
+//	if err == nil && ok {
+//		defer lease.Unlock(ctx)
+//		runJob(ctx)
+//	}
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+const (
+	DefaultTTL           = 15 * time.Second
+	DefaultRetryInterval = time.Second
+)
+
+type (
+	// Backend - storage of locks.
+	Backend interface {
+		// TryLock - acquire lease of lock of key for ttl without waiting, ErrNotAcquired if lock is held by other.
+		TryLock(ctx context.Context, key string, ttl time.Duration) (Lease, error)
+	}
+
+	// Lease - acquired lock.
+	Lease interface {
+		// Renew - extend lease for ttl, ErrLeaseLost if lock is lost (lease is expired, connection is broken).
+		Renew(ctx context.Context, ttl time.Duration) error
+		// Unlock - release lock, ErrLeaseLost if lock was lost before.
+		Unlock(ctx context.Context) error
+	}
+
+	// Config - settings of Locker, zero values are replaced by defaults.
+	Config struct {
+		TTL           time.Duration // lease of lock (memory backend), leader renews lease every TTL/3
+		RetryInterval time.Duration // pause between attempts of Lock and Elect
+	}
+
+	// Locker - locks of Backend.
+	Locker struct {
+		backend Backend
+		cfg     Config
+	}
+)
+
+var (
+	ErrNotAcquired = errors.New("lock is held by other")
+	ErrLeaseLost   = errors.New("lease of lock is lost")
+)
+
+// New - locker of backend.
+func New(backend Backend, cfg Config) *Locker {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = DefaultRetryInterval
+	}
+
+	return &Locker{backend: backend, cfg: cfg}
+}
+
+// TryLock - acquire lock of key without waiting, ok == false if lock is held by other.
+func (l *Locker) TryLock(ctx context.Context, key string) (Lease, bool, error) {
+	lease, err := l.backend.TryLock(ctx, key, l.cfg.TTL)
+	if errors.Is(err, ErrNotAcquired) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, fmt.Errorf("[lock.TryLock] %s: %w", key, err)
+	}
+
+	return lease, true, nil
+}
+
+// Lock - acquire lock of key, wait (attempt every RetryInterval) until lock is released by other or ctx is done.
+func (l *Locker) Lock(ctx context.Context, key string) (Lease, error) {
+	for {
+		lease, ok, err := l.TryLock(ctx, key)
+		if err != nil || ok {
+			return lease, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("[lock.Lock] %s: %w", key, ctx.Err())
+		case <-time.After(l.cfg.RetryInterval):
+		}
+	}
+}
+
+// Key - int64 key of advisory lock for string key (FNV-1a hash).
+func Key(key string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+
+	return int64(h.Sum64())
+}
@@ -0,0 +1,142 @@
+package lock
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Locker(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemory()
+	l := New(mem, Config{TTL: time.Minute, RetryInterval: 5 * time.Millisecond})
+
+	lease, ok, err := l.TryLock(ctx, "job")
+	require.Nil(t, err)
+	assert.True(t, ok)
+
+	_, ok, err = l.TryLock(ctx, "job")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	other, ok, err := l.TryLock(ctx, "other")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, other.Unlock(ctx))
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+
+	_, err = l.Lock(timeoutCtx, "job")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		assert.Nil(t, lease.Unlock(ctx))
+	}()
+
+	lease2, err := l.Lock(ctx, "job") // waits for unlock
+	assert.Nil(t, err)
+	assert.ErrorIs(t, lease.Unlock(ctx), ErrLeaseLost)
+	assert.ErrorIs(t, lease.Renew(ctx, time.Minute), ErrLeaseLost)
+	assert.Nil(t, lease2.Renew(ctx, time.Minute))
+
+	mem.Revoke("job")
+	assert.ErrorIs(t, lease2.Renew(ctx, time.Minute), ErrLeaseLost)
+
+	assert.Equal(t, Key("job"), Key("job"))
+	assert.NotEqual(t, Key("job"), Key("other"))
+}
+
+func Test_MemoryExpiration(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	mem := NewMemory()
+	mem.now = func() time.Time { return now }
+
+	lease, err := mem.TryLock(ctx, "job", time.Second)
+	require.Nil(t, err)
+
+	now = now.Add(500 * time.Millisecond)
+	assert.Nil(t, lease.Renew(ctx, time.Second)) // expires in now + 1s
+
+	now = now.Add(900 * time.Millisecond)
+	_, err = mem.TryLock(ctx, "job", time.Second)
+	assert.ErrorIs(t, err, ErrNotAcquired)
+
+	now = now.Add(200 * time.Millisecond)
+	_, err = mem.TryLock(ctx, "job", time.Second) // lease is expired
+	assert.Nil(t, err)
+	assert.ErrorIs(t, lease.Renew(ctx, time.Second), ErrLeaseLost)
+}
+
+func Test_Elect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	mem := NewMemory()
+
+	var elected, revoked, overlapped int32
+
+	// callbacks of instance, work of leader is stopped gracefully (after ctx is done)
+	callbacks := func() Callbacks {
+		var running int32
+
+		return Callbacks{
+			OnElected: func(leaderCtx context.Context) {
+				atomic.AddInt32(&elected, 1)
+				atomic.StoreInt32(&running, 1)
+
+				<-leaderCtx.Done()
+				time.Sleep(50 * time.Millisecond) // longer than TTL, lease is renewed during stop
+
+				if ctx.Err() != nil { // shutdown (not revoked lease): lock is held until work is stopped
+					if lease, err := mem.TryLock(context.Background(), "leader", time.Second); err == nil {
+						atomic.AddInt32(&overlapped, 1)
+						_ = lease.Unlock(context.Background())
+					}
+				}
+
+				atomic.StoreInt32(&running, 0)
+			},
+			OnRevoked: func() {
+				if atomic.LoadInt32(&running) != 0 {
+					atomic.AddInt32(&overlapped, 1)
+				}
+
+				atomic.AddInt32(&revoked, 1)
+			},
+		}
+	}
+
+	done := make(chan struct{}, 2)
+
+	for i := 0; i < 2; i++ {
+		l, cb := New(mem, Config{TTL: 30 * time.Millisecond, RetryInterval: 5 * time.Millisecond}), callbacks()
+		go func() {
+			l.Elect(ctx, "leader", cb)
+			done <- struct{}{}
+		}()
+	}
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&elected) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&elected)) // leader renews lease, other instance waits
+
+	mem.Revoke("leader") // leader loses lease on renew, one of instances is elected again
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&elected) == 2 && atomic.LoadInt32(&revoked) == 1
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+	<-done
+	assert.Equal(t, int32(2), atomic.LoadInt32(&revoked))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&overlapped)) // OnRevoked and unlock after OnElected returned
+
+	_, err := mem.TryLock(context.Background(), "leader", time.Second) // released on return
+	assert.Nil(t, err)
+}
@@ -0,0 +1,80 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// Memory - backend of locks in memory of process (for tests and single instance), leases expire after ttl.
+	Memory struct {
+		m      sync.Mutex
+		leases map[string]*memoryLease
+		now    func() time.Time
+	}
+
+	memoryLease struct {
+		mem     *Memory
+		key     string
+		expires time.Time
+	}
+)
+
+// NewMemory - memory backend of locks.
+func NewMemory() *Memory {
+	return &Memory{leases: map[string]*memoryLease{}, now: time.Now}
+}
+
+func (m *Memory) TryLock(_ context.Context, key string, ttl time.Duration) (Lease, error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	if l, found := m.leases[key]; found && m.now().Before(l.expires) {
+		return nil, ErrNotAcquired
+	}
+
+	l := &memoryLease{mem: m, key: key, expires: m.now().Add(ttl)}
+	m.leases[key] = l
+
+	return l, nil
+}
+
+// Revoke - release lock of key by force (e.g. for test of lost lease), holder gets ErrLeaseLost on Renew.
+func (m *Memory) Revoke(key string) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	delete(m.leases, key)
+}
+
+// held - lease is current lease of key and it is not expired (m.m must be locked).
+func (l *memoryLease) held() bool {
+	return l.mem.leases[l.key] == l && l.mem.now().Before(l.expires)
+}
+
+func (l *memoryLease) Renew(_ context.Context, ttl time.Duration) error {
+	l.mem.m.Lock()
+	defer l.mem.m.Unlock()
+
+	if !l.held() {
+		return ErrLeaseLost
+	}
+
+	l.expires = l.mem.now().Add(ttl)
+
+	return nil
+}
+
+func (l *memoryLease) Unlock(context.Context) error {
+	l.mem.m.Lock()
+	defer l.mem.m.Unlock()
+
+	if !l.held() {
+		return ErrLeaseLost
+	}
+
+	delete(l.mem.leases, l.key)
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package lock
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	// Pool - pool of connections which pins one connection (e.g. *sqlx.DB).
+	Pool interface {
+		Connx(context.Context) (*sqlx.Conn, error)
+	}
+
+	postgres struct {
+		pool Pool
+	}
+
+	// postgresLease - session advisory lock, connection of session is pinned by lease.
+	postgresLease struct {
+		conn *sqlx.Conn
+		key  int64
+	}
+)
+
+// NewPostgres - backend of Postgres advisory locks (pg_try_advisory_lock of Key(key)). Lock is held by session,
+// so every lease pins own connection of pool until Unlock, ttl is ignored: lock is released by Unlock or when
+// connection is closed (e.g. process dies), Renew checks that connection is alive. If unlock fails, connection
+// is closed instead of returning to pool (advisory locks are re-entrant per session, so lock would be "acquired"
+// by any later TryLock on this connection).
+func NewPostgres(pool Pool) Backend {
+	return postgres{pool: pool}
+}
+
+func (p postgres) TryLock(ctx context.Context, key string, _ time.Duration) (Lease, error) {
+	conn, err := p.pool.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("pool.Connx: %w", err)
+	}
+
+	l := &postgresLease{conn: conn, key: Key(key)}
+
+	var acquired bool
+	if err = conn.QueryRowxContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&acquired); err != nil {
+		l.discard() // lock may be acquired by session (e.g. ctx is done after query)
+
+		return nil, fmt.Errorf("pg_try_advisory_lock: %w", err)
+	}
+
+	if !acquired {
+		_ = conn.Close()
+
+		return nil, ErrNotAcquired
+	}
+
+	return l, nil
+}
+
+func (l *postgresLease) Renew(ctx context.Context, _ time.Duration) error {
+	if _, err := l.conn.ExecContext(ctx, "SELECT 1"); err != nil {
+		return fmt.Errorf("%w: %v", ErrLeaseLost, err)
+	}
+
+	return nil
+}
+
+func (l *postgresLease) Unlock(ctx context.Context) error {
+	var released bool
+	if err := l.conn.QueryRowxContext(ctx, "SELECT pg_advisory_unlock($1)", l.key).Scan(&released); err != nil {
+		l.discard()
+
+		return fmt.Errorf("%w: %v", ErrLeaseLost, err)
+	}
+
+	if !released {
+		l.discard()
+
+		return ErrLeaseLost
+	}
+
+	return l.conn.Close()
+}
+
+// discard - close connection of lease (not return it to pool), session locks are released by database.
+func (l *postgresLease) discard() {
+	_ = l.conn.Raw(func(any) error { return driver.ErrBadConn }) // bad connection is closed by database/sql
+}
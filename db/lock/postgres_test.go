@@ -0,0 +1,97 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// advisory locks of Postgres emulated by functions of sqlite, session is connection.
+var advisory = struct {
+	m       sync.Mutex
+	holders map[int64]*sqlite3.SQLiteConn
+}{holders: map[int64]*sqlite3.SQLiteConn{}}
+
+func init() {
+	sql.Register("sqlite3_advisory", &sqlite3.SQLiteDriver{ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+		if err := conn.RegisterFunc("pg_try_advisory_lock", func(key int64) bool {
+			advisory.m.Lock()
+			defer advisory.m.Unlock()
+
+			if holder, found := advisory.holders[key]; found && holder != conn {
+				return false
+			}
+
+			advisory.holders[key] = conn
+
+			return true
+		}, false); err != nil {
+			return err
+		}
+
+		return conn.RegisterFunc("pg_advisory_unlock", func(key int64) bool {
+			advisory.m.Lock()
+			defer advisory.m.Unlock()
+
+			if advisory.holders[key] != conn {
+				return false
+			}
+
+			delete(advisory.holders, key)
+
+			return true
+		}, false)
+	}})
+}
+
+func Test_Postgres(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := sqlx.Connect("sqlite3_advisory", "file::memory:")
+	require.Nil(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	l := New(NewPostgres(conn), Config{})
+
+	lease, ok, err := l.TryLock(ctx, "job")
+	require.Nil(t, err)
+	assert.True(t, ok)
+
+	_, ok, err = l.TryLock(ctx, "job") // other connection of pool (session)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	other, ok, err := l.TryLock(ctx, "other")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	assert.Nil(t, lease.Renew(ctx, 0))
+	assert.Nil(t, lease.Unlock(ctx))
+	assert.ErrorIs(t, lease.Unlock(ctx), ErrLeaseLost)
+	assert.ErrorIs(t, lease.Renew(ctx, 0), ErrLeaseLost) // connection is returned to pool
+
+	lease, ok, err = l.TryLock(ctx, "job")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Nil(t, lease.Unlock(ctx))
+	assert.Nil(t, other.Unlock(ctx))
+
+	// unlock is failed (ctx is done): connection which holds session lock is closed, not returned to pool
+	lease, ok, err = l.TryLock(ctx, "failed")
+	require.Nil(t, err)
+	require.True(t, ok)
+
+	open := conn.Stats().OpenConnections
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	assert.ErrorIs(t, lease.Unlock(cancelled), ErrLeaseLost)
+	assert.Equal(t, open-1, conn.Stats().OpenConnections)
+}
@@ -0,0 +1,597 @@
+// Package migrate - versioned up/down sql migrations runner for any db.PureSqlxConnection.
+//
+// Migrations are read from fs.FS (embed.FS friendly), file name format: <version>_<name>.(up|down).sql, e.g.:
+//
+//	0001_create_roles.up.sql
+//	0001_create_roles.down.sql
+//	0002_create_users.up.sql
+//
+// Applied migrations are stored in schema_migrations table (version, name, checksum, applied_at).
+// Every migration is applied in own transaction. Up and Down hold session pg_advisory_lock on one connection
+// of pool (connection must have Connx method, e.g. *sqlx.DB) for the whole run, so concurrent runners are serialized.
+// Other connections have only pg_advisory_xact_lock of every migration: concurrent runners never apply one migration
+// twice, but they can interleave (e.g. Up of one runner and Down of another).
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/transaction"
+)
+
+type (
+	// Config - configuration of Migrator.
+	Config struct {
+		TableName         db.Table             // table for store applied migrations, default DefaultTableName
+		LockID            int64                // key of advisory locks, default DefaultLockID
+		DisableLock       bool                 // disable advisory lock (for not Postgres db)
+		DryRun            bool                 // do not execute anything, only report what will be done
+		IgnoreChecksum    bool                 // do not fail on checksum mismatch of already applied migrations
+		PlaceholderFormat db.PlaceholderFormat // default squirrel.Dollar
+	}
+
+	// Migration - one versioned migration.
+	Migration struct {
+		Version  uint64
+		Name     string
+		Up       db.Query
+		Down     db.Query // could be empty, then migration is irreversible
+		Checksum string   // sha256 of Up query
+	}
+
+	// Status - state of migration.
+	Status struct {
+		Migration
+		Applied          bool
+		AppliedAt        time.Time
+		ChecksumMismatch bool // applied migration was changed after apply
+		Missing          bool // migration applied in db, but absent in fs
+	}
+
+	// Migrator - migrations runner.
+	Migrator interface {
+		// Migrations - list of all migrations from fs, sorted by version
+		Migrations() []Migration
+
+		// Up - apply all pending migrations, return applied (or planned in DryRun mode) migrations
+		Up(context.Context) ([]Migration, error)
+		// UpTo - apply pending migrations with version <= target version
+		UpTo(context.Context, uint64) ([]Migration, error)
+		// Down - revert last n applied migrations, return reverted (or planned in DryRun mode) migrations
+		Down(context.Context, int) ([]Migration, error)
+
+		// Status - status of all known migrations (from fs and from db), sorted by version, read-only (as DryRun)
+		Status(context.Context) ([]Status, error)
+	}
+
+	migrator struct {
+		cfg        Config
+		logger     db.Logger
+		dbConn     db.PureSqlxConnection
+		migrations []Migration
+	}
+
+	// conn - part of db.PureSqlxConnection used by migrator, *sqlx.Conn of session lock implements it too.
+	conn interface {
+		sqlx.QueryerContext
+		sqlx.ExecerContext
+		transaction.TxxI
+	}
+
+	// connxer - connection pool which gives one connection of it (*sqlx.DB).
+	connxer interface {
+		Connx(context.Context) (*sqlx.Conn, error)
+	}
+
+	// sqlStater - error of driver with SQLSTATE code (pgx, lib/pq).
+	sqlStater interface {
+		SQLState() string
+	}
+
+	appliedMigration struct {
+		Version   uint64    `db:"version"`
+		Name      string    `db:"name"`
+		Checksum  string    `db:"checksum"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+)
+
+const (
+	DefaultTableName       = "schema_migrations"
+	DefaultLockID    int64 = 7_135_221_890_431 // random constant, just uniq key for pg_advisory_lock
+
+	sqlStateUndefinedTable = "42P01" // Postgres
+	sqlStateNoSuchTable    = "42S02" // ODBC, MySQL
+
+	directionUp   = "up"
+	directionDown = "down"
+)
+
+var (
+	ErrBadFileName        = errors.New("bad migration file name, expected <version>_<name>.(up|down).sql")
+	ErrDuplicateVersion   = errors.New("duplicate migration version")
+	ErrNoUpMigration      = errors.New("migration has not up sql file")
+	ErrNoDownMigration    = errors.New("migration has not down sql file (irreversible)")
+	ErrChecksumMismatch   = errors.New("checksum mismatch, applied migration was changed")
+	ErrMissingMigration   = errors.New("applied migration is absent in migrations fs")
+	ErrNegativeStepsCount = errors.New("negative steps count")
+
+	fileNameRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+)
+
+// New - create Migrator, parse migrations from fsys root (use fs.Sub for nested directory).
+func New(logger db.Logger, dbConn db.PureSqlxConnection, fsys fs.FS, cfg Config) (Migrator, error) {
+	migrations, err := Parse(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("[migrate.New] %w", err)
+	}
+
+	if cfg.TableName == "" {
+		cfg.TableName = DefaultTableName
+	}
+
+	if cfg.LockID == 0 {
+		cfg.LockID = DefaultLockID
+	}
+
+	if cfg.PlaceholderFormat == nil {
+		cfg.PlaceholderFormat = squirrel.Dollar
+	}
+
+	return &migrator{
+		cfg:        cfg,
+		logger:     logger,
+		dbConn:     dbConn,
+		migrations: migrations,
+	}, nil
+}
+
+// Parse - read migrations from fsys root, return migrations sorted by version.
+func Parse(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("fs.ReadDir: %w", err)
+	}
+
+	byVersion := map[uint64]*Migration{}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		parts := fileNameRegexp.FindStringSubmatch(e.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), ErrBadFileName)
+		}
+
+		version, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), ErrBadFileName)
+		}
+
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("fs.ReadFile %s: %w", e.Name(), err)
+		}
+
+		m, found := byVersion[version]
+		if !found {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != parts[2] {
+			return nil, fmt.Errorf("%s: %w", e.Name(), ErrDuplicateVersion)
+		}
+
+		switch parts[3] {
+		case directionUp:
+			if m.Up != "" {
+				return nil, fmt.Errorf("%s: %w", e.Name(), ErrDuplicateVersion)
+			}
+			m.Up, m.Checksum = string(body), Checksum(string(body))
+		case directionDown:
+			if m.Down != "" {
+				return nil, fmt.Errorf("%s: %w", e.Name(), ErrDuplicateVersion)
+			}
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("version %d: %w", m.Version, ErrNoUpMigration)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Checksum - sha256 hex of migration sql.
+func Checksum(query db.Query) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *migrator) Migrations() []Migration {
+	return append([]Migration(nil), m.migrations...)
+}
+
+func (m *migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.up(ctx, "[migrate.Up]", ^uint64(0))
+}
+
+func (m *migrator) UpTo(ctx context.Context, version uint64) ([]Migration, error) {
+	return m.up(ctx, "[migrate.UpTo]", version)
+}
+
+func (m *migrator) up(ctx context.Context, method string, target uint64) (done []Migration, err error) {
+	m.logger.Info(method, zap.Uint64("target", target), zap.Bool("dry_run", m.cfg.DryRun))
+
+	err = m.locked(ctx, method, func(c conn) error {
+		done, err = m.upLocked(ctx, c, method, target)
+
+		return err
+	})
+
+	return done, err
+}
+
+func (m *migrator) upLocked(ctx context.Context, c conn, method string, target uint64) ([]Migration, error) {
+	if !m.cfg.DryRun {
+		if err := m.createTable(ctx, c); err != nil {
+			return nil, fmt.Errorf("%s %w", method, err)
+		}
+	}
+
+	applied, err := m.applied(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", method, err)
+	}
+
+	pending, err := planUp(m.migrations, applied, target, m.cfg.IgnoreChecksum)
+	if err != nil {
+		return nil, fmt.Errorf("%s %w", method, err)
+	}
+
+	if m.cfg.DryRun {
+		for _, mg := range pending {
+			m.logger.Info(method+" dry run", zap.Uint64("version", mg.Version), zap.String("sql", mg.Up))
+		}
+
+		return pending, nil
+	}
+
+	done := make([]Migration, 0, len(pending))
+	for _, mg := range pending {
+		if err = m.apply(ctx, c, mg, directionUp); err != nil {
+			return done, fmt.Errorf("%s version %d (%s): %w", method, mg.Version, mg.Name, err)
+		}
+
+		m.logger.Info(method+" applied", zap.Uint64("version", mg.Version), zap.String("name", mg.Name))
+		done = append(done, mg)
+	}
+
+	return done, nil
+}
+
+func (m *migrator) Down(ctx context.Context, steps int) (done []Migration, err error) {
+	m.logger.Info("[migrate.Down]", zap.Int("steps", steps), zap.Bool("dry_run", m.cfg.DryRun))
+
+	if steps < 0 {
+		return nil, ErrNegativeStepsCount
+	}
+
+	err = m.locked(ctx, "[migrate.Down]", func(c conn) error {
+		done, err = m.downLocked(ctx, c, steps)
+
+		return err
+	})
+
+	return done, err
+}
+
+func (m *migrator) downLocked(ctx context.Context, c conn, steps int) ([]Migration, error) {
+	if !m.cfg.DryRun {
+		if err := m.createTable(ctx, c); err != nil {
+			return nil, fmt.Errorf("[migrate.Down] %w", err)
+		}
+	}
+
+	applied, err := m.applied(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("[migrate.Down] %w", err)
+	}
+
+	pending, err := planDown(m.migrations, applied, steps)
+	if err != nil {
+		return nil, fmt.Errorf("[migrate.Down] %w", err)
+	}
+
+	if m.cfg.DryRun {
+		for _, mg := range pending {
+			m.logger.Info("[migrate.Down] dry run", zap.Uint64("version", mg.Version), zap.String("sql", mg.Down))
+		}
+
+		return pending, nil
+	}
+
+	done := make([]Migration, 0, len(pending))
+	for _, mg := range pending {
+		if err = m.apply(ctx, c, mg, directionDown); err != nil {
+			return done, fmt.Errorf("[migrate.Down] version %d (%s): %w", mg.Version, mg.Name, err)
+		}
+
+		m.logger.Info("[migrate.Down] reverted", zap.Uint64("version", mg.Version), zap.String("name", mg.Name))
+		done = append(done, mg)
+	}
+
+	return done, nil
+}
+
+func (m *migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx, m.dbConn)
+	if err != nil {
+		return nil, fmt.Errorf("[migrate.Status] %w", err)
+	}
+
+	return status(m.migrations, applied), nil
+}
+
+// locked - call fn with connection under session advisory lock (see package doc), without lock if it is disabled,
+// in DryRun mode or connection can't give one connection of pool.
+func (m *migrator) locked(ctx context.Context, method string, fn func(conn) error) error {
+	pool, ok := m.dbConn.(connxer)
+	if m.cfg.DisableLock || m.cfg.DryRun || !ok {
+		return fn(m.dbConn)
+	}
+
+	c, err := pool.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("%s connection for lock: %w", method, err)
+	}
+	defer c.Close()
+
+	if _, err = c.ExecContext(ctx, "SELECT pg_advisory_lock($1)", m.cfg.LockID); err != nil {
+		return fmt.Errorf("%s pg_advisory_lock: %w", method, err)
+	}
+
+	defer func() { // lock is released with connection (session) anyway, if unlock failed
+		if _, err := c.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", m.cfg.LockID); err != nil {
+			m.logger.Error(method+" pg_advisory_unlock", zap.Error(err))
+		}
+	}()
+
+	return fn(c)
+}
+
+// apply - execute migration in own transaction with advisory lock,
+// applied state is rechecked after lock, so concurrent runner just skip already applied migration.
+func (m *migrator) apply(ctx context.Context, c conn, mg Migration, direction string) error {
+	return transaction.WithTransaction(ctx, nil, c, func(tx *sqlx.Tx) error {
+		if !m.cfg.DisableLock {
+			if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", m.cfg.LockID); err != nil {
+				return fmt.Errorf("pg_advisory_xact_lock: %w", err)
+			}
+		}
+
+		query, args, err := squirrel.Select("count(1)").From(m.cfg.TableName).
+			Where(squirrel.Eq{"version": mg.Version}).PlaceholderFormat(m.cfg.PlaceholderFormat).ToSql()
+		if err != nil {
+			return fmt.Errorf("squirrel: %w", err)
+		}
+
+		cnt := 0
+		if err = tx.QueryRowxContext(ctx, query, args...).Scan(&cnt); err != nil {
+			return fmt.Errorf("check applied: %w", err)
+		}
+
+		if (direction == directionUp) == (cnt > 0) {
+			return nil // already done by concurrent runner
+		}
+
+		sb := squirrel.StatementBuilder.PlaceholderFormat(m.cfg.PlaceholderFormat)
+
+		var record squirrel.Sqlizer
+		if direction == directionUp {
+			query = mg.Up
+			record = sb.Insert(m.cfg.TableName).Columns("version", "name", "checksum", "applied_at").
+				Values(mg.Version, mg.Name, mg.Checksum, time.Now().UTC())
+		} else {
+			query = mg.Down
+			record = sb.Delete(m.cfg.TableName).Where(squirrel.Eq{"version": mg.Version})
+		}
+
+		if _, err = tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("exec %s sql: %w", direction, err)
+		}
+
+		query, args, err = record.ToSql()
+		if err != nil {
+			return fmt.Errorf("squirrel: %w", err)
+		}
+
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("store migration state: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// createTable - create table of applied migrations if not exists (Up and Down only, Status and DryRun are read-only).
+func (m *migrator) createTable(ctx context.Context, c conn) error {
+	if _, err := c.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s
+(
+version      BIGINT      PRIMARY KEY,
+name         TEXT        NOT NULL,
+checksum     TEXT        NOT NULL,
+applied_at   TIMESTAMP   NOT NULL
+);`, m.cfg.TableName)); err != nil {
+		return fmt.Errorf("create %s table: %w", m.cfg.TableName, err)
+	}
+
+	return nil
+}
+
+// applied - read-only select of applied migrations, absent table means nothing is applied yet.
+func (m *migrator) applied(ctx context.Context, c conn) (map[uint64]appliedMigration, error) {
+	query, args, err := squirrel.Select("version", "name", "checksum", "applied_at").
+		From(m.cfg.TableName).PlaceholderFormat(m.cfg.PlaceholderFormat).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("squirrel: %w", err)
+	}
+
+	rows := make([]appliedMigration, 0)
+	if err = sqlx.SelectContext(ctx, c, &rows, query, args...); err != nil {
+		if isMissingTableError(err) {
+			return map[uint64]appliedMigration{}, nil
+		}
+
+		return nil, fmt.Errorf("select applied migrations: %w", err)
+	}
+
+	applied := make(map[uint64]appliedMigration, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+
+	return applied, nil
+}
+
+// isMissingTableError - "table does not exist" error: SQLSTATE of driver error (pgx, lib/pq), error number of MySQL
+// driver and message of SQLite driver (they have not SQLState method, SQLite has not specific code for it).
+func isMissingTableError(err error) bool {
+	var se sqlStater
+	if errors.As(err, &se) {
+		return se.SQLState() == sqlStateUndefinedTable || se.SQLState() == sqlStateNoSuchTable
+	}
+
+	msg := err.Error()
+
+	return strings.HasPrefix(msg, "Error 1146") || // MySQL ER_NO_SUCH_TABLE
+		strings.HasPrefix(msg, "no such table: ") // SQLite
+}
+
+func planUp(
+	migrations []Migration, applied map[uint64]appliedMigration, target uint64, ignoreChecksum bool,
+) ([]Migration, error) {
+	known := make(map[uint64]bool, len(migrations))
+	pending := make([]Migration, 0)
+
+	for _, mg := range migrations {
+		known[mg.Version] = true
+
+		a, found := applied[mg.Version]
+		if found {
+			if !ignoreChecksum && a.Checksum != mg.Checksum {
+				return nil, fmt.Errorf("version %d (%s): %w", mg.Version, mg.Name, ErrChecksumMismatch)
+			}
+
+			continue
+		}
+
+		if mg.Version <= target {
+			pending = append(pending, mg)
+		}
+	}
+
+	for v := range applied {
+		if !known[v] {
+			return nil, fmt.Errorf("version %d: %w", v, ErrMissingMigration)
+		}
+	}
+
+	return pending, nil
+}
+
+func planDown(migrations []Migration, applied map[uint64]appliedMigration, steps int) ([]Migration, error) {
+	if steps > len(applied) {
+		steps = len(applied)
+	}
+
+	pending := make([]Migration, 0, steps)
+
+	byVersion := make(map[uint64]Migration, len(migrations))
+	for _, mg := range migrations {
+		byVersion[mg.Version] = mg
+	}
+
+	versions := make([]uint64, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	for _, v := range versions {
+		if len(pending) == steps {
+			break
+		}
+
+		mg, found := byVersion[v]
+		if !found {
+			return nil, fmt.Errorf("version %d: %w", v, ErrMissingMigration)
+		}
+
+		if mg.Down == "" {
+			return nil, fmt.Errorf("version %d (%s): %w", mg.Version, mg.Name, ErrNoDownMigration)
+		}
+
+		pending = append(pending, mg)
+	}
+
+	return pending, nil
+}
+
+func status(migrations []Migration, applied map[uint64]appliedMigration) []Status {
+	statuses := make([]Status, 0, len(migrations))
+	known := make(map[uint64]bool, len(migrations))
+
+	for _, mg := range migrations {
+		known[mg.Version] = true
+
+		s := Status{Migration: mg}
+		if a, found := applied[mg.Version]; found {
+			s.Applied, s.AppliedAt, s.ChecksumMismatch = true, a.AppliedAt, a.Checksum != mg.Checksum
+		}
+
+		statuses = append(statuses, s)
+	}
+
+	for v, a := range applied {
+		if !known[v] {
+			statuses = append(statuses, Status{
+				Migration: Migration{Version: a.Version, Name: a.Name, Checksum: a.Checksum},
+				Applied:   true,
+				AppliedAt: a.AppliedAt,
+				Missing:   true,
+			})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses
+}
@@ -0,0 +1,271 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "ERROR: " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+// locks - calls of advisory lock functions registered in sqlite driver "sqlite3_locks" (see Test_SessionLock).
+var locks []string
+
+func init() {
+	sql.Register("sqlite3_locks", &sqlite3.SQLiteDriver{ConnectHook: func(c *sqlite3.SQLiteConn) error {
+		for _, name := range []string{"pg_advisory_lock", "pg_advisory_unlock", "pg_advisory_xact_lock"} {
+			name := name
+			if err := c.RegisterFunc(name, func(id int64) int64 {
+				locks = append(locks, fmt.Sprint(name, "(", id, ")"))
+
+				return id
+			}, false); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}})
+}
+
+var testFS = fstest.MapFS{
+	"0002_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INT);")},
+	"0002_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	"0001_create_roles.up.sql":   {Data: []byte("CREATE TABLE roles (id INT);")},
+	"0001_create_roles.down.sql": {Data: []byte("DROP TABLE roles;")},
+	"10_irreversible.up.sql":     {Data: []byte("INSERT INTO roles VALUES (1);")},
+	"nested/skip_me.txt":         {Data: []byte("directories are skipped")},
+}
+
+func Test_Parse(t *testing.T) {
+	migrations, err := Parse(testFS)
+	assert.Nil(t, err)
+	assert.Equal(t, []Migration{
+		{
+			Version:  1,
+			Name:     "create_roles",
+			Up:       "CREATE TABLE roles (id INT);",
+			Down:     "DROP TABLE roles;",
+			Checksum: Checksum("CREATE TABLE roles (id INT);"),
+		},
+		{
+			Version:  2,
+			Name:     "create_users",
+			Up:       "CREATE TABLE users (id INT);",
+			Down:     "DROP TABLE users;",
+			Checksum: Checksum("CREATE TABLE users (id INT);"),
+		},
+		{
+			Version:  10,
+			Name:     "irreversible",
+			Up:       "INSERT INTO roles VALUES (1);",
+			Checksum: Checksum("INSERT INTO roles VALUES (1);"),
+		},
+	}, migrations)
+
+	m, err := New(zap.NewNop(), nil, testFS, Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, migrations, m.Migrations())
+	assert.Equal(t, Config{
+		TableName:         DefaultTableName,
+		LockID:            DefaultLockID,
+		PlaceholderFormat: squirrel.Dollar,
+	}, m.(*migrator).cfg)
+}
+
+func Test_Parse_Negative(t *testing.T) {
+	tests := []struct {
+		fs  fstest.MapFS
+		err error
+	}{
+		{fs: fstest.MapFS{"create_users.up.sql": {}}, err: ErrBadFileName},
+		{fs: fstest.MapFS{"0001_create_users.sql": {}}, err: ErrBadFileName},
+		{fs: fstest.MapFS{"0001_a.up.sql": {Data: []byte("1")}, "1_b.up.sql": {Data: []byte("2")}}, err: ErrDuplicateVersion},
+		{fs: fstest.MapFS{"0001_a.up.sql": {Data: []byte("1")}, "1_a.up.sql": {Data: []byte("2")}}, err: ErrDuplicateVersion},
+		{fs: fstest.MapFS{"0001_a.down.sql": {Data: []byte("1")}}, err: ErrNoUpMigration},
+	}
+
+	for i, test := range tests {
+		_, err := Parse(test.fs)
+		assert.Truef(t, errors.Is(err, test.err), "case %d: %v", i, err)
+
+		_, err = New(zap.NewNop(), nil, test.fs, Config{})
+		assert.Truef(t, errors.Is(err, test.err), "case %d: %v", i, err)
+	}
+}
+
+func Test_Plan(t *testing.T) {
+	migrations, err := Parse(testFS)
+	assert.Nil(t, err)
+
+	applied := map[uint64]appliedMigration{
+		1: {Version: 1, Name: "create_roles", Checksum: migrations[0].Checksum, AppliedAt: time.Unix(1, 0)},
+	}
+
+	pending, err := planUp(migrations, applied, ^uint64(0), false)
+	assert.Nil(t, err)
+	assert.Equal(t, migrations[1:], pending)
+
+	pending, err = planUp(migrations, applied, 2, false)
+	assert.Nil(t, err)
+	assert.Equal(t, migrations[1:2], pending)
+
+	pending, err = planDown(migrations, applied, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, migrations[:1], pending)
+
+	pending, err = planDown(migrations, applied, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []Migration{}, pending)
+
+	applied[10] = appliedMigration{Version: 10, Checksum: migrations[2].Checksum}
+	applied[2] = appliedMigration{Version: 2, Checksum: migrations[1].Checksum}
+	_, err = planDown(migrations, applied, 1)
+	assert.ErrorIs(t, err, ErrNoDownMigration)
+
+	applied[2] = appliedMigration{Version: 2, Checksum: "changed"}
+	_, err = planUp(migrations, applied, ^uint64(0), false)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+	pending, err = planUp(migrations, applied, ^uint64(0), true)
+	assert.Nil(t, err)
+	assert.Equal(t, []Migration{}, pending)
+
+	applied[42] = appliedMigration{Version: 42}
+	_, err = planUp(migrations, applied, ^uint64(0), true)
+	assert.ErrorIs(t, err, ErrMissingMigration)
+
+	_, err = planDown(migrations, applied, 1)
+	assert.ErrorIs(t, err, ErrMissingMigration)
+}
+
+func Test_Status(t *testing.T) {
+	migrations, err := Parse(testFS)
+	assert.Nil(t, err)
+
+	applied := map[uint64]appliedMigration{
+		1:  {Version: 1, Name: "create_roles", Checksum: migrations[0].Checksum, AppliedAt: time.Unix(1, 0)},
+		2:  {Version: 2, Name: "create_users", Checksum: "changed", AppliedAt: time.Unix(2, 0)},
+		11: {Version: 11, Name: "lost", Checksum: "lost", AppliedAt: time.Unix(11, 0)},
+	}
+
+	assert.Equal(t, []Status{
+		{Migration: migrations[0], Applied: true, AppliedAt: time.Unix(1, 0)},
+		{Migration: migrations[1], Applied: true, AppliedAt: time.Unix(2, 0), ChecksumMismatch: true},
+		{Migration: migrations[2]},
+		{
+			Migration: Migration{Version: 11, Name: "lost", Checksum: "lost"},
+			Applied:   true,
+			AppliedAt: time.Unix(11, 0),
+			Missing:   true,
+		},
+	}, status(migrations, applied))
+}
+
+func Test_ReadOnly(t *testing.T) {
+	ctx := context.Background()
+
+	dbConn, err := sqlx.Connect("sqlite3", ":memory:")
+	assert.Nil(t, err)
+
+	dbConn.SetMaxOpenConns(1) // one connection -> one in-memory database
+
+	cfg := Config{DisableLock: true, PlaceholderFormat: squirrel.Question}
+
+	m, err := New(zap.NewNop(), dbConn, testFS, cfg)
+	assert.Nil(t, err)
+
+	dry, err := New(zap.NewNop(), dbConn, testFS, Config{DisableLock: true, DryRun: true})
+	assert.Nil(t, err)
+
+	tableExists := func() bool {
+		var n int
+		assert.Nil(t, dbConn.Get(&n, "SELECT count(1) FROM sqlite_master WHERE name = ?", DefaultTableName))
+
+		return n == 1
+	}
+
+	statuses, err := m.Status(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, statuses, 3)
+	assert.False(t, statuses[0].Applied)
+
+	planned, err := dry.Up(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, planned, 3)
+	assert.False(t, tableExists()) // Status and DryRun do not create table
+
+	done, err := m.UpTo(ctx, 1)
+	assert.Nil(t, err)
+	assert.Len(t, done, 1)
+	assert.True(t, tableExists())
+
+	statuses, err = m.Status(ctx)
+	assert.Nil(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+
+	assert.Nil(t, dbConn.Close())
+
+	_, err = m.Status(ctx)
+	assert.NotNil(t, err) // only missing table means nothing applied, other errors are returned
+
+	_, err = dry.Up(ctx)
+	assert.NotNil(t, err)
+}
+
+func Test_SessionLock(t *testing.T) {
+	ctx := context.Background()
+
+	dbConn, err := sqlx.Connect("sqlite3_locks", ":memory:")
+	require.Nil(t, err)
+
+	dbConn.SetMaxOpenConns(1) // one connection -> one in-memory database, locked connection is used for migrations
+
+	t.Cleanup(func() { _ = dbConn.Close() })
+
+	m, err := New(zap.NewNop(), dbConn, testFS, Config{LockID: 1, PlaceholderFormat: squirrel.Question})
+	require.Nil(t, err)
+
+	locks = nil
+
+	done, err := m.UpTo(ctx, 2)
+	assert.Nil(t, err)
+	assert.Len(t, done, 2)
+	assert.Equal(t, []string{
+		"pg_advisory_lock(1)", // one session lock for whole run
+		"pg_advisory_xact_lock(1)",
+		"pg_advisory_xact_lock(1)",
+		"pg_advisory_unlock(1)",
+	}, locks)
+
+	locks = nil
+
+	done, err = m.Down(ctx, 1)
+	assert.Nil(t, err)
+	assert.Len(t, done, 1)
+	assert.Equal(t, []string{"pg_advisory_lock(1)", "pg_advisory_xact_lock(1)", "pg_advisory_unlock(1)"}, locks)
+}
+
+func Test_IsMissingTableError(t *testing.T) {
+	assert.True(t, isMissingTableError(fmt.Errorf("wrap: %w", sqlStateError("42P01"))))
+	assert.True(t, isMissingTableError(sqlStateError("42S02")))
+	assert.False(t, isMissingTableError(sqlStateError("42601"))) // syntax error
+	assert.True(t, isMissingTableError(errors.New("no such table: schema_migrations")))
+	assert.False(t, isMissingTableError(errors.New("database is closed")))
+}
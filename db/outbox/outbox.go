@@ -0,0 +1,66 @@
+// Package outbox - transactional outbox for publishing of events to Kafka.
+//
+// Event is inserted to outbox table in the same transaction as business data (Insert), so event is not lost if
+// process dies between commit and publishing. Relay claims unsent events, publishes them by confluent.Producer
+// and marks them sent on delivery report. Delivery is at least once (relay can die after publishing, before
+// commit), so consumers must be idempotent (e.g. by Event.ID in header). Events of one key (Event.Key) are
+// delivered in order of id: the next event of key is published after delivery of previous one.
+//
+// Table of outbox (DefaultTable) can be created by orm.GetCreateTableDDL(outbox.Event{}, dialect).
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/imperiuse/golib/db/transaction"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+// DefaultTable - default name of outbox table.
+const DefaultTable = "outbox"
+
+type (
+	// Event - row of outbox table, one message of Kafka.
+	Event struct {
+		ID          int64             `db:"id"           orm_use_in:"select" orm_pk:"identity"`
+		Topic       string            `db:"topic"        orm_use_in:"select,create"`
+		Key         []byte            `db:"msg_key"      orm_use_in:"select,create" orm_null:"true"`
+		Payload     []byte            `db:"payload"      orm_use_in:"select,create" orm_null:"true"`
+		Headers     map[string]string `db:"headers"      orm_use_in:"select,create" orm_json:"true"`
+		CreatedAt   time.Time         `db:"created_at"   orm_use_in:"select" orm_default:"CURRENT_TIMESTAMP"`
+		SentAt      *time.Time        `db:"sent_at"      orm_use_in:"select"`
+		LockedUntil *time.Time        `db:"locked_until" orm_use_in:"select"` // claim of relay
+		_           any               `orm_table_name:"outbox"`
+	}
+)
+
+// Insert - TxFn which inserts events to DefaultTable, use it in the same transaction as business data:
+//
+//	transaction.WithTransaction(ctx, nil, conn, createOrder, outbox.Insert(ctx, outbox.Event{Topic: "orders", ...}))
+func Insert(ctx context.Context, events ...Event) transaction.TxFn {
+	return InsertInto(ctx, DefaultTable, events...)
+}
+
+// InsertInto - the same as Insert, but events are inserted to table.
+func InsertInto(ctx context.Context, table string, events ...Event) transaction.TxFn {
+	return func(tx *sqlx.Tx) error {
+		query := tx.Rebind(fmt.Sprintf("INSERT INTO %s (topic, msg_key, payload, headers) VALUES (?, ?, ?, ?)", table))
+
+		for _, e := range events {
+			headers, err := orm.JSON{V: e.Headers}.Value()
+			if err != nil {
+				return fmt.Errorf("[outbox.Insert] headers: %w", err)
+			}
+
+			if _, err = tx.ExecContext(ctx, query, e.Topic, e.Key, e.Payload, headers); err != nil {
+				return fmt.Errorf("[outbox.Insert] tx.ExecContext: %w", err)
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,308 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3" // for sqlite3 driver import.
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/imperiuse/golib/db/dialect"
+	"github.com/imperiuse/golib/db/transaction"
+	"github.com/imperiuse/golib/kafka/confluent"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+// fakeProducer - confluent.Producer which reports delivery of every message (failed for topic "bad") by own goroutine
+// to unbuffered channel like real producer does, channel is closed by Stop after all reports.
+type fakeProducer struct {
+	m         sync.Mutex
+	published []*confluent.Message
+	ch        chan confluent.Event
+	reports   sync.WaitGroup
+	onPublish func(msg *confluent.Message)
+}
+
+var errDelivery = errors.New("delivery failed")
+
+func (p *fakeProducer) Start(context.Context, int) (chan confluent.Event, error) {
+	p.ch = make(chan confluent.Event)
+
+	return p.ch, nil
+}
+
+func (p *fakeProducer) Stop() {
+	p.reports.Wait()
+	close(p.ch)
+}
+
+func (p *fakeProducer) Flush(int) {}
+
+func (p *fakeProducer) Publish(msg *confluent.Message) error {
+	if p.onPublish != nil {
+		p.onPublish(msg)
+	}
+
+	p.m.Lock()
+	p.published = append(p.published, msg)
+	p.m.Unlock()
+
+	report := *msg
+	if *msg.TopicPartition.Topic == "bad" {
+		report.TopicPartition.Error = errDelivery
+	}
+
+	p.reports.Add(1)
+
+	go func() {
+		defer p.reports.Done()
+
+		p.ch <- &report
+	}()
+
+	return nil
+}
+
+func (p *fakeProducer) topics() []string {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	topics := []string{}
+	for _, msg := range p.published {
+		topics = append(topics, *msg.TopicPartition.Topic)
+	}
+
+	return topics
+}
+
+func (p *fakeProducer) ids() []int64 {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	ids := []int64{}
+	for _, msg := range p.published {
+		ids = append(ids, msg.Opaque.(int64))
+	}
+
+	return ids
+}
+
+// startRelay - relay with started producer and draining of reports, producer is stopped on cleanup.
+func startRelay(t *testing.T, conn *sqlx.DB, p *fakeProducer, cfg Config) *Relay {
+	t.Helper()
+
+	r := NewRelay(conn, p, zap.NewNop(), cfg)
+	deliveries, _ := p.Start(context.Background(), 0)
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+
+		r.drain(deliveries)
+	}()
+
+	t.Cleanup(func() {
+		p.Stop()
+		<-drained
+	})
+
+	return r
+}
+
+func newConn(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	conn, err := sqlx.Connect("sqlite3", "file::memory:")
+	require.Nil(t, err)
+
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	ddl, err := orm.GetCreateTableDDL(Event{}, orm.DialectSQLite)
+	require.Nil(t, err)
+
+	_, err = conn.Exec(ddl)
+	require.Nil(t, err, ddl)
+
+	_, err = conn.Exec("CREATE TABLE orders (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL)")
+	require.Nil(t, err)
+
+	return conn
+}
+
+func createOrder(ctx context.Context, title string) transaction.TxFn {
+	return func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, "INSERT INTO orders (title) VALUES (?)", title)
+
+		return err
+	}
+}
+
+func count(t *testing.T, conn *sqlx.DB, query string) int {
+	t.Helper()
+
+	var n int
+	require.Nil(t, conn.Get(&n, query))
+
+	return n
+}
+
+func Test_Insert(t *testing.T) {
+	ctx := context.Background()
+	conn := newConn(t)
+
+	err := transaction.WithTransaction(ctx, nil, conn, createOrder(ctx, "a"),
+		Insert(ctx, Event{Topic: "orders", Key: []byte("a"), Payload: []byte(`{"title":"a"}`),
+			Headers: map[string]string{"type": "created"}}))
+	assert.Nil(t, err)
+
+	errFailed := errors.New("failed")
+	err = transaction.WithTransaction(ctx, nil, conn, Insert(ctx, Event{Topic: "orders"}), createOrder(ctx, "b"),
+		func(*sqlx.Tx) error { return errFailed })
+	assert.ErrorIs(t, err, errFailed)
+
+	assert.Equal(t, 1, count(t, conn, "SELECT count(1) FROM orders"))
+	assert.Equal(t, 1, count(t, conn, "SELECT count(1) FROM outbox WHERE sent_at IS NULL")) // event of rolled back transaction is not inserted
+}
+
+func Test_Relay(t *testing.T) {
+	ctx := context.Background()
+	conn := newConn(t)
+
+	require.Nil(t, transaction.WithTransaction(ctx, nil, conn, createOrder(ctx, "a"), Insert(ctx,
+		Event{Topic: "orders", Key: []byte("a"), Headers: map[string]string{"type": "created"}},
+		Event{Topic: "bad"},
+		Event{Topic: "orders", Key: []byte("b")},
+	)))
+
+	p := &fakeProducer{}
+	r := NewRelay(conn, p, zap.NewNop(), Config{Dialect: dialect.SQLite, BatchSize: 2})
+	deliveries, _ := p.Start(ctx, 0)
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+
+		r.drain(deliveries)
+	}()
+
+	n, err := r.relay(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"orders", "bad"}, p.topics())
+	assert.Equal(t, []kafka.Header{{Key: "type", Value: []byte("created")}, {Key: HeaderEventID, Value: []byte("1")}},
+		p.published[0].Headers)
+	assert.Equal(t, 2, count(t, conn, "SELECT count(1) FROM outbox WHERE sent_at IS NULL")) // bad and not polled
+
+	n, err = r.relay(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"orders", "bad", "bad", "orders"}, p.topics()) // undelivered event is published again
+	assert.Equal(t, 1, count(t, conn, "SELECT count(1) FROM outbox WHERE sent_at IS NULL"))
+
+	topic := "orders"
+	deliveries <- &confluent.Message{TopicPartition: confluent.TopicPartition{Topic: &topic}, Opaque: int64(1)} // late report
+
+	p.Stop() // reports between batches are read, producer is not blocked
+	<-drained
+}
+
+func Test_RelayRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	conn := newConn(t)
+
+	p := &fakeProducer{}
+	r := NewRelay(conn, p, zap.NewNop(), Config{Dialect: dialect.SQLite, PollInterval: 10 * time.Millisecond})
+
+	done := make(chan error)
+	go func() { done <- r.Run(ctx) }()
+
+	require.Nil(t, transaction.WithTransaction(ctx, nil, conn, Insert(ctx, Event{Topic: "orders"})))
+
+	assert.Eventually(t, func() bool {
+		return count(t, conn, "SELECT count(1) FROM outbox WHERE sent_at IS NOT NULL") == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.Nil(t, <-done)
+	assert.Equal(t, []string{"orders"}, p.topics())
+}
+
+func Test_RelayKeyOrder(t *testing.T) {
+	ctx := context.Background()
+	conn := newConn(t)
+
+	require.Nil(t, transaction.WithTransaction(ctx, nil, conn, Insert(ctx,
+		Event{Topic: "bad", Key: []byte("a")},
+		Event{Topic: "orders", Key: []byte("a")},
+		Event{Topic: "orders", Key: []byte("b")},
+		Event{Topic: "orders", Key: []byte("a")},
+	)))
+
+	p := &fakeProducer{}
+	r := startRelay(t, conn, p, Config{Dialect: dialect.SQLite})
+
+	n, err := r.relay(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, []int64{1, 3}, p.ids()) // events of key "a" after failed one are not published
+	assert.Equal(t, 1, count(t, conn, "SELECT count(1) FROM outbox WHERE sent_at IS NOT NULL"))
+	assert.Equal(t, 0, count(t, conn, "SELECT count(1) FROM outbox WHERE locked_until IS NOT NULL AND sent_at IS NULL"))
+
+	_, err = conn.Exec("UPDATE outbox SET topic = 'orders' WHERE id = 1")
+	require.Nil(t, err)
+
+	n, err = r.relay(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []int64{1, 3, 1, 2, 4}, p.ids()) // the next event of key after delivery of previous one
+	assert.Equal(t, 4, count(t, conn, "SELECT count(1) FROM outbox WHERE sent_at IS NOT NULL"))
+}
+
+func Test_RelayClaim(t *testing.T) {
+	ctx := context.Background()
+	conn := newConn(t) // one connection, so open transaction of relay blocks other queries
+
+	require.Nil(t, transaction.WithTransaction(ctx, nil, conn, Insert(ctx,
+		Event{Topic: "orders", Key: []byte("a")},
+		Event{Topic: "orders", Key: []byte("a")},
+		Event{Topic: "orders", Key: []byte("b")},
+	)))
+
+	p := &fakeProducer{}
+	p.onPublish = func(*confluent.Message) {
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		var claimed int
+		assert.Nil(t, conn.GetContext(ctx, &claimed, "SELECT count(1) FROM outbox WHERE locked_until IS NOT NULL"))
+		assert.Equal(t, 2, claimed) // claim is committed before publishing
+	}
+
+	r := startRelay(t, conn, p, Config{Dialect: dialect.SQLite})
+	r.now = func() time.Time { return time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	// the first event of key "a" is claimed by other relay, so the second one waits for it
+	_, err := conn.Exec("UPDATE outbox SET locked_until = ? WHERE id = 1", r.now().Add(time.Minute))
+	require.Nil(t, err)
+
+	n, err := r.relay(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{3}, p.ids())
+
+	r.now = func() time.Time { return time.Date(2023, 1, 1, 0, 2, 0, 0, time.UTC) } // claim is expired
+
+	p.onPublish = nil
+	n, err = r.relay(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []int64{3, 1, 2}, p.ids())
+}
@@ -0,0 +1,499 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/dialect"
+	"github.com/imperiuse/golib/db/transaction"
+	"github.com/imperiuse/golib/kafka/confluent"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+const (
+	DefaultBatchSize       = 100
+	DefaultPollInterval    = time.Second
+	DefaultDeliveryTimeout = 10 * time.Second
+	DefaultFlushTimeoutMs  = 5000
+
+	// HeaderEventID - header of published message with id of event (for idempotent consumers).
+	HeaderEventID = "outbox-event-id"
+)
+
+type (
+	// Config - settings of Relay, zero values are replaced by defaults.
+	Config struct {
+		Table           string        // DefaultTable if empty
+		Dialect         db.Dialect    // dialect.Default if nil, rows are claimed with FOR UPDATE SKIP LOCKED if supported
+		BatchSize       uint64        // max count of events of one poll
+		PollInterval    time.Duration // pause between polls if there were not unsent events
+		DeliveryTimeout time.Duration // max wait of delivery reports of batch, undelivered events are published again
+		ClaimTimeout    time.Duration // claim of batch by relay (2 * DeliveryTimeout if zero), then batch is claimable
+		FlushTimeoutMs  int           // flush of producer on stop
+	}
+
+	// Relay - publisher of unsent events of outbox table. Batch of events is claimed (locked_until) in short
+	// transaction and published without open transaction, so several relays can work in parallel. Events of one key
+	// are delivered in order of id (see Relay.publish). Producer must be used only by relay (delivery reports
+	// of other messages are dropped).
+	Relay struct {
+		conn     transaction.TxxI
+		producer confluent.Producer
+		logger   db.Logger
+		cfg      Config
+		now      func() time.Time
+
+		m     sync.Mutex
+		batch *batch // batch which waits for delivery reports, nil between batches
+	}
+
+	// batch - published events of one relay, reports are routed to it by Opaque (id of event).
+	batch struct {
+		pending map[int64]bool
+		reports chan report // buffered by count of pending events, so routing of reports never blocks
+	}
+
+	report struct {
+		id  int64
+		err error
+	}
+)
+
+// NewRelay - relay of outbox table by producer (not started, Relay.Run starts it).
+func NewRelay(conn transaction.TxxI, producer confluent.Producer, logger db.Logger, cfg Config) *Relay {
+	if cfg.Table == "" {
+		cfg.Table = DefaultTable
+	}
+
+	if cfg.Dialect == nil {
+		cfg.Dialect = dialect.Default
+	}
+
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+
+	if cfg.DeliveryTimeout <= 0 {
+		cfg.DeliveryTimeout = DefaultDeliveryTimeout
+	}
+
+	if cfg.ClaimTimeout <= 0 {
+		cfg.ClaimTimeout = 2 * cfg.DeliveryTimeout
+	}
+
+	if cfg.FlushTimeoutMs <= 0 {
+		cfg.FlushTimeoutMs = DefaultFlushTimeoutMs
+	}
+
+	return &Relay{conn: conn, producer: producer, logger: logger, cfg: cfg, now: func() time.Time { return time.Now().UTC() }}
+}
+
+// Run - start producer and relay events until ctx is done. Producer is stopped on return, Run waits until producer
+// closes channel of delivery reports (reports are read all the time, so producer is never blocked by relay).
+func (r *Relay) Run(ctx context.Context) error {
+	deliveries, err := r.producer.Start(ctx, r.cfg.FlushTimeoutMs)
+	if err != nil {
+		return fmt.Errorf("[outbox.Relay] producer.Start: %w", err)
+	}
+
+	drained := make(chan struct{})
+
+	go func() {
+		defer close(drained)
+
+		r.drain(deliveries)
+	}()
+
+	defer func() {
+		r.producer.Stop()
+		<-drained
+	}()
+
+	for {
+		n, err := r.relay(ctx)
+		if err != nil {
+			r.logger.Error("[outbox.Relay] relay of events failed", zap.Error(err))
+		}
+
+		if err == nil && uint64(n) == r.cfg.BatchSize {
+			continue // probably there are more unsent events
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.cfg.PollInterval):
+		}
+	}
+}
+
+// relay - claim one batch of unsent events (short transaction, locks are not held while publishing), publish it
+// and mark delivered events sent, claim of undelivered events is released. Return count of claimed events.
+func (r *Relay) relay(ctx context.Context) (int, error) {
+	events, err := r.claim(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("[outbox.Relay] %w", err)
+	}
+
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	sent := r.publish(ctx, events)
+
+	err = transaction.WithTransaction(ctx, nil, r.conn, func(tx *sqlx.Tx) error {
+		return r.markSent(ctx, tx, events, sent)
+	})
+	if err != nil {
+		return len(events), fmt.Errorf("[outbox.Relay] %w", err)
+	}
+
+	return len(events), nil
+}
+
+// claim - select unsent and not claimed events in order of id and claim them for ClaimTimeout. Events of key which
+// has older unsent event out of batch (claimed by other relay or not claimable yet) are skipped, so events of one
+// key are never published by several relays at once.
+func (r *Relay) claim(ctx context.Context) ([]Event, error) {
+	events := []Event{}
+
+	err := transaction.WithTransaction(ctx, nil, r.conn, func(tx *sqlx.Tx) error {
+		now := r.now()
+
+		query := fmt.Sprintf("SELECT id, topic, msg_key, payload, headers FROM %s "+
+			"WHERE sent_at IS NULL AND (locked_until IS NULL OR locked_until < ?) ORDER BY id LIMIT ?", r.cfg.Table)
+		if r.cfg.Dialect.SupportsSkipLocked() {
+			query += " FOR UPDATE SKIP LOCKED"
+		}
+
+		selected, err := r.selectEvents(ctx, tx, query, now, r.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("select unsent: %w", err)
+		}
+
+		if selected, err = r.withoutBlocked(ctx, tx, selected); err != nil {
+			return err
+		}
+
+		if len(selected) == 0 {
+			return nil
+		}
+
+		query, args, err := sqlx.In(fmt.Sprintf("UPDATE %s SET locked_until = ? WHERE id IN (?)", r.cfg.Table),
+			now.Add(r.cfg.ClaimTimeout), ids(selected))
+		if err != nil {
+			return fmt.Errorf("sqlx.In: %w", err)
+		}
+
+		if _, err = tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
+			return fmt.Errorf("claim: %w", err)
+		}
+
+		events = selected
+
+		return nil
+	})
+
+	return events, err
+}
+
+// withoutBlocked - events without older unsent events of the same key out of events (they must be published first).
+func (r *Relay) withoutBlocked(ctx context.Context, tx *sqlx.Tx, events []Event) ([]Event, error) {
+	keys := [][]byte{}
+	for _, e := range events {
+		if e.Key != nil {
+			keys = append(keys, e.Key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return events, nil
+	}
+
+	query, args, err := sqlx.In(fmt.Sprintf("SELECT min(id), msg_key FROM %s "+
+		"WHERE sent_at IS NULL AND id < ? AND msg_key IN (?) AND id NOT IN (?) GROUP BY msg_key", r.cfg.Table),
+		events[len(events)-1].ID, keys, ids(events))
+	if err != nil {
+		return nil, fmt.Errorf("sqlx.In: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, tx.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("select blocking: %w", err)
+	}
+	defer rows.Close()
+
+	blocking := map[string]int64{} // key -> the oldest unsent event of key out of events
+	for rows.Next() {
+		var (
+			id  int64
+			key []byte
+		)
+
+		if err = rows.Scan(&id, &key); err != nil {
+			return nil, fmt.Errorf("scan blocking: %w", err)
+		}
+
+		blocking[string(key)] = id
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("select blocking: %w", err)
+	}
+
+	free := make([]Event, 0, len(events))
+	for _, e := range events {
+		if id, blocked := blocking[string(e.Key)]; e.Key == nil || !blocked || e.ID < id {
+			free = append(free, e)
+		}
+	}
+
+	return free, nil
+}
+
+func (r *Relay) selectEvents(ctx context.Context, tx *sqlx.Tx, query string, args ...any) ([]Event, error) {
+	rows, err := tx.QueryContext(ctx, tx.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Event{}
+
+	for rows.Next() {
+		e := Event{}
+		if err = rows.Scan(&e.ID, &e.Topic, &e.Key, &e.Payload, orm.JSON{V: &e.Headers}); err != nil {
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// publish - publish claimed events and wait for delivery reports not longer than DeliveryTimeout (late reports are
+// ignored, such events will be published again), return ids of delivered events. Events of one key are published
+// one by one (the next one after delivery report of previous), after failed publishing or delivery the rest events
+// of key are not published, so events of key are delivered in order of id.
+func (r *Relay) publish(ctx context.Context, events []Event) []int64 {
+	b := r.begin(len(events))
+	defer r.end()
+
+	var (
+		byID     = make(map[int64]Event, len(events))
+		queues   = map[string][]Event{} // key -> events of key waiting for delivery of previous one
+		started  = map[string]bool{}
+		failed   = map[string]bool{}
+		inflight = 0
+	)
+
+	send := func(e Event) {
+		if r.send(b, e) {
+			inflight++
+		} else if e.Key != nil {
+			failed[string(e.Key)] = true
+			delete(queues, string(e.Key))
+		}
+	}
+
+	for _, e := range events {
+		byID[e.ID] = e
+
+		switch key := string(e.Key); {
+		case e.Key == nil: // events without key are not ordered
+			send(e)
+		case failed[key]:
+		case started[key]:
+			queues[key] = append(queues[key], e)
+		default:
+			started[key] = true
+			send(e)
+		}
+	}
+
+	sent := []int64{}
+
+	timer := time.NewTimer(r.cfg.DeliveryTimeout)
+	defer timer.Stop()
+
+	for inflight > 0 {
+		select {
+		case <-ctx.Done():
+			return sent
+		case <-timer.C:
+			r.logger.Warn("[outbox.Relay] delivery reports timeout", zap.Int("pending", inflight))
+
+			return sent
+		case rep := <-b.reports:
+			inflight--
+
+			e := byID[rep.id]
+			if rep.err != nil {
+				r.logger.Error("[outbox.Relay] delivery failed", zap.Int64("id", rep.id), zap.Error(rep.err))
+
+				if e.Key != nil {
+					failed[string(e.Key)] = true
+					delete(queues, string(e.Key))
+				}
+
+				continue
+			}
+
+			sent = append(sent, rep.id)
+
+			if q := queues[string(e.Key)]; e.Key != nil && len(q) > 0 {
+				queues[string(e.Key)] = q[1:]
+				send(q[0])
+			}
+		}
+	}
+
+	return sent
+}
+
+// send - publish event of batch, report of event is routed to batch. Return false if publishing failed.
+func (r *Relay) send(b *batch, e Event) bool {
+	r.m.Lock()
+	b.pending[e.ID] = true // before publishing, report can be received before Publish returns
+	r.m.Unlock()
+
+	if err := r.producer.Publish(message(e)); err != nil {
+		r.logger.Error("[outbox.Relay] publish failed", zap.Int64("id", e.ID), zap.Error(err))
+
+		r.m.Lock()
+		delete(b.pending, e.ID)
+		r.m.Unlock()
+
+		return false
+	}
+
+	return true
+}
+
+// drain - read delivery reports until channel is closed and route them to waiting batch (reports of other, late
+// or failed to route messages are dropped).
+func (r *Relay) drain(deliveries chan confluent.Event) {
+	for ev := range deliveries {
+		msg, ok := ev.(*confluent.Message)
+		if !ok {
+			r.logger.Error("[outbox.Relay] unexpected event of producer", zap.Any("ev", ev))
+
+			continue
+		}
+
+		id, ok := msg.Opaque.(int64)
+		if !ok {
+			continue // report of other message
+		}
+
+		r.m.Lock()
+		if b := r.batch; b != nil && b.pending[id] {
+			delete(b.pending, id)
+			b.reports <- report{id: id, err: msg.TopicPartition.Error}
+		}
+		r.m.Unlock()
+	}
+}
+
+// begin - batch of cnt events which waits for delivery reports.
+func (r *Relay) begin(cnt int) *batch {
+	b := &batch{pending: make(map[int64]bool, cnt), reports: make(chan report, cnt)}
+
+	r.m.Lock()
+	r.batch = b
+	r.m.Unlock()
+
+	return b
+}
+
+// end - stop routing of reports to batch (late reports are dropped).
+func (r *Relay) end() {
+	r.m.Lock()
+	r.batch = nil
+	r.m.Unlock()
+}
+
+// markSent - mark delivered events sent and release claim of other events, so they are claimed again on next poll.
+func (r *Relay) markSent(ctx context.Context, tx *sqlx.Tx, events []Event, sent []int64) error {
+	delivered := make(map[int64]bool, len(sent))
+	for _, id := range sent {
+		delivered[id] = true
+	}
+
+	unsent := []int64{}
+	for _, e := range events {
+		if !delivered[e.ID] {
+			unsent = append(unsent, e.ID)
+		}
+	}
+
+	for _, u := range []struct {
+		set string
+		ids []int64
+	}{{set: "sent_at = CURRENT_TIMESTAMP", ids: sent}, {set: "locked_until = NULL", ids: unsent}} {
+		if len(u.ids) == 0 {
+			continue
+		}
+
+		query, args, err := sqlx.In(fmt.Sprintf("UPDATE %s SET %s WHERE id IN (?)", r.cfg.Table, u.set), u.ids)
+		if err != nil {
+			return fmt.Errorf("sqlx.In: %w", err)
+		}
+
+		if _, err = tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
+			return fmt.Errorf("mark sent: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func ids(events []Event) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+
+	return ids
+}
+
+// message - kafka message of event, id of event is Opaque (for delivery report) and HeaderEventID header.
+func message(e Event) *confluent.Message {
+	topic := e.Topic
+
+	keys := make([]string, 0, len(e.Headers))
+	for k := range e.Headers {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys) // stable order of headers
+
+	headers := make([]kafka.Header, 0, len(keys)+1)
+	for _, k := range keys {
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(e.Headers[k])})
+	}
+
+	headers = append(headers, kafka.Header{Key: HeaderEventID, Value: []byte(strconv.FormatInt(e.ID, 10))})
+
+	return &confluent.Message{
+		TopicPartition: confluent.TopicPartition{Topic: &topic, Partition: confluent.PartitionAny},
+		Key:            e.Key,
+		Value:          e.Payload,
+		Headers:        headers,
+		Opaque:         e.ID,
+	}
+}
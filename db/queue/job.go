@@ -0,0 +1,31 @@
+package queue
+
+import "time"
+
+// Status - state of job.
+type Status = string
+
+const (
+	StatusPending Status = "pending" // waits for run_at
+	StatusRunning Status = "running" // claimed by worker until locked_until (visibility timeout)
+	StatusDone    Status = "done"
+	StatusDead    Status = "dead" // all attempts failed (dead letter), see Queue.Retry
+)
+
+type (
+	// Job - row of queue table (DefaultTable can be created by orm.GetCreateTableDDL(queue.Job{}, dialect)).
+	Job struct {
+		ID          int64      `db:"id"           orm_use_in:"select" orm_pk:"identity"`
+		Queue       string     `db:"queue"        orm_use_in:"select,create"`
+		Payload     []byte     `db:"payload"      orm_use_in:"select,create" orm_null:"true"`
+		Priority    int        `db:"priority"     orm_use_in:"select,create"` // greater is earlier
+		RunAt       time.Time  `db:"run_at"       orm_use_in:"select,create"`
+		Attempts    int        `db:"attempts"     orm_use_in:"select" orm_default:"0"`
+		MaxAttempts int        `db:"max_attempts" orm_use_in:"select,create"`
+		Status      Status     `db:"status"       orm_use_in:"select,create"`
+		LastError   string     `db:"last_error"   orm_use_in:"select" orm_default:"''"`
+		LockedUntil *time.Time `db:"locked_until" orm_use_in:"select"`
+		CreatedAt   time.Time  `db:"created_at"   orm_use_in:"select" orm_default:"CURRENT_TIMESTAMP"`
+		_           any        `orm_table_name:"jobs"`
+	}
+)
@@ -0,0 +1,263 @@
+// Package queue - durable queue of jobs in table of database (without broker).
+//
+// Jobs are enqueued with payload, priority, run at time and max attempts (Enqueue works in transaction of ctx, so job
+// can be enqueued atomically with business data, see transaction.InTransaction). Workers (Queue.Work) claim jobs by
+// SELECT ... FOR UPDATE SKIP LOCKED (if dialect supports it, SQLite has not row locks), claimed job is invisible for
+// other workers until VisibilityTimeout, so job of crashed worker is claimed again after it. Failed job is retried
+// with backoff, job without attempts gets StatusDead (dead letter, see Queue.Dead and Queue.Retry).
+package queue
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/dialect"
+	"github.com/imperiuse/golib/db/transaction"
+)
+
+const (
+	DefaultTable             = "jobs"
+	DefaultName              = "default"
+	DefaultWorkers           = 1
+	DefaultPollInterval      = time.Second
+	DefaultVisibilityTimeout = 5 * time.Minute
+	DefaultMaxAttempts       = 5
+
+	maxBackoff = time.Hour
+
+	errVisibilityTimeout = "visibility timeout is expired" // last_error of job of crashed worker
+)
+
+type (
+	// Config - settings of Queue, zero values are replaced by defaults.
+	Config struct {
+		Table             string        // DefaultTable if empty
+		Name              string        // name of queue, several queues can share one table
+		Workers           int           // count of workers of Queue.Work
+		PollInterval      time.Duration // pause of worker if there are not ready jobs
+		VisibilityTimeout time.Duration // max duration of handler, after it job can be claimed by other worker
+		MaxAttempts       int           // max attempts of job enqueued without MaxAttempts
+
+		// Backoff - delay of next attempt after failed attempt (1, 2, ...), DefaultBackoff if nil.
+		Backoff func(attempt int) time.Duration
+	}
+
+	// Queue - queue of jobs in table.
+	Queue struct {
+		repo    db.Repository
+		conn    transaction.TxxI
+		dialect db.Dialect
+		logger  db.Logger
+		cfg     Config
+
+		now func() time.Time
+	}
+)
+
+// New - queue in table of connector.
+func New[C db.Config](connector db.Connector[C], cfg Config) *Queue {
+	if cfg.Table == "" {
+		cfg.Table = DefaultTable
+	}
+
+	if cfg.Name == "" {
+		cfg.Name = DefaultName
+	}
+
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+
+	if cfg.VisibilityTimeout <= 0 {
+		cfg.VisibilityTimeout = DefaultVisibilityTimeout
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if cfg.Backoff == nil {
+		cfg.Backoff = DefaultBackoff
+	}
+
+	return &Queue{
+		repo:    connector.RepoByName(cfg.Table),
+		conn:    connector.Connection(),
+		dialect: dialect.Of(connector.Config()),
+		logger:  connector.Logger(),
+		cfg:     cfg,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// DefaultBackoff - exponential backoff: 1s, 2s, 4s, ... (not more than hour).
+func DefaultBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	d := time.Duration(math.Pow(2, float64(attempt-1))) * time.Second
+	if d <= 0 || d > maxBackoff {
+		return maxBackoff
+	}
+
+	return d
+}
+
+// Enqueue - add job to queue (Payload, Priority, RunAt and MaxAttempts of job are used), zero RunAt - now,
+// zero MaxAttempts - Config.MaxAttempts. Return id of job.
+func (q *Queue) Enqueue(ctx context.Context, job Job) (int64, error) {
+	job.Queue, job.Status = q.cfg.Name, StatusPending
+
+	if job.RunAt.IsZero() {
+		job.RunAt = q.now()
+	}
+
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = q.cfg.MaxAttempts
+	}
+
+	id, err := q.repo.Create(ctx, job)
+	if err != nil {
+		return 0, fmt.Errorf("[queue.Enqueue] %w", err)
+	}
+
+	return id, nil
+}
+
+// Dead - jobs of queue without attempts (dead letter).
+func (q *Queue) Dead(ctx context.Context) ([]Job, error) {
+	jobs := []Job{}
+	if err := q.repo.FindBy(ctx, []db.Column{"*"}, q.where(squirrel.Eq{"status": StatusDead}), &jobs); err != nil {
+		return nil, fmt.Errorf("[queue.Dead] %w", err)
+	}
+
+	return jobs, nil
+}
+
+// Retry - return dead job to queue with new attempts, false if job is not dead.
+func (q *Queue) Retry(ctx context.Context, id int64) (bool, error) {
+	n, err := q.repo.UpdateCustom(ctx,
+		map[string]any{"status": StatusPending, "attempts": 0, "run_at": q.now(), "last_error": ""},
+		q.where(squirrel.Eq{"id": id, "status": StatusDead}))
+	if err != nil {
+		return false, fmt.Errorf("[queue.Retry] %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// claim - lock ready jobs (pending jobs with run_at in past and running jobs with expired visibility timeout)
+// in order of priority and mark them running for VisibilityTimeout.
+func (q *Queue) claim(ctx context.Context, limit uint64) ([]Job, error) {
+	claimed := []Job{}
+
+	err := transaction.InTransaction(ctx, q.conn, func(ctx context.Context, _ *sqlx.Tx) error {
+		now := q.now()
+
+		sb := squirrel.Select("*").
+			Where(q.where(squirrel.Or{
+				squirrel.And{squirrel.Eq{"status": StatusPending}, squirrel.LtOrEq{"run_at": now}},
+				squirrel.And{squirrel.Eq{"status": StatusRunning}, squirrel.Lt{"locked_until": now}}, // crashed worker
+			})).
+			OrderBy("priority DESC", "run_at", "id").
+			Limit(limit)
+
+		if q.dialect.SupportsSkipLocked() {
+			sb = sb.Suffix("FOR UPDATE SKIP LOCKED")
+		}
+
+		jobs := []Job{}
+		if err := q.repo.Select(ctx, sb, &jobs); err != nil {
+			return err
+		}
+
+		for _, job := range jobs {
+			if job.Status == StatusRunning {
+				q.logger.Warn("[queue.claim] visibility timeout of job is expired", zap.Int64("id", job.ID))
+
+				if job.Attempts >= job.MaxAttempts { // worker crashed on the last attempt
+					if _, err := q.repo.UpdateCustom(ctx,
+						map[string]any{"status": StatusDead, "locked_until": nil, "last_error": errVisibilityTimeout},
+						squirrel.Eq{"id": job.ID}); err != nil {
+						return err
+					}
+
+					continue
+				}
+			}
+
+			lockedUntil := now.Add(q.cfg.VisibilityTimeout)
+			job.Status, job.Attempts, job.LockedUntil = StatusRunning, job.Attempts+1, &lockedUntil
+
+			if _, err := q.repo.UpdateCustom(ctx,
+				map[string]any{"status": job.Status, "attempts": job.Attempts, "locked_until": lockedUntil},
+				squirrel.Eq{"id": job.ID}); err != nil {
+				return err
+			}
+
+			claimed = append(claimed, job)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[queue.claim] %w", err)
+	}
+
+	return claimed, nil
+}
+
+// finish - save result of attempt: done, retry with backoff or dead. Lease of attempt is checked by attempts,
+// false if job was claimed again (visibility timeout is expired).
+func (q *Queue) finish(ctx context.Context, job Job, jobErr error) (bool, error) {
+	set := map[string]any{"status": StatusDone, "locked_until": nil, "last_error": ""}
+
+	switch {
+	case jobErr == nil:
+	case job.Attempts >= job.MaxAttempts:
+		set["status"], set["last_error"] = StatusDead, jobErr.Error()
+	default:
+		set["status"], set["last_error"] = StatusPending, jobErr.Error()
+		set["run_at"] = q.now().Add(q.cfg.Backoff(job.Attempts))
+	}
+
+	n, err := q.repo.UpdateCustom(ctx, set, q.lease(job))
+	if err != nil {
+		return false, fmt.Errorf("[queue.finish] %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// release - return job to queue without consuming of attempt (e.g. worker is stopped).
+func (q *Queue) release(ctx context.Context, job Job) error {
+	if _, err := q.repo.UpdateCustom(ctx,
+		map[string]any{"status": StatusPending, "attempts": job.Attempts - 1, "locked_until": nil},
+		q.lease(job)); err != nil {
+		return fmt.Errorf("[queue.release] %w", err)
+	}
+
+	return nil
+}
+
+// lease - condition of claimed attempt of job.
+func (q *Queue) lease(job Job) db.Condition {
+	return squirrel.Eq{"id": job.ID, "status": StatusRunning, "attempts": job.Attempts}
+}
+
+// where - condition of queue AND cond.
+func (q *Queue) where(cond db.Condition) db.Condition {
+	return squirrel.And{squirrel.Eq{"queue": q.cfg.Name}, cond}
+}
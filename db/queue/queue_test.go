@@ -0,0 +1,305 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3" // for sqlite3 driver import.
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/imperiuse/golib/db/connector"
+	"github.com/imperiuse/golib/db/dialect"
+	"github.com/imperiuse/golib/db/example/simple/config"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+var errJob = errors.New("job failed")
+
+// clock - fake time of queue.
+type clock struct {
+	m   sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.m.Lock()
+	c.now = c.now.Add(d)
+	c.m.Unlock()
+}
+
+func newQueue(t *testing.T, cfg Config) (*Queue, *clock) {
+	t.Helper()
+
+	conn, err := sqlx.Connect("sqlite3", "file::memory:")
+	require.Nil(t, err)
+
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	ddl, err := orm.GetCreateTableDDL(Job{}, orm.DialectSQLite)
+	require.Nil(t, err)
+
+	_, err = conn.Exec(ddl)
+	require.Nil(t, err, ddl)
+
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	q := New(connector.New(config.New(nil, false, false).WithDialect(dialect.SQLite), zap.NewNop(), conn), cfg)
+	q.now = c.Now
+
+	return q, c
+}
+
+func (q *Queue) get(t *testing.T, id int64) Job {
+	t.Helper()
+
+	job := Job{}
+	require.Nil(t, q.repo.Get(context.Background(), id, &job))
+
+	return job
+}
+
+func Test_DefaultBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, DefaultBackoff(0))
+	assert.Equal(t, time.Second, DefaultBackoff(1))
+	assert.Equal(t, 4*time.Second, DefaultBackoff(3))
+	assert.Equal(t, time.Hour, DefaultBackoff(20))
+	assert.Equal(t, time.Hour, DefaultBackoff(100))
+}
+
+func Test_Claim(t *testing.T) {
+	ctx := context.Background()
+	q, c := newQueue(t, Config{})
+
+	low, err := q.Enqueue(ctx, Job{Payload: []byte("low")})
+	require.Nil(t, err)
+
+	high, err := q.Enqueue(ctx, Job{Payload: []byte("high"), Priority: 10})
+	require.Nil(t, err)
+
+	later, err := q.Enqueue(ctx, Job{Priority: 100, RunAt: c.Now().Add(time.Minute)})
+	require.Nil(t, err)
+
+	job := q.get(t, low)
+	assert.Equal(t, DefaultName, job.Queue)
+	assert.Equal(t, StatusPending, job.Status)
+	assert.Equal(t, DefaultMaxAttempts, job.MaxAttempts)
+	assert.Equal(t, 0, job.Attempts)
+
+	jobs, err := q.claim(ctx, 10)
+	require.Nil(t, err)
+	require.Len(t, jobs, 2) // job of future is not ready
+	assert.Equal(t, high, jobs[0].ID)
+	assert.Equal(t, low, jobs[1].ID)
+	assert.Equal(t, []byte("high"), jobs[0].Payload)
+
+	job = q.get(t, high)
+	assert.Equal(t, StatusRunning, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	require.NotNil(t, job.LockedUntil)
+	assert.True(t, job.LockedUntil.Equal(c.Now().Add(DefaultVisibilityTimeout)))
+
+	jobs, err = q.claim(ctx, 10)
+	require.Nil(t, err)
+	assert.Empty(t, jobs) // claimed jobs are invisible
+
+	c.Add(time.Minute)
+
+	jobs, err = q.claim(ctx, 10)
+	require.Nil(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, later, jobs[0].ID)
+
+	other := *q
+	other.cfg.Name = "other"
+
+	jobs, err = other.claim(ctx, 10)
+	require.Nil(t, err)
+	assert.Empty(t, jobs) // jobs of other queue
+}
+
+func Test_RetryAndDead(t *testing.T) {
+	ctx := context.Background()
+	q, c := newQueue(t, Config{MaxAttempts: 2, Backoff: func(attempt int) time.Duration {
+		return time.Duration(attempt) * time.Minute
+	}})
+
+	id, err := q.Enqueue(ctx, Job{})
+	require.Nil(t, err)
+
+	fail := func(context.Context, Job) error { return errJob }
+
+	n, err := q.Process(ctx, fail)
+	require.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	job := q.get(t, id)
+	assert.Equal(t, StatusPending, job.Status)
+	assert.Equal(t, errJob.Error(), job.LastError)
+	assert.Nil(t, job.LockedUntil)
+	assert.True(t, job.RunAt.Equal(c.Now().Add(time.Minute)))
+
+	n, err = q.Process(ctx, fail)
+	require.Nil(t, err)
+	assert.Equal(t, 0, n) // backoff
+
+	c.Add(time.Minute)
+
+	n, err = q.Process(ctx, func(context.Context, Job) error { panic("boom") })
+	require.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	job = q.get(t, id)
+	assert.Equal(t, StatusDead, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, "panic: boom", job.LastError)
+
+	dead, err := q.Dead(ctx)
+	require.Nil(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, id, dead[0].ID)
+
+	ok, err := q.Retry(ctx, id)
+	require.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = q.Retry(ctx, id)
+	require.Nil(t, err)
+	assert.False(t, ok) // not dead
+
+	n, err = q.Process(ctx, func(_ context.Context, job Job) error {
+		assert.Equal(t, 1, job.Attempts)
+
+		return nil
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	job = q.get(t, id)
+	assert.Equal(t, StatusDone, job.Status)
+	assert.Equal(t, "", job.LastError)
+
+	dead, err = q.Dead(ctx)
+	require.Nil(t, err)
+	assert.Empty(t, dead)
+}
+
+func Test_VisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	q, c := newQueue(t, Config{MaxAttempts: 2, VisibilityTimeout: time.Minute})
+
+	id, err := q.Enqueue(ctx, Job{})
+	require.Nil(t, err)
+
+	crashed, err := q.claim(ctx, 1) // worker crashed, job is not finished
+	require.Nil(t, err)
+	require.Len(t, crashed, 1)
+
+	c.Add(time.Minute + time.Second)
+
+	jobs, err := q.claim(ctx, 1)
+	require.Nil(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, id, jobs[0].ID)
+	assert.Equal(t, 2, jobs[0].Attempts)
+
+	ok, err := q.finish(ctx, crashed[0], nil)
+	require.Nil(t, err)
+	assert.False(t, ok) // lease of stale attempt is lost
+
+	c.Add(time.Minute + time.Second) // crashed on the last attempt
+
+	jobs, err = q.claim(ctx, 1)
+	require.Nil(t, err)
+	assert.Empty(t, jobs)
+
+	job := q.get(t, id)
+	assert.Equal(t, StatusDead, job.Status)
+	assert.Equal(t, errVisibilityTimeout, job.LastError)
+}
+
+func Test_Work(t *testing.T) {
+	q, _ := newQueue(t, Config{Workers: 3, PollInterval: 10 * time.Millisecond})
+	q.now = func() time.Time { return time.Now().UTC() }
+
+	const cnt = 20
+
+	for i := 0; i < cnt; i++ {
+		_, err := q.Enqueue(context.Background(), Job{Priority: i})
+		require.Nil(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var handled int32
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		q.Work(ctx, func(context.Context, Job) error {
+			atomic.AddInt32(&handled, 1)
+
+			return nil
+		})
+	}()
+
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&handled) == cnt }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	jobs := []Job{}
+	require.Nil(t, q.repo.FindBy(context.Background(), []string{"*"}, squirrel.Eq{"queue": DefaultName}, &jobs))
+	require.Len(t, jobs, cnt)
+
+	for _, job := range jobs {
+		assert.Equal(t, StatusDone, job.Status)
+		assert.Equal(t, 1, job.Attempts)
+	}
+}
+
+func Test_WorkStop(t *testing.T) {
+	q, _ := newQueue(t, Config{})
+	q.now = func() time.Time { return time.Now().UTC() }
+
+	id, err := q.Enqueue(context.Background(), Job{})
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan struct{})
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	q.Work(ctx, func(ctx context.Context, _ Job) error {
+		close(started)
+		<-ctx.Done()
+
+		return ctx.Err()
+	})
+
+	job := q.get(t, id)
+	assert.Equal(t, StatusPending, job.Status)
+	assert.Equal(t, 0, job.Attempts) // attempt is not consumed by stop
+	assert.Nil(t, job.LockedUntil)
+}
@@ -0,0 +1,94 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Handler - handler of job, error of handler (or panic) is failed attempt. Ctx of handler is done on
+// VisibilityTimeout (job can be claimed by other worker after it) or stop of workers.
+type Handler func(ctx context.Context, job Job) error
+
+// Work - run Config.Workers workers which handle jobs of queue by handler, blocks until ctx is done and all
+// workers are stopped. Jobs interrupted by stop are returned to queue without consuming of attempt.
+func (q *Queue) Work(ctx context.Context, handler Handler) {
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < q.cfg.Workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			q.work(ctx, handler)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// work - loop of one worker, it polls queue every PollInterval if there are not ready jobs.
+func (q *Queue) work(ctx context.Context, handler Handler) {
+	for {
+		n, err := q.Process(ctx, handler)
+		if err != nil && ctx.Err() == nil { // errors of stop are not logged
+			q.logger.Error("[queue.Work] process of job failed", zap.Error(err))
+		}
+
+		if err == nil && n > 0 {
+			continue // probably there are more ready jobs
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(q.cfg.PollInterval):
+		}
+	}
+}
+
+// Process - claim one ready job and handle it by handler, return count of handled jobs (0 if there are not ready
+// jobs). It is used by Work, but can be called directly (e.g. by cron or in tests).
+func (q *Queue) Process(ctx context.Context, handler Handler) (int, error) {
+	jobs, err := q.claim(ctx, 1)
+	if err != nil || len(jobs) == 0 {
+		return 0, err
+	}
+
+	job := jobs[0]
+
+	hctx, cancel := context.WithTimeout(ctx, q.cfg.VisibilityTimeout)
+	jobErr := q.handle(hctx, handler, job)
+	cancel()
+
+	if jobErr != nil && ctx.Err() != nil { // interrupted by stop, ctx of Process is done already
+		return 0, q.release(context.Background(), job)
+	}
+
+	ok, err := q.finish(context.Background(), job, jobErr) // result is saved even if ctx is done during it
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		q.logger.Warn("[queue.Process] job was claimed again after visibility timeout, result is dropped",
+			zap.Int64("id", job.ID))
+	}
+
+	return 1, nil
+}
+
+// handle - call handler, panic of handler is error of attempt.
+func (q *Queue) handle(ctx context.Context, handler Handler, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
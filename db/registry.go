@@ -0,0 +1,104 @@
+package db
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+type (
+	// Registry - cached generic repositories of one connector, key - type of DTO (see repo.For),
+	// and set of registered (declared by repo.Register) DTO types.
+	// Zero value is ready to use, registry lives and dies together with its connector.
+	Registry struct {
+		m          sync.RWMutex
+		repos      map[reflect.Type]any // GRepository[I, D]
+		registered map[reflect.Type]struct{}
+	}
+
+	// Reconnector - connector which connection can be replaced after reconnect (e.g. in Storage.OnReconnect),
+	// cached repositories of connector are dropped, so they are recreated with new connection.
+	Reconnector interface {
+		Reconnect(PureSqlxConnection)
+	}
+)
+
+// Load - cached repository of DTO type.
+func (r *Registry) Load(t reflect.Type) (any, bool) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	g, found := r.repos[t]
+
+	return g, found
+}
+
+// LoadOrStore - cached repository of DTO type, if it is absent => created by create func and cached.
+func (r *Registry) LoadOrStore(t reflect.Type, create func() any) any {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.loadOrStore(t, create)
+}
+
+// Register - LoadOrStore and mark DTO type registered (see Registered).
+func (r *Registry) Register(t reflect.Type, create func() any) any {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.registered == nil {
+		r.registered = map[reflect.Type]struct{}{}
+	}
+
+	r.registered[t] = struct{}{}
+
+	return r.loadOrStore(t, create)
+}
+
+func (r *Registry) loadOrStore(t reflect.Type, create func() any) any {
+	if g, found := r.repos[t]; found {
+		return g
+	}
+
+	if r.repos == nil {
+		r.repos = map[reflect.Type]any{}
+	}
+
+	g := create()
+	r.repos[t] = g
+
+	return g
+}
+
+// Registered - registered DTO types (sorted by type name), repositories created by repo.For only are not listed.
+func (r *Registry) Registered() []reflect.Type {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	types := make([]reflect.Type, 0, len(r.registered))
+	for t := range r.registered {
+		types = append(types, t)
+	}
+
+	sort.Slice(types, func(i, j int) bool { return types[i].String() < types[j].String() })
+
+	return types
+}
+
+// Reset - drop all cached repositories (e.g. connection of connector is recreated), registered DTO types are kept,
+// their repositories are recreated on next usage.
+func (r *Registry) Reset() {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.repos = nil
+}
+
+// Unregister - drop all cached repositories and registered DTO types.
+func (r *Registry) Unregister() {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.repos = nil
+	r.registered = nil
+}
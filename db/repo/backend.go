@@ -0,0 +1,179 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/lifecycle"
+)
+
+type (
+	// Backend - connector without sql connection which creates repositories itself (e.g. in-memory connector
+	// of package db/fake), NewGen, For and Register of such connector return generic repository over repository
+	// of Backend, Join returns repository which fails with db.ErrNotSupported.
+	Backend interface {
+		BackendRepository(dto db.DTO) BackendRepository
+	}
+
+	// BackendRepository - repository of Backend for DTO (pk and tenant columns of DTO).
+	BackendRepository interface {
+		db.Repository
+
+		// CreateAs - CreateAndGetID reported to query hooks as method (Create of generic repository).
+		CreateAs(ctx context.Context, method string, obj any, id any) error
+	}
+
+	// backendRepository - db.GRepository over BackendRepository, raw sql methods of BackendRepository
+	// (Select*) are used by Iterate and Stream too, preload is not supported.
+	backendRepository[I db.ID, D db.DTO] struct {
+		BackendRepository
+	}
+)
+
+func (g *backendRepository[I, D]) Create(ctx context.Context, d D) (I, error) {
+	var id I
+
+	err := g.CreateAs(ctx, "Create", d, &id)
+
+	return id, err
+}
+
+func (g *backendRepository[I, D]) Get(ctx context.Context, id I) (D, error) {
+	var dto D
+	err := g.BackendRepository.Get(ctx, id, &dto)
+
+	return dto, err
+}
+
+func (g *backendRepository[I, D]) Update(ctx context.Context, id I, d D) (int64, error) {
+	return g.BackendRepository.Update(ctx, id, d)
+}
+
+func (g *backendRepository[I, D]) UpdateFields(ctx context.Context, id I, set any, fields ...db.Column) (int64, error) {
+	return g.BackendRepository.UpdateFields(ctx, id, set, fields...)
+}
+
+// Delete - if DTO implements db.BeforeDeleteHook, row is read by id before the hook (without transaction).
+func (g *backendRepository[I, D]) Delete(ctx context.Context, id I) (int64, error) {
+	var dto D
+	if _, ok := any(&dto).(db.BeforeDeleteHook); !ok {
+		return g.BackendRepository.Delete(ctx, id)
+	}
+
+	if err := g.BackendRepository.Get(ctx, id, &dto); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+
+		return RowsAffectedUnknown, err
+	}
+
+	if err := lifecycle.BeforeDelete(ctx, &dto); err != nil {
+		return RowsAffectedUnknown, fmt.Errorf("[repo.Delete] %w", err)
+	}
+
+	return g.BackendRepository.Delete(ctx, id)
+}
+
+func (g *backendRepository[I, D]) FindBy(
+	ctx context.Context, columns []db.Column, condition db.Condition, opts ...db.SelectOption,
+) ([]D, error) {
+	if len(db.NewSelectOptions(opts...).Preload) > 0 {
+		return nil, fmt.Errorf("[repo.FindBy] preload: %w", db.ErrNotSupported)
+	}
+
+	var dtos = make([]D, 0)
+	err := g.BackendRepository.FindBy(ctx, columns, condition, &dtos)
+
+	return dtos, err
+}
+
+func (g *backendRepository[I, D]) FindOneBy(
+	ctx context.Context, columns []db.Column, condition db.Condition,
+) (D, error) {
+	var dto D
+	err := g.BackendRepository.FindOneBy(ctx, columns, condition, &dto)
+
+	return dto, err
+}
+
+func (g *backendRepository[I, D]) Select(
+	ctx context.Context, builder db.SelectBuilder, opts ...db.SelectOption,
+) ([]D, error) {
+	if len(db.NewSelectOptions(opts...).Preload) > 0 {
+		return nil, fmt.Errorf("[repo.Select] preload: %w", db.ErrNotSupported)
+	}
+
+	var dtos = make([]D, 0)
+	err := g.BackendRepository.Select(ctx, builder, &dtos)
+
+	return dtos, err
+}
+
+func (g *backendRepository[I, D]) SelectWithCursorOnPKPagination(
+	ctx context.Context, builder db.SelectBuilder, params db.CursorPaginationParams,
+) ([]D, error) {
+	var dtos = make([]D, 0)
+	err := g.BackendRepository.SelectWithCursorOnPKPagination(ctx, builder, params, &dtos)
+
+	return dtos, err
+}
+
+func (g *backendRepository[I, D]) SelectWithPagePagination(
+	ctx context.Context, builder db.SelectBuilder, params db.PagePaginationParams,
+) ([]D, db.PagePaginationResults, error) {
+	var dtos = make([]D, 0)
+	res, err := g.BackendRepository.SelectWithPagePagination(ctx, builder, params, &dtos)
+
+	return dtos, res, err
+}
+
+func (g *backendRepository[I, D]) Iterate(ctx context.Context, builder db.SelectBuilder, fn func(D) error) error {
+	dtos, err := g.Select(ctx, builder)
+	if err != nil {
+		return err
+	}
+
+	for _, dto := range dtos {
+		if err = fn(dto); err != nil {
+			if errors.Is(err, db.ErrStopIteration) {
+				return nil
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *backendRepository[I, D]) IterateWithServerCursor(
+	ctx context.Context, builder db.SelectBuilder, _ uint64, fn func(D) error,
+) error {
+	return g.Iterate(ctx, builder, fn)
+}
+
+func (g *backendRepository[I, D]) Stream(ctx context.Context, builder db.SelectBuilder) (<-chan D, <-chan error) {
+	dtoCh, errCh := make(chan D), make(chan error, 1)
+
+	go func() {
+		defer close(errCh)
+		defer close(dtoCh)
+
+		if err := g.Iterate(ctx, builder, func(dto D) error {
+			select {
+			case dtoCh <- dto:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}); err != nil {
+			errCh <- err
+		}
+	}()
+
+	return dtoCh, errCh
+}
@@ -38,10 +38,18 @@ func (r *repo) UpdateCustom(context.Context, map[string]any, db.Condition) (int6
 	return 0, db.ErrInvalidRepoEmptyRepo
 }
 
+func (r *repo) Upsert(context.Context, []db.Column, []db.Argument, []db.Column, []db.Column) (int64, error) {
+	return 0, db.ErrInvalidRepoEmptyRepo
+}
+
 func (r *repo) Create(context.Context, any) (int64, error) {
 	return int64(0), db.ErrInvalidRepoEmptyRepo
 }
 
+func (r *repo) CreateAndGetID(context.Context, any, any) error {
+	return db.ErrInvalidRepoEmptyRepo
+}
+
 func (r *repo) Get(context.Context, db.ID, any) error {
 	return db.ErrInvalidRepoEmptyRepo
 }
@@ -50,6 +58,10 @@ func (r *repo) Update(context.Context, db.ID, any) (int64, error) {
 	return 0, db.ErrInvalidRepoEmptyRepo
 }
 
+func (r *repo) UpdateFields(context.Context, db.ID, any, ...db.Column) (int64, error) {
+	return 0, db.ErrInvalidRepoEmptyRepo
+}
+
 func (r *repo) Delete(context.Context, db.ID) (int64, error) {
 	return 0, db.ErrInvalidRepoEmptyRepo
 }
@@ -2,11 +2,13 @@ package repo
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-
-	"github.com/Masterminds/squirrel"
+	"reflect"
 
 	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/dialect"
 	"github.com/imperiuse/golib/db/genrepo/emptygen"
 	"github.com/imperiuse/golib/reflect/orm"
 )
@@ -26,17 +28,20 @@ func NewGen[I db.ID, D db.GDTO[I], C db.Config](connector db.Connector[C]) db.GR
 		return emptygen.NewGen[I, D]()
 	}
 
-	phf := cfg.PlaceholderFormat()
-	if phf == nil {
-		phf = squirrel.Dollar
+	if b, ok := any(connector).(Backend); ok {
+		return &backendRepository[I, D]{b.BackendRepository(dto)}
 	}
 
 	return &gRepository[I, D]{
 		repository{
-			logger: connector.Logger(),
-			dbConn: connector.Connection(),
-			phf:    phf,
-			name:   dto.Repo(),
+			logger:  connector.Logger(),
+			dbConn:  connector.Connection(),
+			phf:     dialect.PlaceholderFormat(cfg),
+			dialect: dialect.Of(cfg),
+			hook:    connector.QueryHook(),
+			name:    dto.Repo(),
+			pk:      orm.GetPrimaryKeyColumns(dto),
+			tenant:  orm.GetTenantColumn(dto),
 		},
 	}
 }
@@ -44,21 +49,9 @@ func NewGen[I db.ID, D db.GDTO[I], C db.Config](connector db.Connector[C]) db.GR
 func (g *gRepository[I, D]) Create(ctx context.Context, d D) (I, error) {
 	var lastInsertID I
 
-	g.logger.Info("[repo.Create]", g.loggerFieldRepo(), loggerFieldObj(d))
-
-	cols, vals := orm.GetDataForCreate(d)
+	err := g.createAndGetID(ctx, "Create", d, &lastInsertID)
 
-	query, args, err := squirrel.Insert(g.name).
-		Columns(cols...).
-		Values(vals...).
-		Suffix("RETURNING id").
-		PlaceholderFormat(g.phf).
-		ToSql()
-	if err != nil {
-		return lastInsertID, fmt.Errorf("[repo.Create] squirrel: %w", err)
-	}
-
-	return lastInsertID, g.create(ctx, query, &lastInsertID, args...)
+	return lastInsertID, err
 }
 
 func (g *gRepository[I, D]) Get(ctx context.Context, id I) (D, error) {
@@ -72,13 +65,49 @@ func (g *gRepository[I, D]) Update(ctx context.Context, id I, d D) (int64, error
 	return g.repository.Update(ctx, id, d)
 }
 
+func (g *gRepository[I, D]) UpdateFields(ctx context.Context, id I, set any, fields ...db.Column) (int64, error) {
+	return g.repository.UpdateFields(ctx, id, set, fields...)
+}
+
+// Delete - delete row by id, if DTO implements db.BeforeDeleteHook, row is read by id before the hook
+// in the same transaction (nothing to delete if row is not found).
 func (g *gRepository[I, D]) Delete(ctx context.Context, id I) (int64, error) {
-	return g.repository.Delete(ctx, id)
+	var dto D
+	if _, ok := any(&dto).(db.BeforeDeleteHook); !ok {
+		return g.repository.Delete(ctx, id)
+	}
+
+	var n int64 = RowsAffectedUnknown
+
+	err := g.inTx(ctx, func(ctx context.Context) (err error) {
+		if err = g.repository.Get(ctx, id, &dto); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+
+			return err
+		}
+
+		if err = any(&dto).(db.BeforeDeleteHook).BeforeDelete(ctx); err != nil {
+			return fmt.Errorf("[repo.Delete] BeforeDelete: %w", err)
+		}
+
+		n, err = g.repository.Delete(ctx, id)
+
+		return err
+	})
+
+	return n, err
 }
 
-func (g *gRepository[I, D]) FindBy(ctx context.Context, columns []db.Column, condition db.Condition) ([]D, error) {
+func (g *gRepository[I, D]) FindBy(
+	ctx context.Context, columns []db.Column, condition db.Condition, opts ...db.SelectOption,
+) ([]D, error) {
 	var dtos = make([]D, 0)
 	err := g.repository.FindBy(ctx, columns, condition, &dtos)
+	if err == nil {
+		err = g.preload(ctx, reflect.ValueOf(dtos), db.NewSelectOptions(opts...).Preload)
+	}
 
 	return dtos, err
 }
@@ -90,9 +119,12 @@ func (g *gRepository[I, D]) FindOneBy(ctx context.Context, columns []db.Column,
 	return dto, err
 }
 
-func (g *gRepository[I, D]) Select(ctx context.Context, builder db.SelectBuilder) ([]D, error) {
+func (g *gRepository[I, D]) Select(ctx context.Context, builder db.SelectBuilder, opts ...db.SelectOption) ([]D, error) {
 	var dtos = make([]D, 0)
 	err := g.repository.Select(ctx, builder, &dtos)
+	if err == nil {
+		err = g.preload(ctx, reflect.ValueOf(dtos), db.NewSelectOptions(opts...).Preload)
+	}
 
 	return dtos, err
 }
@@ -0,0 +1,169 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/transaction"
+)
+
+const serverCursorName = "golib_repo_iterate_cursor" // cursor lives only inside own transaction, so name can be constant
+
+// Iterate - scan rows one by one (sqlx.Rows.StructScan) and call fn for each of them, without materialize all rows.
+// Return db.ErrStopIteration from fn for break loop without error.
+func (g *gRepository[I, D]) Iterate(ctx context.Context, sb db.SelectBuilder, fn func(D) error) error {
+	sb, err := g.scopeSelect(ctx, "Iterate", sb)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := sb.
+		From(g.table()).
+		PlaceholderFormat(g.phf).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("[repo.Iterate] squirrel: %w", err)
+	}
+
+	return g.run(ctx, "Iterate", nil, query, args, func(ctx context.Context) (int64, error) {
+		rows, err := g.dbConn.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return db.RowsUnknown, fmt.Errorf("[repo.Iterate] dbConn.QueryxContext: %w", err)
+		}
+
+		cnt := int64(0)
+		if err = scanEach(ctx, rows, func(d D) error {
+			cnt++
+
+			return fn(d)
+		}); err != nil && !errors.Is(err, db.ErrStopIteration) {
+			return cnt, fmt.Errorf("[repo.Iterate] %w", err)
+		}
+
+		return cnt, nil
+	})
+}
+
+// IterateWithServerCursor - the same as Iterate, but use server side cursor (DECLARE ... CURSOR / FETCH) inside
+// read only transaction, rows are fetched from server by fetchSize chunks. Useful for very large result sets.
+// Postgres only.
+func (g *gRepository[I, D]) IterateWithServerCursor(
+	ctx context.Context, sb db.SelectBuilder, fetchSize uint64, fn func(D) error,
+) error {
+	if fetchSize == 0 {
+		return db.ErrZeroFetchSize
+	}
+
+	sb, err := g.scopeSelect(ctx, "IterateWithServerCursor", sb)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := sb.
+		From(g.table()).
+		PlaceholderFormat(g.phf).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("[repo.IterateWithServerCursor] squirrel: %w", err)
+	}
+
+	fetchQuery := fmt.Sprintf("FETCH FORWARD %d FROM %s", fetchSize, serverCursorName)
+
+	return g.run(ctx, "IterateWithServerCursor", nil, query, args, func(ctx context.Context) (int64, error) {
+		total := int64(0)
+
+		err := transaction.WithTransaction(ctx, &sql.TxOptions{ReadOnly: true}, g.dbConn, func(tx *sqlx.Tx) error {
+			if _, err := tx.ExecContext(ctx,
+				fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR %s", serverCursorName, query), args...); err != nil {
+				return fmt.Errorf("declare cursor: %w", err)
+			}
+
+			for {
+				rows, err := tx.QueryxContext(ctx, fetchQuery)
+				if err != nil {
+					return fmt.Errorf("fetch cursor: %w", err)
+				}
+
+				cnt := uint64(0)
+				if err = scanEach(ctx, rows, func(d D) error {
+					cnt++
+					total++
+
+					return fn(d)
+				}); err != nil {
+					return err
+				}
+
+				if cnt < fetchSize {
+					return nil // cursor exhausted, transaction commit closes it
+				}
+			}
+		})
+		if err != nil && !errors.Is(err, db.ErrStopIteration) {
+			return total, fmt.Errorf("[repo.IterateWithServerCursor] %w", err)
+		}
+
+		return total, nil
+	})
+}
+
+// Stream - channel based wrapper over Iterate. Data channel is closed when rows are over, ctx canceled or error happened,
+// after that error channel return error (if any) and be closed too.
+func (g *gRepository[I, D]) Stream(ctx context.Context, sb db.SelectBuilder) (<-chan D, <-chan error) {
+	ch, errCh := make(chan D), make(chan error, 1)
+
+	go func() {
+		defer close(errCh)
+		defer close(ch)
+
+		if err := g.Iterate(ctx, sb, func(d D) error {
+			select {
+			case ch <- d:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}); err != nil {
+			errCh <- err
+		}
+	}()
+
+	return ch, errCh
+}
+
+// scanEach - scan rows one by one, db.AfterGetHook of row is called before fn.
+func scanEach[D any](ctx context.Context, rows *sqlx.Rows, fn func(D) error) (err error) {
+	defer func() {
+		if errC := rows.Close(); errC != nil && err == nil {
+			err = fmt.Errorf("rows.Close: %w", errC)
+		}
+	}()
+
+	for rows.Next() {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		var d D
+		if err = structScan(rows, &d); err != nil {
+			return fmt.Errorf("rows.StructScan: %w", err)
+		}
+
+		if h, ok := any(&d).(db.AfterGetHook); ok {
+			if err = h.AfterGet(ctx); err != nil {
+				return fmt.Errorf("AfterGet: %w", err)
+			}
+		}
+
+		if err = fn(d); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
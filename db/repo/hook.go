@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/hook"
+	"github.com/imperiuse/golib/db/transaction"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+// WithQueryHook - set hook (usually chain of connector hooks) which is called around every query of repository.
+func (r *repository) WithQueryHook(h db.QueryHook) *repository {
+	r.hook = h
+
+	return r
+}
+
+// run - execute query with hooks of repository (see hook.Run).
+func (r *repository) run(
+	ctx context.Context, method string, obj any, query db.Query, args []any, fn func(context.Context) (int64, error),
+) error {
+	return hook.Run(ctx, r.hook, &db.QueryEvent{Repo: r.name, Method: method, Obj: obj, Query: query, Args: args}, fn)
+}
+
+// conn - transaction of repository connection of ctx (see transaction.ContextWithTx) or the connection itself,
+// transaction of other connection (e.g. other database) is not used.
+func (r *repository) conn(ctx context.Context) sqlx.ExtContext {
+	if tx, ok := transaction.TxFromContextFor(ctx, r.dbConn); ok {
+		return tx
+	}
+
+	return r.dbConn
+}
+
+// exec - ExecContext with hooks, return rows affected.
+func (r *repository) exec(ctx context.Context, method string, obj any, query db.Query, args []any) (int64, error) {
+	var ra int64 = RowsAffectedUnknown
+
+	err := r.run(ctx, method, obj, query, args, func(ctx context.Context) (int64, error) {
+		res, err := r.conn(ctx).ExecContext(ctx, query, args...)
+		if err != nil {
+			return db.RowsUnknown, fmt.Errorf("[repo.%s] dbConn.ExecContext: %w", method, err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return db.RowsUnknown, fmt.Errorf("[repo.%s] res.RowsAffected: %w", method, err)
+		}
+
+		ra = n
+
+		return n, nil
+	})
+
+	return ra, err
+}
+
+// selectContext - sqlx.SelectContext with hooks (orm_json and derived columns, see selectJSON),
+// db.AfterGetHook is called for every row.
+func (r *repository) selectContext(ctx context.Context, method string, query db.Query, args []any, target any) error {
+	selectFn := sqlx.SelectContext
+	if orm.NeedsScanTargets(target) {
+		selectFn = selectJSON
+	}
+
+	if err := r.run(ctx, method, nil, query, args, func(ctx context.Context) (int64, error) {
+		if err := selectFn(ctx, r.conn(ctx), target, query, args...); err != nil {
+			return db.RowsUnknown, err
+		}
+
+		return sliceLen(target), nil
+	}); err != nil {
+		return err
+	}
+
+	return afterGetAll(ctx, method, target)
+}
+
+// getContext - sqlx.GetContext with hooks (orm_json and derived columns, see getJSON), then db.AfterGetHook.
+func (r *repository) getContext(ctx context.Context, method string, query db.Query, args []any, target any) error {
+	getFn := sqlx.GetContext
+	if orm.NeedsScanTargets(target) {
+		getFn = getJSON
+	}
+
+	if err := r.run(ctx, method, nil, query, args, func(ctx context.Context) (int64, error) {
+		if err := getFn(ctx, r.conn(ctx), target, query, args...); err != nil {
+			return 0, err
+		}
+
+		return 1, nil
+	}); err != nil {
+		return err
+	}
+
+	return afterGet(ctx, method, target)
+}
+
+func sliceLen(target any) int64 {
+	v := reflect.Indirect(reflect.ValueOf(target))
+	if v.Kind() != reflect.Slice {
+		return db.RowsUnknown
+	}
+
+	return int64(v.Len())
+}
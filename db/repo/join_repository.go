@@ -0,0 +1,193 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/dialect"
+	"github.com/imperiuse/golib/db/genrepo/emptygen"
+	"github.com/imperiuse/golib/db/hook"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+type (
+	joinRepository[D any] struct {
+		dbConn db.PureSqlxConnection
+		phf    db.PlaceholderFormat
+		hook   db.QueryHook
+
+		cols  []db.Column
+		parts []orm.JoinPart
+		err   error // invalid tags of composite DTO, returned by every query
+	}
+)
+
+// Join - create db.JoinRepository for composite DTO (like dto.UsersRole),
+// SELECT ... FROM a JOIN b ON ... query is built from DTO tags only, see orm.GetJoinParts.
+// if cfg.IsEnableValidationRepoNames() == true => all tables of composite DTO must be allowed.
+// Joined table without condition (orm.ErrNoJoinCond) is returned by every method of repository,
+// db.ErrNotSupported for connector without sql connection (see Backend).
+func Join[D any, C db.Config](connector db.Connector[C]) db.JoinRepository[D] {
+	var dto D
+
+	cfg := connector.Config()
+
+	parts, err := orm.GetJoinParts(&dto)
+	if cfg.IsEnableValidationRepoNames() {
+		for _, p := range parts {
+			if !connector.IsAllowRepo(p.Table) {
+				return emptygen.NewJoin[D]()
+			}
+		}
+	}
+
+	if _, ok := any(connector).(Backend); ok && err == nil {
+		err = fmt.Errorf("%T: %w", connector, db.ErrNotSupported)
+	}
+
+	return &joinRepository[D]{
+		dbConn: connector.Connection(),
+		phf:    dialect.PlaceholderFormat(cfg),
+		hook:   connector.QueryHook(),
+		cols:   orm.GetDataForSelectOnlyCols(&dto),
+		parts:  parts,
+		err:    err,
+	}
+}
+
+func (j *joinRepository[D]) Name() db.Table {
+	if len(j.parts) == 0 {
+		return ""
+	}
+
+	return j.parts[0].Table
+}
+
+// run - execute query with hooks, rows - count of fetched rows.
+func (j *joinRepository[D]) run(
+	ctx context.Context, method string, query db.Query, args []any, fn func(context.Context) (int64, error),
+) error {
+	return hook.Run(ctx, j.hook, &db.QueryEvent{Repo: j.Name(), Method: "Join." + method, Query: query, Args: args}, fn)
+}
+
+func (j *joinRepository[D]) selectContext(
+	ctx context.Context, method string, query db.Query, args []any, dtos *[]D,
+) error {
+	selectFn := sqlx.SelectContext
+	if orm.NeedsScanTargets(dtos) {
+		selectFn = selectJSON // columns "alias.column" are mapped to parts of composite DTO by db tag of part
+	}
+
+	if err := j.run(ctx, method, query, args, func(ctx context.Context) (int64, error) {
+		err := selectFn(ctx, j.dbConn, dtos, query, args...)
+
+		return int64(len(*dtos)), err
+	}); err != nil {
+		return err
+	}
+
+	return afterGetAll(ctx, "Join."+method, dtos)
+}
+
+// build - query of composite DTO, parts with orm_tenant column are scoped by tenant of ctx: root part (and parts
+// inner joined by USING) in WHERE, other joined parts in ON (so LEFT JOIN stays left), outer join by USING can't be
+// scoped (db.ErrTenantUsing).
+func (j *joinRepository[D]) build(ctx context.Context, sb db.SelectBuilder) (db.Query, []any, error) {
+	if j.err != nil {
+		return "", nil, j.err
+	}
+
+	if len(j.parts) < 2 {
+		return "", nil, db.ErrNotCompositeDTO
+	}
+
+	sb = sb.Columns(j.cols...).From(fmt.Sprintf("%s AS %s", j.parts[0].Table, j.parts[0].Alias))
+
+	for i, p := range j.parts {
+		column := qualified(p.Alias, p.Tenant)
+
+		tc, err := tenantCond(ctx, column)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if i > 0 {
+			clause := fmt.Sprintf("%s JOIN %s AS %s %s", p.Type, p.Table, p.Alias, p.Cond)
+			if tc != nil && strings.HasPrefix(strings.ToUpper(p.Cond), "ON ") {
+				sb = sb.JoinClause(clause+" AND "+column+" = ?", tc[column])
+
+				continue
+			}
+
+			if tc != nil && p.Type != orm.JoinInner {
+				return "", nil, fmt.Errorf("%s JOIN %s: %w", p.Type, p.Table, db.ErrTenantUsing)
+			}
+
+			sb = sb.JoinClause(clause)
+		}
+
+		if tc != nil {
+			sb = sb.Where(tc)
+		}
+	}
+
+	return sb.PlaceholderFormat(j.phf).ToSql()
+}
+
+func (j *joinRepository[D]) FindBy(ctx context.Context, condition db.Condition) ([]D, error) {
+	var dtos = make([]D, 0)
+
+	query, args, err := j.build(ctx, squirrel.Select().Where(condition))
+	if err != nil {
+		return dtos, fmt.Errorf("[repo.Join.FindBy] %w", err)
+	}
+
+	err = j.selectContext(ctx, "FindBy", query, args, &dtos)
+
+	return dtos, err
+}
+
+func (j *joinRepository[D]) FindOneBy(ctx context.Context, condition db.Condition) (D, error) {
+	var dto D
+
+	query, args, err := j.build(ctx, squirrel.Select().Where(condition).Limit(1))
+	if err != nil {
+		return dto, fmt.Errorf("[repo.Join.FindOneBy] %w", err)
+	}
+
+	getFn := sqlx.GetContext
+	if orm.NeedsScanTargets(&dto) {
+		getFn = getJSON
+	}
+
+	err = j.run(ctx, "FindOneBy", query, args, func(ctx context.Context) (int64, error) {
+		if err := getFn(ctx, j.dbConn, &dto, query, args...); err != nil {
+			return 0, err
+		}
+
+		return 1, nil
+	})
+	if err == nil {
+		err = afterGet(ctx, "Join.FindOneBy", &dto)
+	}
+
+	return dto, err
+}
+
+func (j *joinRepository[D]) Select(ctx context.Context, sb db.SelectBuilder) ([]D, error) {
+	var dtos = make([]D, 0)
+
+	query, args, err := j.build(ctx, sb)
+	if err != nil {
+		return dtos, fmt.Errorf("[repo.Join.Select] %w", err)
+	}
+
+	err = j.selectContext(ctx, "Select", query, args, &dtos)
+
+	return dtos, err
+}
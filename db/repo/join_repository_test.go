@@ -0,0 +1,148 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/example/simple/config"
+	"github.com/imperiuse/golib/db/example/simple/dto"
+	"github.com/imperiuse/golib/db/genrepo/emptygen"
+	"github.com/imperiuse/golib/db/mocks"
+	"github.com/imperiuse/golib/db/tenant"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+type connectorStub[C db.Config] struct {
+	db.Connector[C]
+	cfg      C
+	allowed  map[db.Table]bool
+	registry *db.Registry
+}
+
+func (c connectorStub[C]) Config() C                         { return c.cfg }
+func (c connectorStub[C]) Logger() db.Logger                 { return zap.NewNop() }
+func (c connectorStub[C]) Connection() db.PureSqlxConnection { return mocks.GoodMockDBConn }
+func (c connectorStub[C]) IsAllowRepo(t db.Table) bool       { return c.allowed[t] }
+func (c connectorStub[C]) QueryHook() db.QueryHook           { return nil }
+func (c connectorStub[C]) Registry() *db.Registry            { return c.registry }
+func (c connectorStub[C]) AddAllowsRepos(repos ...db.Table) {
+	for _, r := range repos {
+		c.allowed[r] = true
+	}
+}
+
+type usersRoleWithPaginator[I db.ID] struct {
+	dto.User[I]      `db:"u" orm_alias:"u"`
+	dto.Role[I]      `db:"r" orm_alias:"r" orm_join:"u.role_id = r.id"`
+	dto.Paginator[I] `db:"p" orm_alias:"p" orm_join:"ON p.n = u.id" orm_join_type:"left"`
+}
+
+type usersRoleNoCond struct {
+	dto.User[dto.ID]      `db:"u" orm_alias:"u"`
+	dto.Role[dto.ID]      `db:"r" orm_alias:"r" orm_join:"u.role_id = r.id"`
+	dto.Paginator[dto.ID] `db:"p" orm_alias:"p"`
+}
+
+type (
+	TenantUser struct {
+		ID       int64 `db:"id" orm_use_in:"select"`
+		TenantID int64 `db:"tenant_id" orm_use_in:"select" orm_tenant:"true"`
+		_        any   `orm_table_name:"users"`
+	}
+
+	TenantOrder struct {
+		ID       int64 `db:"id" orm_use_in:"select"`
+		TenantID int64 `db:"tenant_id" orm_use_in:"select" orm_tenant:"true"`
+		_        any   `orm_table_name:"orders"`
+	}
+
+	tenantUserOrder struct {
+		TenantUser  `db:"u" orm_alias:"u"`
+		TenantOrder `db:"o" orm_alias:"o" orm_join:"o.id = u.id" orm_join_type:"left"`
+	}
+
+	tenantUserOrderUsing struct {
+		TenantUser  `db:"u" orm_alias:"u"`
+		TenantOrder `db:"o" orm_alias:"o" orm_join:"USING (id)" orm_join_type:"left"`
+	}
+)
+
+func Test_JoinBuild(t *testing.T) {
+	c := connectorStub[config.SimpleTestConfig]{cfg: config.New(squirrel.Question, false, false)}
+
+	j := Join[dto.UsersRole[dto.ID], config.SimpleTestConfig](c).(*joinRepository[dto.UsersRole[dto.ID]])
+	assert.Equal(t, "Users", j.Name())
+
+	query, args, err := j.build(context.Background(), squirrel.Select().Where(squirrel.Eq{"u.id": 1}))
+	assert.Nil(t, err)
+	assert.Equal(t, []any{1}, args)
+	assert.Equal(t, "SELECT u.id as \"u.id\", u.created_at as \"u.created_at\", u.updated_at as \"u.updated_at\", "+
+		"u.name as \"u.name\", u.email as \"u.email\", u.password as \"u.password\", u.role_id as \"u.role_id\", "+
+		"r.id as \"r.id\", r.created_at as \"r.created_at\", r.updated_at as \"r.updated_at\", "+
+		"r.name as \"r.name\", r.rights as \"r.rights\" "+
+		"FROM Users AS u INNER JOIN Roles AS r ON u.role_id = r.id WHERE u.id = ?", query)
+
+	j2 := Join[usersRoleWithPaginator[dto.ID], config.SimpleTestConfig](c).(*joinRepository[usersRoleWithPaginator[dto.ID]])
+	query, _, err = j2.build(context.Background(), squirrel.Select().OrderBy("u.id"))
+	assert.Nil(t, err)
+	assert.Contains(t, query,
+		"FROM Users AS u INNER JOIN Roles AS r ON u.role_id = r.id LEFT JOIN Paginators AS p ON p.n = u.id ORDER BY u.id")
+}
+
+func Test_JoinBuildTenant(t *testing.T) {
+	c := connectorStub[config.SimpleTestConfig]{cfg: config.New(squirrel.Question, false, false)}
+	j := Join[tenantUserOrder, config.SimpleTestConfig](c).(*joinRepository[tenantUserOrder])
+
+	query, args, err := j.build(tenant.NewContext(context.Background(), 7), squirrel.Select().Where(squirrel.Eq{"u.id": 1}))
+	assert.Nil(t, err)
+	assert.Equal(t, []any{7, 1, 7}, args)
+	assert.Contains(t, query, "FROM users AS u LEFT JOIN orders AS o ON o.id = u.id AND o.tenant_id = ? "+
+		"WHERE u.id = ? AND u.tenant_id = ?")
+
+	_, _, err = j.build(context.Background(), squirrel.Select())
+	assert.ErrorIs(t, err, db.ErrNoTenant)
+
+	// left join by USING would become inner by tenant condition in WHERE
+	ju := Join[tenantUserOrderUsing, config.SimpleTestConfig](c).(*joinRepository[tenantUserOrderUsing])
+	_, _, err = ju.build(tenant.NewContext(context.Background(), 7), squirrel.Select())
+	assert.ErrorIs(t, err, db.ErrTenantUsing)
+}
+
+func Test_JoinNotValid(t *testing.T) {
+	ctx := context.Background()
+
+	c := connectorStub[config.SimpleTestConfig]{
+		cfg:     config.New(squirrel.Dollar, true, false),
+		allowed: map[db.Table]bool{"Users": true},
+	}
+
+	assert.Equal(t, emptygen.NewJoin[dto.UsersRole[dto.ID]](), Join[dto.UsersRole[dto.ID], config.SimpleTestConfig](c))
+
+	c.allowed["Roles"] = true
+	assert.NotEqual(t, emptygen.NewJoin[dto.UsersRole[dto.ID]](), Join[dto.UsersRole[dto.ID], config.SimpleTestConfig](c))
+
+	c.cfg = config.New(squirrel.Dollar, false, false)
+	r := Join[dto.User[dto.ID], config.SimpleTestConfig](c)
+	_, err := r.FindBy(ctx, squirrel.Eq{"id": 1})
+	assert.Equal(t, db.ErrNotCompositeDTO, errors.Unwrap(err))
+	_, err = r.FindOneBy(ctx, squirrel.Eq{"id": 1})
+	assert.Equal(t, db.ErrNotCompositeDTO, errors.Unwrap(err))
+	_, err = r.Select(ctx, squirrel.Select())
+	assert.Equal(t, db.ErrNotCompositeDTO, errors.Unwrap(err))
+	assert.Equal(t, "[repo.Join.Select] "+db.ErrNotCompositeDTO.Error(), err.Error())
+
+	noCond := Join[usersRoleNoCond, config.SimpleTestConfig](c) // third part without orm_join
+	_, err = noCond.FindBy(ctx, squirrel.Eq{"u.id": 1})
+	assert.ErrorIs(t, err, orm.ErrNoJoinCond)
+	_, err = noCond.FindOneBy(ctx, squirrel.Eq{"u.id": 1})
+	assert.ErrorIs(t, err, orm.ErrNoJoinCond)
+	_, err = noCond.Select(ctx, squirrel.Select())
+	assert.ErrorIs(t, err, orm.ErrNoJoinCond)
+}
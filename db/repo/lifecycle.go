@@ -0,0 +1,87 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/imperiuse/golib/db/lifecycle"
+	"github.com/imperiuse/golib/db/transaction"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+// Lifecycle hooks of DTO (db.BeforeCreateHook, db.AfterCreateHook, db.BeforeUpdateHook, db.AfterGetHook,
+// db.BeforeDeleteHook) are detected by type assertion of DTO (pointer to copy of DTO if DTO is passed by value),
+// see package db/lifecycle.
+// Write operation and its hooks are executed in one transaction (transaction of ctx or new one),
+// error of hook rolls back the transaction.
+
+// withTx - fn in transaction of repository connection of ctx (see transaction.ContextWithTx) or in new transaction.
+func (r *repository) withTx(ctx context.Context, fn transaction.TxFn) error {
+	if tx, ok := transaction.TxFromContextFor(ctx, r.dbConn); ok {
+		return fn(tx)
+	}
+
+	return transaction.WithTransaction(ctx, nil, r.dbConn, fn)
+}
+
+// inTx - fn in one transaction with hooks, ctx of fn contains the transaction.
+func (r *repository) inTx(ctx context.Context, fn func(context.Context) error) error {
+	return transaction.InTransaction(ctx, r.dbConn, func(ctx context.Context, _ *sqlx.Tx) error {
+		return fn(ctx)
+	})
+}
+
+// validateDTO - check validate tags of DTO (see lifecycle.Validate).
+func validateDTO(obj any) error {
+	return lifecycle.Validate(obj)
+}
+
+func hasCreateHooks(obj any) bool {
+	return lifecycle.HasCreateHooks(obj)
+}
+
+func beforeCreate(ctx context.Context, method string, obj any) error {
+	return wrapHook(method, lifecycle.BeforeCreate(ctx, obj))
+}
+
+func afterCreate(ctx context.Context, method string, obj any) error {
+	return wrapHook(method, lifecycle.AfterCreate(ctx, obj))
+}
+
+func beforeUpdate(ctx context.Context, obj any) error {
+	return wrapHook("Update", lifecycle.BeforeUpdate(ctx, obj))
+}
+
+func afterGet(ctx context.Context, method string, obj any) error {
+	return wrapHook(method, lifecycle.AfterGet(ctx, obj))
+}
+
+// afterGetAll - afterGet for every row of target (pointer to []D or []*D).
+func afterGetAll(ctx context.Context, method string, target any) error {
+	return wrapHook(method, lifecycle.AfterGetAll(ctx, target))
+}
+
+func wrapHook(method string, err error) error {
+	if err != nil {
+		return fmt.Errorf("[repo.%s] %w", method, err)
+	}
+
+	return nil
+}
+
+// setCreatedID - set id of created row to pk field of DTO (for AfterCreate hook), not composite pk only.
+func (r *repository) setCreatedID(obj any, id any) {
+	if len(r.pk) != 1 {
+		return
+	}
+
+	v, found := orm.GetColumnValue(reflect.ValueOf(obj), r.pk[0])
+	if !found || !v.CanAddr() || !v.IsZero() {
+		return
+	}
+
+	_ = setID(v.Addr().Interface(), reflect.Indirect(reflect.ValueOf(id)).Interface()) // pk field of other type is left
+}
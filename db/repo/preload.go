@@ -0,0 +1,175 @@
+package repo
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+// preload - load relation fields (see db.Preload) of parents (slice of DTO), one query per relation.
+func (r *repository) preload(ctx context.Context, parents reflect.Value, fields []string) error {
+	if len(fields) == 0 || parents.Len() == 0 {
+		return nil
+	}
+
+	parent := reflect.New(parents.Type().Elem()).Interface()
+
+	for _, field := range fields {
+		rel, found := orm.GetRelation(parent, field)
+		if !found {
+			return fmt.Errorf("[repo.Preload] %s: %w", field, db.ErrUnknownRelation)
+		}
+
+		if err := r.preloadRelation(ctx, parents, rel); err != nil {
+			return fmt.Errorf("[repo.Preload] %s: %w", field, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *repository) preloadRelation(ctx context.Context, parents reflect.Value, rel orm.Relation) error {
+	related := reflect.New(rel.Type).Interface()
+
+	table := orm.GetTableName(related)
+	if dto, ok := related.(db.DTO); ok {
+		table = dto.Repo()
+	}
+
+	if table == "" {
+		return orm.ErrNoTableName
+	}
+
+	rr := r.relatedRepository(table, orm.GetPrimaryKeyColumns(related), orm.GetTenantColumn(related))
+
+	// belongs to: fk column of parent = pk of related, has many: pk of parent = fk column of related
+	parentKey, relatedKey := rel.ForeignKey, rr.pk[0]
+	if rel.Kind == orm.RelationHasMany {
+		if len(r.pk) > 1 {
+			return db.ErrCompositePK
+		}
+
+		parentKey, relatedKey = r.pk[0], rel.ForeignKey
+	} else if len(rr.pk) > 1 {
+		return db.ErrCompositePK // one fk column can't reference composite pk
+	}
+
+	keys := make([]any, parents.Len())
+	ids, seen := []any{}, map[any]bool{}
+
+	for i := 0; i < parents.Len(); i++ {
+		v, found := orm.GetColumnValue(parents.Index(i), parentKey)
+		if !found {
+			return fmt.Errorf("column %s: %w", parentKey, db.ErrUnknownRelation)
+		}
+
+		if keys[i] = keyOf(v); keys[i] != nil && !seen[keys[i]] {
+			ids, seen[keys[i]] = append(ids, v.Interface()), true
+		}
+	}
+
+	byKey := map[any][]reflect.Value{}
+
+	if len(ids) > 0 {
+		cond := squirrel.Eq{relatedKey: ids}
+
+		tc, err := tenantCond(ctx, rr.tenant) // related rows of the same tenant only
+		if err != nil {
+			return err
+		}
+
+		for c, v := range tc {
+			cond[c] = v
+		}
+
+		query, args, err := squirrel.
+			Select("*").
+			From(rr.table()).
+			Where(cond).
+			PlaceholderFormat(rr.phf).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("squirrel: %w", err)
+		}
+
+		rows := reflect.New(reflect.SliceOf(rel.Type))
+		if err = rr.selectContext(ctx, "Preload", query, args, rows.Interface()); err != nil {
+			return err
+		}
+
+		for i := 0; i < rows.Elem().Len(); i++ {
+			row := rows.Elem().Index(i)
+
+			v, found := orm.GetColumnValue(row, relatedKey)
+			if !found {
+				return fmt.Errorf("column %s: %w", relatedKey, db.ErrUnknownRelation)
+			}
+
+			byKey[keyOf(v)] = append(byKey[keyOf(v)], row)
+		}
+	}
+
+	for i := 0; i < parents.Len(); i++ {
+		setRelation(parents.Index(i).FieldByIndex(rel.Index), byKey[keys[i]])
+	}
+
+	return nil
+}
+
+// relatedRepository - repository of related table with the same connection, dialect and hooks,
+// so query events of preload are reported for related table.
+func (r *repository) relatedRepository(table db.Table, pk []db.Column, tenant db.Column) *repository {
+	rr := *r
+	rr.name, rr.pk, rr.tenant = table, pk, tenant
+
+	return &rr
+}
+
+// setRelation - set related rows to relation field: slice (has many), pointer or struct (belongs to).
+func setRelation(field reflect.Value, rows []reflect.Value) {
+	switch field.Kind() {
+	case reflect.Slice:
+		s := reflect.MakeSlice(field.Type(), 0, len(rows))
+		for _, row := range rows {
+			if field.Type().Elem().Kind() == reflect.Pointer {
+				row = row.Addr()
+			}
+
+			s = reflect.Append(s, row)
+		}
+
+		field.Set(s)
+	case reflect.Pointer:
+		field.Set(reflect.Zero(field.Type()))
+
+		if len(rows) > 0 {
+			p := reflect.New(field.Type().Elem())
+			p.Elem().Set(rows[0])
+			field.Set(p)
+		}
+	default:
+		if len(rows) > 0 {
+			field.Set(rows[0])
+		}
+	}
+}
+
+// keyOf - comparable key of column value (driver value, so int and int64 ids are equal), nil for NULL.
+func keyOf(v reflect.Value) any {
+	dv, err := driver.DefaultParameterConverter.ConvertValue(v.Interface())
+	if err != nil {
+		return v.Interface()
+	}
+
+	if b, ok := dv.([]byte); ok {
+		return string(b)
+	}
+
+	return dv
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"reflect"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/genrepo/emptygen"
+)
+
+// Register - create db.GRepository for DTO (or return already registered one), cache it in connector.Registry()
+// and mark DTO registered (see Registered). Register does not touch allowed repos of connector:
+// if cfg.IsEnableValidationRepoNames() == true, repo name of DTO must be allowed explicitly
+// (connector.AddAllowsRepos) before, otherwise emptygen repo is returned (DTO is not registered).
+//
+// Usage (once, on start of app):
+//
+//	connector.AddAllowsRepos(dto.User[dto.ID]{}.Repo())
+//	repo.Register[dto.ID, dto.User[dto.ID]](connector)
+//
+// and later anywhere: repo.For[dto.ID, dto.User[dto.ID]](connector).
+func Register[I db.ID, D db.GDTO[I], C db.Config](connector db.Connector[C]) db.GRepository[I, D] {
+	var dto D
+	if connector.Config().IsEnableValidationRepoNames() && !connector.IsAllowRepo(dto.Repo()) {
+		return emptygen.NewGen[I, D]()
+	}
+
+	t := reflect.TypeOf((*D)(nil)).Elem()
+
+	return connector.Registry().Register(t, func() any { return NewGen[I, D](connector) }).(db.GRepository[I, D])
+}
+
+// For - cached db.GRepository for DTO, analog of generic method connector.Repo[I, D]() (Go does not support it).
+// Not registered DTO is created by NewGen and cached too (but it is not listed by Registered),
+// if cfg.IsEnableValidationRepoNames() == true and repo is not allowed => emptygen repo (not cached).
+// Cached repositories are dropped on reconnect of connector (see db.Reconnector).
+func For[I db.ID, D db.GDTO[I], C db.Config](connector db.Connector[C]) db.GRepository[I, D] {
+	t := reflect.TypeOf((*D)(nil)).Elem()
+	r := connector.Registry()
+
+	if g, found := r.Load(t); found {
+		return g.(db.GRepository[I, D])
+	}
+
+	var dto D
+	if connector.Config().IsEnableValidationRepoNames() && !connector.IsAllowRepo(dto.Repo()) {
+		return emptygen.NewGen[I, D]()
+	}
+
+	return r.LoadOrStore(t, func() any { return NewGen[I, D](connector) }).(db.GRepository[I, D])
+}
+
+// Registered - types of DTO registered for connector by Register (sorted by type name).
+func Registered[C db.Config](connector db.Connector[C]) []reflect.Type {
+	return connector.Registry().Registered()
+}
+
+// Unregister - drop all cached repositories and registered DTO of connector.
+func Unregister[C db.Config](connector db.Connector[C]) {
+	connector.Registry().Unregister()
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/example/simple/config"
+	"github.com/imperiuse/golib/db/example/simple/dto"
+	"github.com/imperiuse/golib/db/genrepo/emptygen"
+)
+
+func Test_Registry(t *testing.T) {
+	allowed := map[db.Table]bool{}
+
+	var c db.Connector[config.SimpleTestConfig] = &connectorStub[config.SimpleTestConfig]{
+		cfg:      config.New(squirrel.Dollar, true, false),
+		allowed:  allowed,
+		registry: &db.Registry{},
+	}
+
+	assert.Equal(t, emptygen.NewGen[dto.ID, dto.User[dto.ID]](), For[dto.ID, dto.User[dto.ID]](c)) // not allowed
+	assert.Equal(t, emptygen.NewGen[dto.ID, dto.User[dto.ID]](), Register[dto.ID, dto.User[dto.ID]](c))
+	assert.False(t, c.IsAllowRepo("Users")) // Register does not allow repo itself
+	assert.Empty(t, Registered(c))
+
+	c.AddAllowsRepos("Users")
+	users := Register[dto.ID, dto.User[dto.ID]](c)
+	assert.Equal(t, "Users", users.Name())
+	assert.Same(t, users, For[dto.ID, dto.User[dto.ID]](c))
+	assert.Same(t, users, Register[dto.ID, dto.User[dto.ID]](c))
+
+	allowed["Roles"] = true
+	roles := For[dto.ID, dto.Role[dto.ID]](c) // allowed, created and cached without Register
+	assert.Same(t, roles, For[dto.ID, dto.Role[dto.ID]](c))
+	assert.Same(t, roles, Register[dto.ID, dto.Role[dto.ID]](c))
+
+	allowed["Paginators"] = true
+	_ = For[dto.ID, dto.Paginator[dto.ID]](c) // not listed by Registered
+
+	assert.Equal(t, []reflect.Type{
+		reflect.TypeOf(dto.Role[dto.ID]{}),
+		reflect.TypeOf(dto.User[dto.ID]{}),
+	}, Registered(c))
+
+	var other db.Connector[config.SimpleTestConfig] = &connectorStub[config.SimpleTestConfig]{
+		cfg:      config.New(squirrel.Dollar, false, false),
+		registry: &db.Registry{},
+	}
+
+	assert.NotSame(t, users, For[dto.ID, dto.User[dto.ID]](other)) // cache is per connector
+	assert.Empty(t, Registered(other))
+
+	c.Registry().Reset() // reconnect of connector: repositories are recreated, registrations are kept
+	assert.NotSame(t, users, For[dto.ID, dto.User[dto.ID]](c))
+	assert.Len(t, Registered(c), 2)
+
+	Unregister(c)
+	assert.Empty(t, Registered(c))
+}
@@ -4,16 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"reflect"
+	"sort"
 	"strconv"
 
-	"go.uber.org/zap"
-
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
 
 	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/dialect"
 	"github.com/imperiuse/golib/db/helper"
-	"github.com/imperiuse/golib/db/transaction"
+	"github.com/imperiuse/golib/db/lifecycle"
 	"github.com/imperiuse/golib/reflect/orm"
 )
 
@@ -24,50 +26,73 @@ const (
 
 type (
 	repository struct {
-		logger db.Logger
-		dbConn db.PureSqlxConnection
-		phf    db.PlaceholderFormat
-		name   db.Table
+		logger  db.Logger
+		dbConn  db.PureSqlxConnection
+		phf     db.PlaceholderFormat
+		dialect db.Dialect
+		hook    db.QueryHook
+		name    db.Table
+		pk      []db.Column
+		tenant  db.Column // orm_tenant column of DTO, empty - repository is not scoped by tenant
 	}
 )
 
 func New(logger db.Logger, db db.PureSqlxConnection, tableName db.Table, phf db.PlaceholderFormat) *repository {
+	return NewWithDialect(logger, db, tableName, phf, dialect.Default)
+}
+
+// NewWithDialect - create repository for specific sql dialect (nil -> dialect.Default),
+// if phf == nil placeholder format of dialect is used.
+func NewWithDialect(
+	logger db.Logger, db db.PureSqlxConnection, tableName db.Table, phf db.PlaceholderFormat, d db.Dialect,
+) *repository {
+	if d == nil {
+		d = dialect.Default
+	}
+
 	if phf == nil {
-		phf = squirrel.Dollar
+		phf = d.PlaceholderFormat()
 	}
 
 	return &repository{
-		logger: logger,
-		dbConn: db,
-		name:   tableName,
-		phf:    phf,
+		logger:  logger,
+		dbConn:  db,
+		name:    tableName,
+		phf:     phf,
+		dialect: d,
+		pk:      []orm.Column{orm.DefaultPrimaryKey},
 	}
 }
 
-func (r *repository) loggerFieldRepo() zap.Field {
-	return zap.String("repo", r.name)
-}
+// WithPrimaryKey - use pk columns instead of default `id` in Create (RETURNING), Get, Update, Delete and cursor
+// pagination, several columns -> composite pk, id of Get, Update and Delete is []any or map[string]any of pk values.
+func (r *repository) WithPrimaryKey(pk ...db.Column) *repository {
+	if len(pk) > 0 {
+		r.pk = pk
+	}
 
-func loggerFieldObj(obj any) zap.Field {
-	return zap.Any("obj", obj)
+	return r
 }
 
-func loggerFieldID(id db.ID) zap.Field {
-	return zap.Any("id", id)
-}
+// ConvertIDToInt64 convert ID (integer or string of integer) to int64.
+func ConvertIDToInt64(id any) (int64, error) {
+	v := reflect.ValueOf(id)
 
-// ConvertIDToInt64 convert ID to int64.
-func ConvertIDToInt64(id any) int64 {
-	if id == nil {
-		return int64(0)
-	}
+	switch {
+	case v.CanInt():
+		return v.Int(), nil
+	case v.CanUint() && v.Uint() <= math.MaxInt64:
+		return int64(v.Uint()), nil
+	case v.Kind() == reflect.String:
+		n, err := strconv.ParseInt(v.String(), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("[repo.ConvertIDToInt64] %w", err)
+		}
 
-	temp, err := strconv.Atoi(fmt.Sprint(id))
-	if err != nil {
-		return int64(0)
+		return n, nil
 	}
 
-	return int64(temp)
+	return 0, fmt.Errorf("[repo.ConvertIDToInt64] %T: %w", id, db.ErrUnsupportedID)
 }
 
 // ConvertIDToString convert ID to string.
@@ -83,105 +108,319 @@ func (r *repository) Name() db.Table {
 	return r.name
 }
 
+// Create - insert DTO and return id of created row, SerialUnknown for not integer pk (uuid, string, composite),
+// use CreateAndGetID for them.
 func (r *repository) Create(ctx context.Context, obj any) (int64, error) {
-	r.logger.Info("[repo.Create]", r.loggerFieldRepo(), loggerFieldObj(obj))
+	if !IsIntegerPK(r.pk, obj) {
+		return SerialUnknown, r.createAndGetID(ctx, "Create", obj, new(any))
+	}
 
-	cols, vals := orm.GetDataForCreate(obj)
+	var lastInsertID = int64(SerialUnknown)
 
-	query, args, err := squirrel.
-		Insert(r.name).
-		Columns(cols...).
-		Values(vals...).
-		Suffix("RETURNING id"). // todo check this foe MySQL, Sqlite and other db.... I'm not sure ...
-		PlaceholderFormat(r.phf).
-		ToSql()
+	err := r.createAndGetID(ctx, "Create", obj, &lastInsertID)
+
+	return lastInsertID, err
+}
+
+// CreateAndGetID - the same as Create, but id of created row is set to id (pointer of any type: *uuid.UUID, *string),
+// for composite pk id is not set (all pk values are known by client).
+func (r *repository) CreateAndGetID(ctx context.Context, obj any, id any) error {
+	return r.createAndGetID(ctx, "CreateAndGetID", obj, id)
+}
+
+func (r *repository) createAndGetID(ctx context.Context, method string, obj any, id any) error {
+	data := lifecycle.Addressable(obj)
+
+	if _, err := tenantCond(ctx, r.tenant); err != nil {
+		return fmt.Errorf("[repo.%s] %w", method, err)
+	}
+
+	r.setTenant(ctx, data) // hooks see tenant of created row
+
+	if !hasCreateHooks(data) {
+		return r.insertDTO(ctx, method, obj, data, id)
+	}
+
+	return r.inTx(ctx, func(ctx context.Context) error {
+		if err := beforeCreate(ctx, method, data); err != nil {
+			return err
+		}
+
+		if err := r.insertDTO(ctx, method, obj, data, id); err != nil {
+			return err
+		}
+
+		r.setCreatedID(data, id)
+
+		return afterCreate(ctx, method, data)
+	})
+}
+
+// insertDTO - insert columns of data (addressable obj), obj is DTO of query event. Data is validated here, after
+// BeforeCreate hook (values set by hook are validated too).
+func (r *repository) insertDTO(ctx context.Context, method string, obj any, data any, id any) error {
+	if err := validateDTO(data); err != nil {
+		return fmt.Errorf("[repo.%s] %w", method, err)
+	}
+
+	cols, vals := orm.GetDataForCreate(data)
+
+	keys, err := orm.GetGeneratedKeys(data)
+	if err != nil {
+		return fmt.Errorf("[repo.%s] orm.GetGeneratedKeys: %w", method, err)
+	}
+
+	cols, vals = withGeneratedKeys(cols, vals, keys)
+
+	if cols, vals, err = r.scopeInsert(ctx, method, cols, vals); err != nil {
+		return err
+	}
+
+	query, args, err := r.insertBuilder(cols, vals, !r.isIDKnown(keys)).ToSql()
 	if err != nil {
-		return SerialUnknown, fmt.Errorf("[repo.Create] squirrel: %w", err)
+		return fmt.Errorf("[repo.%s] squirrel: %w", method, err)
+	}
+
+	return r.run(ctx, method, obj, query, args, func(ctx context.Context) (int64, error) {
+		return 1, r.create(ctx, query, id, keys, args...)
+	})
+}
+
+// insertBuilder - insert builder which returns id of created row (RETURNING pk) if dialect supports it.
+func (r *repository) insertBuilder(cols []db.Column, vals []db.Argument, returning bool) squirrel.InsertBuilder {
+	ib := squirrel.
+		Insert(r.table()).
+		Columns(r.quoteColumns(cols)...).
+		Values(vals...).
+		PlaceholderFormat(r.phf)
+
+	if returning && r.dialect.SupportsReturning() {
+		ib = ib.Suffix("RETURNING " + r.dialect.QuoteIdent(r.pk[0]))
+	}
+
+	return ib
+}
+
+// isIDKnown - id of created row is known before insert (generated on client uuid or composite pk).
+func (r *repository) isIDKnown(keys map[db.Column]db.Argument) bool {
+	_, found := keys[r.pk[0]]
+
+	return found || len(r.pk) > 1
+}
+
+func (r *repository) create(
+	ctx context.Context, query db.Query, id any, keys map[db.Column]db.Argument, args ...any,
+) error {
+	if r.isIDKnown(keys) {
+		return r.withTx(ctx, func(tx *sqlx.Tx) error {
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return err
+			}
+
+			if len(r.pk) > 1 {
+				return nil
+			}
+
+			return setID(id, keys[r.pk[0]])
+		})
+	}
+
+	if r.dialect.SupportsReturning() {
+		return r.withTx(ctx, helper.InsertAndGetLastID(ctx, id, query, args...))
 	}
 
-	var lastInsertID = int64(0)
+	var lastInsertID int64
+	if err := r.withTx(ctx, helper.ExecAndGetLastInsertID(ctx, &lastInsertID, query, args...)); err != nil {
+		return err
+	}
 
-	return lastInsertID, r.create(ctx, query, &lastInsertID, args...)
+	return setID(id, lastInsertID)
 }
 
-func (r *repository) create(ctx context.Context, query db.Query, lastInsertID any, args ...any) error {
-	return transaction.WithTransaction(ctx, nil, r.dbConn, helper.InsertAndGetLastID(ctx, lastInsertID, query, args...))
+// withGeneratedKeys - add generated on client pk values to create columns (or replace zero values of them).
+func withGeneratedKeys(
+	cols []db.Column, vals []db.Argument, keys map[db.Column]db.Argument,
+) ([]db.Column, []db.Argument) {
+	for _, pk := range sortedColumns(keys) {
+		if i := indexOf(cols, pk); i >= 0 {
+			vals[i] = keys[pk]
+
+			continue
+		}
+
+		cols, vals = append(cols, pk), append(vals, keys[pk])
+	}
+
+	return cols, vals
+}
+
+// setID - set id (int64 from sql.Result.LastInsertId(), generated uuid) to pointer of compatible type.
+func setID(dest any, id any) error {
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Pointer || d.IsNil() || id == nil {
+		return db.ErrUnsupportedID
+	}
+
+	d, v := d.Elem(), reflect.ValueOf(id)
+
+	switch {
+	case v.Type().AssignableTo(d.Type()):
+		d.Set(v)
+	case d.Kind() == reflect.String:
+		d.SetString(fmt.Sprint(id)) // integers and Stringer (uuid.UUID)
+	case v.CanInt() && d.CanInt():
+		d.SetInt(v.Int())
+	case v.CanInt() && d.CanUint():
+		d.SetUint(uint64(v.Int()))
+	case v.Kind() == reflect.Array && v.Type().ConvertibleTo(d.Type()):
+		d.Set(v.Convert(d.Type()))
+	default:
+		return db.ErrUnsupportedID
+	}
+
+	return nil
 }
 
 func (r *repository) Get(ctx context.Context, id db.ID, dest any) error {
-	r.logger.Info("[repo.Get]", r.loggerFieldRepo(), loggerFieldID(id))
+	cond, err := r.pkCondition(id)
+	if err != nil {
+		return fmt.Errorf("[repo.Get] %w", err)
+	}
+
+	if err = r.scopePK(ctx, "Get", cond); err != nil {
+		return err
+	}
 
 	query, args, err := squirrel.
 		Select("*").
-		From(r.name).
-		Where(squirrel.Eq{"id": id}).
+		From(r.table()).
+		Where(r.quoteEq(cond)).
 		PlaceholderFormat(r.phf).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("[repo.Get] squirrel: %w", err)
 	}
 
-	return sqlx.GetContext(ctx, r.dbConn, dest, query, args...)
+	return r.getContext(ctx, "Get", query, args, dest)
 }
 
 func (r *repository) Update(ctx context.Context, id db.ID, obj any) (int64, error) {
-	r.logger.Info("[repo.Update]", r.loggerFieldRepo(), loggerFieldID(id), loggerFieldObj(obj))
+	data := lifecycle.Addressable(obj)
+
+	if _, ok := data.(db.BeforeUpdateHook); !ok {
+		return r.update(ctx, id, obj, data)
+	}
+
+	var n int64 = RowsAffectedUnknown
+
+	err := r.inTx(ctx, func(ctx context.Context) (err error) {
+		if err = beforeUpdate(ctx, data); err != nil {
+			return err
+		}
+
+		n, err = r.update(ctx, id, obj, data)
+
+		return err
+	})
+
+	return n, err
+}
+
+// update - update by columns of data (addressable obj), data is validated after BeforeUpdate hook.
+func (r *repository) update(ctx context.Context, id db.ID, obj any, data any) (int64, error) {
+	if err := validateDTO(data); err != nil {
+		return RowsAffectedUnknown, fmt.Errorf("[repo.Update] %w", err)
+	}
 
-	sm := orm.GetDataForUpdate(obj)
+	cond, err := r.pkCondition(id)
+	if err != nil {
+		return RowsAffectedUnknown, fmt.Errorf("[repo.Update] %w", err)
+	}
+
+	if err = r.scopePK(ctx, "Update", cond); err != nil {
+		return RowsAffectedUnknown, err
+	}
+
+	sm := r.scopeSet(orm.GetDataForUpdate(data))
 
 	query, args, err := squirrel.
-		Update(r.name).
-		SetMap(sm).
-		Where(squirrel.Eq{"id": id}).
+		Update(r.table()).
+		SetMap(r.quoteEq(sm)).
+		Where(r.quoteEq(cond)).
 		PlaceholderFormat(r.phf).
 		ToSql()
 	if err != nil {
 		return RowsAffectedUnknown, fmt.Errorf("[repo.Update] squirrel: %w", err)
 	}
 
-	res, err := r.dbConn.ExecContext(ctx, query, args...)
+	return r.exec(ctx, "Update", obj, query, args)
+}
+
+// UpdateFields - partial update of row by id: set is map[string]any of columns (e.g. result of orm.Diff)
+// or DTO with list of fields (go names or db columns with orm_use_in:"update" tag) which must be updated.
+// Nil values are set to NULL. Nothing to update -> no query, 0 rows affected.
+func (r *repository) UpdateFields(ctx context.Context, id db.ID, set any, fields ...db.Column) (int64, error) {
+	sm, obj, err := updateFieldsSetMap(set, fields)
 	if err != nil {
-		return RowsAffectedUnknown, fmt.Errorf("[repo.Update] dbConn.ExecContext: %w", err)
+		return RowsAffectedUnknown, fmt.Errorf("[repo.UpdateFields] %w", err)
 	}
 
-	ra, err := res.RowsAffected()
-	if err != nil {
-		return RowsAffectedUnknown, fmt.Errorf("[repo.Update] res.RowsAffected: %w", err)
+	if sm = r.scopeSet(sm); len(sm) == 0 {
+		return 0, nil
 	}
 
-	return ra, nil
-}
+	cond, err := r.pkCondition(id)
+	if err != nil {
+		return RowsAffectedUnknown, fmt.Errorf("[repo.UpdateFields] %w", err)
+	}
 
-func (r *repository) Delete(ctx context.Context, id db.ID) (int64, error) {
-	r.logger.Info("[repo.Delete]", r.loggerFieldRepo(), loggerFieldID(id))
+	if err = r.scopePK(ctx, "UpdateFields", cond); err != nil {
+		return RowsAffectedUnknown, err
+	}
 
 	query, args, err := squirrel.
-		Delete(r.name).
-		Where(squirrel.Eq{"id": id}).
+		Update(r.table()).
+		SetMap(r.quoteEq(sm)).
+		Where(r.quoteEq(cond)).
 		PlaceholderFormat(r.phf).
 		ToSql()
 	if err != nil {
-		return RowsAffectedUnknown, fmt.Errorf("[repo.Delete] squirrel: %w", err)
+		return RowsAffectedUnknown, fmt.Errorf("[repo.UpdateFields] squirrel: %w", err)
 	}
 
-	res, err := r.dbConn.ExecContext(ctx, query, args...)
+	return r.exec(ctx, "UpdateFields", obj, query, args)
+}
+
+func (r *repository) Delete(ctx context.Context, id db.ID) (int64, error) {
+	cond, err := r.pkCondition(id)
 	if err != nil {
-		return RowsAffectedUnknown, fmt.Errorf("[repo.Delete] dbConn.ExecContext: %w", err)
+		return RowsAffectedUnknown, fmt.Errorf("[repo.Delete] %w", err)
+	}
+
+	if err = r.scopePK(ctx, "Delete", cond); err != nil {
+		return RowsAffectedUnknown, err
 	}
 
-	ra, err := res.RowsAffected()
+	query, args, err := squirrel.
+		Delete(r.table()).
+		Where(r.quoteEq(cond)).
+		PlaceholderFormat(r.phf).
+		ToSql()
 	if err != nil {
-		return RowsAffectedUnknown, fmt.Errorf("[repo.Delete] res.RowsAffected: %w", err)
+		return RowsAffectedUnknown, fmt.Errorf("[repo.Delete] squirrel: %w", err)
 	}
 
-	return ra, nil
+	return r.exec(ctx, "Delete", nil, query, args)
 }
 
 func (r *repository) Insert(ctx context.Context, columns []string, values []any) (int64, error) {
-	r.logger.Info("[repo.Insert]", r.loggerFieldRepo(), zap.Any("columns", columns), zap.Any("values", values))
+	columns, values, err := r.scopeInsert(ctx, "Insert", columns, values)
+	if err != nil {
+		return 0, err
+	}
 
 	query, args, err := squirrel.
-		Insert(r.name).
+		Insert(r.table()).
 		Columns(columns...).
 		Values(values...).
 		PlaceholderFormat(r.phf).
@@ -190,21 +429,49 @@ func (r *repository) Insert(ctx context.Context, columns []string, values []any)
 		return 0, fmt.Errorf("[repo.Insert] squirrel: %w", err)
 	}
 
-	res, err := r.dbConn.ExecContext(ctx, query, args...)
+	return r.exec(ctx, "Insert", nil, query, args)
+}
+
+func (r *repository) Upsert(
+	ctx context.Context, columns []db.Column, values []db.Argument, conflict []db.Column, update []db.Column,
+) (int64, error) {
+	columns, values, err := r.scopeInsert(ctx, "Upsert", columns, values)
+	if err != nil {
+		return RowsAffectedUnknown, err
+	}
+
+	update = r.scopeUpdate(update)
+
+	ib, err := r.scopeUpsert(r.dialect.Upsert(
+		squirrel.
+			Insert(r.table()).
+			Columns(columns...).
+			Values(values...).
+			PlaceholderFormat(r.phf),
+		conflict,
+		update,
+	), update)
 	if err != nil {
-		return RowsAffectedUnknown, fmt.Errorf("[repo.Insert] dbConn.ExecContext: %w", err)
+		return RowsAffectedUnknown, fmt.Errorf("[repo.Upsert] %w", err)
 	}
 
-	return res.RowsAffected()
+	query, args, err := ib.ToSql()
+	if err != nil {
+		return RowsAffectedUnknown, fmt.Errorf("[repo.Upsert] squirrel: %w", err)
+	}
+
+	return r.exec(ctx, "Upsert", nil, query, args)
 }
 
 func (r *repository) UpdateCustom(ctx context.Context, set map[string]any, cond db.Condition) (int64, error) {
-	r.logger.Info("[repo.UpdateCustom]", r.loggerFieldRepo(),
-		zap.Any("set_map", set), zap.Any("condition", cond))
+	cond, err := r.scope(ctx, "UpdateCustom", cond, "")
+	if err != nil {
+		return RowsAffectedUnknown, err
+	}
 
 	query, args, err := squirrel.
-		Update(r.name).
-		SetMap(set).
+		Update(r.table()).
+		SetMap(r.scopeSet(set)).
 		Where(cond).
 		PlaceholderFormat(r.phf).
 		ToSql()
@@ -212,26 +479,18 @@ func (r *repository) UpdateCustom(ctx context.Context, set map[string]any, cond
 		return RowsAffectedUnknown, fmt.Errorf("[repo.UpdateCustom] squirrel: %w", err)
 	}
 
-	res, err := r.dbConn.ExecContext(ctx, query, args...)
-	if err != nil {
-		return RowsAffectedUnknown, fmt.Errorf("[repo.ExecContext] squirrel: %w", err)
-	}
-
-	ra, err := res.RowsAffected()
-	if err != nil {
-		return RowsAffectedUnknown, fmt.Errorf("[repo.ExecContext] RowsAffected: %w", err)
-	}
-
-	return ra, nil
+	return r.exec(ctx, "UpdateCustom", nil, query, args)
 }
 
 func (r *repository) FindBy(ctx context.Context, columns []string, condition db.Condition, target any) error {
-	r.logger.Info("[repo.FindBy]", r.loggerFieldRepo(),
-		zap.Any("columns", columns), zap.Any("condition", condition))
+	condition, err := r.scope(ctx, "FindBy", condition, "")
+	if err != nil {
+		return err
+	}
 
 	query, args, err := squirrel.
 		Select(columns...).
-		From(r.name).
+		From(r.table()).
 		Where(condition).
 		PlaceholderFormat(r.phf).
 		ToSql()
@@ -239,16 +498,18 @@ func (r *repository) FindBy(ctx context.Context, columns []string, condition db.
 		return fmt.Errorf("[repo.FindBy] squirrel: %w", err)
 	}
 
-	return sqlx.SelectContext(ctx, r.dbConn, target, query, args...)
+	return r.selectContext(ctx, "FindBy", query, args, target)
 }
 
 func (r *repository) FindOneBy(ctx context.Context, columns []string, condition db.Condition, target any) error {
-	r.logger.Info("[repo.FindOneBy]", r.loggerFieldRepo(),
-		zap.Any("columns", columns), zap.Any("condition", condition))
+	condition, err := r.scope(ctx, "FindOneBy", condition, "")
+	if err != nil {
+		return err
+	}
 
 	query, args, err := squirrel.
 		Select(columns...).
-		From(r.name).
+		From(r.table()).
 		Where(condition).
 		PlaceholderFormat(r.phf).
 		ToSql()
@@ -256,7 +517,7 @@ func (r *repository) FindOneBy(ctx context.Context, columns []string, condition
 		return fmt.Errorf("[repo.FindOneBy] squirrel: %w", err)
 	}
 
-	return sqlx.GetContext(ctx, r.dbConn, target, query, args...)
+	return r.getContext(ctx, "FindOneBy", query, args, target)
 }
 
 func (r *repository) FindByWithInnerJoin(
@@ -267,10 +528,10 @@ func (r *repository) FindByWithInnerJoin(
 	condition db.Condition,
 	target any,
 ) error {
-	r.logger.Info("[repo.FindByWithInnerJoin]", r.loggerFieldRepo(),
-		zap.Any("columns", columns),
-		zap.Any("join", join),
-		zap.Any("condition", condition))
+	condition, err := r.scope(ctx, "FindByWithInnerJoin", condition, fromQualifier(fromWithAlias))
+	if err != nil {
+		return err
+	}
 
 	query, args, err := squirrel.
 		Select(columns...).
@@ -283,7 +544,7 @@ func (r *repository) FindByWithInnerJoin(
 		return fmt.Errorf("[repo.FindByWithInnerJoin] squirrel: %w", err)
 	}
 
-	return sqlx.SelectContext(ctx, r.dbConn, target, query, args...)
+	return r.selectContext(ctx, "FindByWithInnerJoin", query, args, target)
 }
 
 func (r *repository) FindOneByWithInnerJoin(
@@ -294,10 +555,10 @@ func (r *repository) FindOneByWithInnerJoin(
 	condition db.Condition,
 	target any,
 ) error {
-	r.logger.Info("[repo.FindOneByWithInnerJoin]", r.loggerFieldRepo(),
-		zap.Any("columns", columns),
-		zap.Any("join", join),
-		zap.Any("condition", condition))
+	condition, err := r.scope(ctx, "FindOneByWithInnerJoin", condition, fromQualifier(fromWithAlias))
+	if err != nil {
+		return err
+	}
 
 	query, args, err := squirrel.
 		Select(columns...).
@@ -310,28 +571,42 @@ func (r *repository) FindOneByWithInnerJoin(
 		return fmt.Errorf("[repo.FindOneByWithInnerJoin] squirrel: %w", err)
 	}
 
-	return sqlx.GetContext(ctx, r.dbConn, target, query, args...)
+	return r.getContext(ctx, "FindOneByWithInnerJoin", query, args, target)
 }
 
 func (r *repository) GetRowsByQuery(ctx context.Context, qb squirrel.SelectBuilder) (*sql.Rows, error) {
-	r.logger.Info("[repo.GetRowsByQuery]", r.loggerFieldRepo(), zap.Any("qb", qb))
+	qb, err := r.scopeSelect(ctx, "GetRowsByQuery", qb)
+	if err != nil {
+		return nil, err
+	}
 
 	query, args, err := qb.
-		From(r.name).
+		From(r.table()).
 		PlaceholderFormat(r.phf).
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("[repo.GetRowsByQuery] squirrel: %w", err)
 	}
 
-	return r.dbConn.QueryContext(ctx, query, args...)
+	var rows *sql.Rows
+
+	err = r.run(ctx, "GetRowsByQuery", nil, query, args, func(ctx context.Context) (int64, error) {
+		rows, err = r.conn(ctx).QueryContext(ctx, query, args...)
+
+		return db.RowsUnknown, err
+	})
+
+	return rows, err
 }
 
 func (r *repository) CountByQuery(ctx context.Context, qb squirrel.SelectBuilder) (uint64, error) {
-	r.logger.Info("[repo.CountByQuery]", r.loggerFieldRepo(), zap.Any("qb", qb))
+	qb, err := r.scopeSelect(ctx, "CountByQuery", qb)
+	if err != nil {
+		return 0, err
+	}
 
 	query, args, err := qb.
-		From(r.name).
+		From(r.table()).
 		PlaceholderFormat(r.phf).
 		ToSql()
 	if err != nil {
@@ -340,7 +615,9 @@ func (r *repository) CountByQuery(ctx context.Context, qb squirrel.SelectBuilder
 
 	counter := uint64(0)
 
-	err = r.dbConn.QueryRowxContext(ctx, query, args...).Scan(&counter)
+	err = r.run(ctx, "CountByQuery", nil, query, args, func(ctx context.Context) (int64, error) {
+		return 1, r.conn(ctx).QueryRowxContext(ctx, query, args...).Scan(&counter)
+	})
 	if err != nil {
 		return counter, fmt.Errorf("[repo.CountByQuery] dbConn.QueryRowxContext: %w", err)
 	}
@@ -349,17 +626,20 @@ func (r *repository) CountByQuery(ctx context.Context, qb squirrel.SelectBuilder
 }
 
 func (r *repository) Select(ctx context.Context, sb db.SelectBuilder, target any) error {
-	r.logger.Info("[repo.Select]", r.loggerFieldRepo(), zap.Any("sb", sb))
+	sb, err := r.scopeSelect(ctx, "Select", sb)
+	if err != nil {
+		return err
+	}
 
 	query, args, err := sb.
-		From(r.name).
+		From(r.table()).
 		PlaceholderFormat(r.phf).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("[repo.Select] squirrel: %w", err)
 	}
 
-	return sqlx.SelectContext(ctx, r.dbConn, target, query, args...)
+	return r.selectContext(ctx, "Select", query, args, target)
 }
 
 func (r *repository) SelectWithPagePagination(
@@ -371,8 +651,6 @@ func (r *repository) SelectWithPagePagination(
 	db.PagePaginationResults,
 	error,
 ) {
-	r.logger.Info("[repo.SelectWithPagePagination]", r.loggerFieldRepo(), zap.Any("params", params))
-
 	const pageNumberPresent = 1
 
 	paginationResult := db.PagePaginationResults{
@@ -394,7 +672,11 @@ func (r *repository) SelectWithPagePagination(
 		paginationResult.CntPages++
 	}
 
-	selectBuilder = selectBuilder.From(r.name).Limit(params.PageSize)
+	if selectBuilder, err = r.scopeSelect(ctx, "SelectWithPagePagination", selectBuilder); err != nil {
+		return paginationResult, err
+	}
+
+	selectBuilder = selectBuilder.From(r.table()).Limit(params.PageSize)
 	if params.PageNumber > pageNumberPresent {
 		selectBuilder = selectBuilder.Offset((params.PageNumber - 1) * params.PageSize)
 	}
@@ -404,7 +686,7 @@ func (r *repository) SelectWithPagePagination(
 		return paginationResult, fmt.Errorf("SelectWithPagePagination: selectBuilder.ToSql(): %w", err)
 	}
 
-	if err = sqlx.SelectContext(ctx, r.dbConn, target, query, args...); err != nil {
+	if err = r.selectContext(ctx, "SelectWithPagePagination", query, args, target); err != nil {
 		return paginationResult, fmt.Errorf("SelectWithPagePagination: sqlx.SelectContext(): %w", err)
 	}
 
@@ -423,24 +705,37 @@ func (r *repository) SelectWithCursorOnPKPagination(
 	params db.CursorPaginationParams,
 	target any,
 ) error {
-	r.logger.Info("[repo.SelectWithCursorOnPKPagination]", r.loggerFieldRepo(), zap.Any("params", params))
-
 	if params.Limit == 0 {
 		return db.ErrZeroLimitSize
 	}
 
+	if len(r.pk) > 1 {
+		return fmt.Errorf("SelectWithCursorOnPKPagination: %w", db.ErrCompositePK)
+	}
+
+	selectBuilder, err := r.scopeSelect(ctx, "SelectWithCursorOnPKPagination", selectBuilder)
+	if err != nil {
+		return err
+	}
+
+	var cursor any = params.Cursor
+	if params.CursorValue != nil {
+		cursor = params.CursorValue
+	}
+
 	var (
-		wh      squirrel.Sqlizer = squirrel.Gt{"id": params.Cursor}
-		orderBy                  = "id ASC"
+		pk                       = r.dialect.QuoteIdent(r.pk[0])
+		wh      squirrel.Sqlizer = squirrel.Gt{pk: cursor}
+		orderBy                  = pk + " ASC"
 	)
 
 	if params.DescOrder {
-		wh = squirrel.Lt{"id": params.Cursor}
-		orderBy = "id DESC"
+		wh = squirrel.Lt{pk: cursor}
+		orderBy = pk + " DESC"
 	}
 
 	query, args, err := selectBuilder.
-		From(r.name).
+		From(r.table()).
 		Where(wh).
 		OrderBy(orderBy).
 		Limit(params.Limit).
@@ -449,9 +744,121 @@ func (r *repository) SelectWithCursorOnPKPagination(
 		return fmt.Errorf("SelectWithCursorOnPKPagination: selectBuilder.ToSql(): %w", err)
 	}
 
-	if err = sqlx.SelectContext(ctx, r.dbConn, target, query, args...); err != nil {
+	if err = r.selectContext(ctx, "SelectWithCursorOnPKPagination", query, args, target); err != nil {
 		return fmt.Errorf("SelectWithCursorOnPKPagination: sqlx.SelectContext(): %w", err)
 	}
 
 	return nil
 }
+
+// table - name of table of repository quoted by dialect.
+func (r *repository) table() string {
+	return r.dialect.QuoteIdent(r.name)
+}
+
+// quoteColumns - columns generated from DTO quoted by dialect.
+func (r *repository) quoteColumns(cols []db.Column) []db.Column {
+	quoted := make([]db.Column, 0, len(cols))
+	for _, c := range cols {
+		quoted = append(quoted, r.dialect.QuoteIdent(c))
+	}
+
+	return quoted
+}
+
+// quoteEq - condition (set map) by columns generated from DTO (pk, tenant, update data) with quoted columns.
+func (r *repository) quoteEq(eq map[string]any) squirrel.Eq {
+	quoted := make(squirrel.Eq, len(eq))
+	for c, v := range eq {
+		quoted[r.dialect.QuoteIdent(c)] = v
+	}
+
+	return quoted
+}
+
+// pkCondition - condition by primary key of repository (see PKCondition).
+func (r *repository) pkCondition(id db.ID) (squirrel.Eq, error) {
+	return PKCondition(r.pk, id)
+}
+
+// IsIntegerPK - pk is one column of integer field of DTO (or field of pk is unknown), so id of created row is int64.
+func IsIntegerPK(pk []db.Column, obj any) bool {
+	if len(pk) != 1 {
+		return false
+	}
+
+	v, found := orm.GetColumnValue(reflect.ValueOf(obj), pk[0])
+
+	return !found || v.CanInt() || v.CanUint()
+}
+
+// PKCondition - condition by primary key columns, for composite pk id is []any (in order of pk columns)
+// or map[string]any (squirrel.Eq).
+func PKCondition(pk []db.Column, id db.ID) (squirrel.Eq, error) {
+	if len(pk) == 1 {
+		return squirrel.Eq{pk[0]: id}, nil
+	}
+
+	cond := make(squirrel.Eq, len(pk))
+
+	switch v := id.(type) {
+	case []any:
+		if len(v) != len(pk) {
+			return nil, db.ErrInvalidPK
+		}
+
+		for i, c := range pk {
+			cond[c] = v[i]
+		}
+	case map[string]any:
+		for _, c := range pk {
+			val, found := v[c]
+			if !found {
+				return nil, db.ErrInvalidPK
+			}
+
+			cond[c] = val
+		}
+	case squirrel.Eq:
+		return PKCondition(pk, map[string]any(v))
+	default:
+		return nil, db.ErrInvalidPK
+	}
+
+	return cond, nil
+}
+
+func sortedColumns(m map[db.Column]db.Argument) []db.Column {
+	cols := make([]db.Column, 0, len(m))
+	for c := range m {
+		cols = append(cols, c)
+	}
+
+	sort.Strings(cols)
+
+	return cols
+}
+
+func indexOf(cols []db.Column, col db.Column) int {
+	for i, c := range cols {
+		if c == col {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// updateFieldsSetMap - SetMap and DTO (nil for map, values of map are not masked in logs) of UpdateFields.
+func updateFieldsSetMap(set any, fields []db.Column) (map[string]any, any, error) {
+	switch v := set.(type) {
+	case map[string]any:
+		return v, nil, nil
+	case squirrel.Eq:
+		return v, nil, nil
+	}
+
+	sm, err := orm.GetDataForUpdateFields(set, fields...)
+
+	return sm, set, err
+}
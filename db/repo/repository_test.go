@@ -4,16 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"math"
 	"testing"
 
 	"go.uber.org/zap"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/dialect"
 	"github.com/imperiuse/golib/db/example/simple/dto"
 	"github.com/imperiuse/golib/db/mocks"
+	"github.com/imperiuse/golib/db/tenant"
 	"github.com/imperiuse/golib/reflect/orm"
 )
 
@@ -161,6 +165,7 @@ func Test_ConvertFunc(t *testing.T) {
 		Input  interface{}
 		OutInt int64
 		OutStr string
+		Err    bool
 	}{
 		{
 			Input:  0,
@@ -172,25 +177,139 @@ func Test_ConvertFunc(t *testing.T) {
 			OutInt: 123,
 			OutStr: "123",
 		},
+		{
+			Input:  uint8(7),
+			OutInt: 7,
+			OutStr: "7",
+		},
 		{
 			Input:  "123",
 			OutInt: 123,
 			OutStr: "123",
 		},
+		{
+			Input:  "abc",
+			OutInt: 0,
+			OutStr: "abc",
+			Err:    true,
+		},
 		{
 			Input:  1.23,
 			OutInt: 0,
 			OutStr: "1.23",
+			Err:    true,
+		},
+		{
+			Input:  uint64(math.MaxUint64),
+			OutInt: 0,
+			OutStr: "18446744073709551615",
+			Err:    true,
 		},
 		{
 			Input:  nil,
 			OutInt: 0,
 			OutStr: "0",
+			Err:    true,
 		},
 	}
 
 	for _, v := range tests {
-		assert.Equal(t, v.OutInt, ConvertIDToInt64(v.Input))
+		n, err := ConvertIDToInt64(v.Input)
+		assert.Equal(t, v.OutInt, n)
+		assert.Equal(t, v.Err, err != nil, v.Input)
 		assert.Equal(t, v.OutStr, ConvertIDToString(v.Input))
 	}
 }
+
+func Test_PrimaryKey(t *testing.T) {
+	r := New(zap.NewNop(), mocks.GoodMockDBConn, "Members", squirrel.Dollar)
+	assert.Equal(t, []db.Column{"id"}, r.pk)
+
+	cond, err := r.pkCondition(1)
+	assert.Nil(t, err)
+	assert.Equal(t, squirrel.Eq{"id": 1}, cond)
+
+	r = r.WithPrimaryKey("group_id", "user_id")
+
+	cond, err = r.pkCondition([]any{1, 2})
+	assert.Nil(t, err)
+	assert.Equal(t, squirrel.Eq{"group_id": 1, "user_id": 2}, cond)
+
+	cond, err = r.pkCondition(map[string]any{"group_id": 1, "user_id": 2, "other": 3})
+	assert.Nil(t, err)
+	assert.Equal(t, squirrel.Eq{"group_id": 1, "user_id": 2}, cond)
+
+	cond, err = r.pkCondition(squirrel.Eq{"group_id": 1, "user_id": 2})
+	assert.Nil(t, err)
+	assert.Equal(t, squirrel.Eq{"group_id": 1, "user_id": 2}, cond)
+
+	for _, id := range []any{1, []any{1}, map[string]any{"group_id": 1}} {
+		_, err = r.pkCondition(id)
+		assert.ErrorIs(t, err, db.ErrInvalidPK)
+	}
+
+	err = r.Get(context.Background(), 1, &struct{}{})
+	assert.ErrorIs(t, err, db.ErrInvalidPK)
+
+	err = r.SelectWithCursorOnPKPagination(context.Background(), squirrel.Select("*"),
+		db.CursorPaginationParams{Limit: 1}, &[]struct{}{})
+	assert.ErrorIs(t, err, db.ErrCompositePK)
+}
+
+func Test_SetID(t *testing.T) {
+	u := uuid.New()
+
+	var (
+		i   int
+		u64 uint64
+		s   string
+		a   any
+		uid uuid.UUID
+	)
+
+	assert.Nil(t, setID(&i, int64(7)))
+	assert.Equal(t, 7, i)
+	assert.Nil(t, setID(&u64, int64(7)))
+	assert.Equal(t, uint64(7), u64)
+	assert.Nil(t, setID(&s, int64(7)))
+	assert.Equal(t, "7", s)
+	assert.Nil(t, setID(&a, int64(7)))
+	assert.Equal(t, int64(7), a)
+
+	assert.Nil(t, setID(&uid, u))
+	assert.Equal(t, u, uid)
+	assert.Nil(t, setID(&s, u))
+	assert.Equal(t, u.String(), s)
+
+	assert.ErrorIs(t, setID(&i, u), db.ErrUnsupportedID)
+	assert.ErrorIs(t, setID(i, int64(7)), db.ErrUnsupportedID)
+	assert.ErrorIs(t, setID(&i, nil), db.ErrUnsupportedID)
+}
+
+func Test_WithGeneratedKeys(t *testing.T) {
+	cols, vals := withGeneratedKeys([]db.Column{"uid", "name"}, []db.Argument{"", "n"},
+		map[db.Column]db.Argument{"uid": "u1", "code": "c1"})
+	assert.Equal(t, []db.Column{"uid", "name", "code"}, cols)
+	assert.Equal(t, []db.Argument{"u1", "n", "c1"}, vals)
+}
+
+func Test_UpsertTenant(t *testing.T) {
+	ctx := tenant.NewContext(context.Background(), 1)
+
+	r := NewWithDialect(zap.NewNop(), mocks.GoodMockDBConn, "notes", nil, dialect.Postgres).WithTenantColumn("tenant_id")
+	assert.Equal(t, []db.Column{"text"}, r.scopeUpdate([]db.Column{"tenant_id", "text"}))
+
+	ib, err := r.scopeUpsert(squirrel.Insert("notes").Columns("id").Values(1).
+		Suffix("ON CONFLICT (id) DO UPDATE SET text = EXCLUDED.text"), []db.Column{"text"})
+	assert.Nil(t, err)
+
+	scoped, _, err := ib.ToSql()
+	assert.Nil(t, err)
+	assert.Equal(t, `INSERT INTO notes (id) VALUES (?) ON CONFLICT (id) DO UPDATE SET text = EXCLUDED.text `+
+		`WHERE "notes"."tenant_id" = EXCLUDED."tenant_id"`, scoped)
+
+	r = NewWithDialect(zap.NewNop(), mocks.GoodMockDBConn, "notes", nil, dialect.MySQL).WithTenantColumn("tenant_id")
+
+	_, err = r.Upsert(ctx, []db.Column{"id", "text"}, []db.Argument{1, "a"}, nil, []db.Column{"text"})
+	assert.ErrorIs(t, err, db.ErrTenantUpsert)
+}
@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+// sqlx can scan columns only to sql.Scanner (or builtin) types and knows only db tags, so DTO with orm_json columns
+// or columns derived by orm naming strategy are scanned by orm.GetScanTargetsByColumns (see orm.NeedsScanTargets),
+// other DTO are scanned by sqlx as usual.
+
+// structScan - rows.StructScan with support of orm_json and derived columns.
+func structScan(rows *sqlx.Rows, dest any) error {
+	if !orm.NeedsScanTargets(dest) {
+		return rows.StructScan(dest)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	targets, err := orm.GetScanTargetsByColumns(dest, columns)
+	if err != nil {
+		return err
+	}
+
+	return rows.Scan(targets...)
+}
+
+// selectJSON - the same as sqlx.SelectContext for slice of DTO with orm_json (or derived) columns (target is pointer to []D or []*D).
+func selectJSON(ctx context.Context, q sqlx.QueryerContext, target any, query db.Query, args ...any) (err error) {
+	slice := reflect.ValueOf(target)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("pointer to slice expected, got %T", target)
+	}
+
+	slice = slice.Elem()
+	elemType, isPtr := slice.Type().Elem(), false
+
+	if elemType.Kind() == reflect.Pointer {
+		elemType, isPtr = elemType.Elem(), true
+	}
+
+	rows, err := q.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if errC := rows.Close(); errC != nil && err == nil {
+			err = errC
+		}
+	}()
+
+	for rows.Next() {
+		p := reflect.New(elemType)
+		if err = structScan(rows, p.Interface()); err != nil {
+			return err
+		}
+
+		if isPtr {
+			slice.Set(reflect.Append(slice, p))
+		} else {
+			slice.Set(reflect.Append(slice, p.Elem()))
+		}
+	}
+
+	return rows.Err()
+}
+
+// getJSON - the same as sqlx.GetContext for DTO with orm_json (or derived) columns, sql.ErrNoRows if there are not any rows.
+func getJSON(ctx context.Context, q sqlx.QueryerContext, target any, query db.Query, args ...any) (err error) {
+	rows, err := q.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if errC := rows.Close(); errC != nil && err == nil {
+			err = errC
+		}
+	}()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		return sql.ErrNoRows
+	}
+
+	return structScan(rows, target)
+}
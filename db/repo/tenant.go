@@ -0,0 +1,184 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+
+	"github.com/imperiuse/golib/db"
+	"github.com/imperiuse/golib/db/dialect"
+	"github.com/imperiuse/golib/db/tenant"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+// WithTenantColumn - scope all queries of repository by tenant of context (see package db/tenant), column is
+// orm_tenant column of DTO (see orm.GetTenantColumn), empty column - repository is not scoped.
+func (r *repository) WithTenantColumn(column db.Column) *repository {
+	r.tenant = column
+
+	return r
+}
+
+// tenantCond - condition by tenant of ctx, nil if column is empty (not scoped), db.ErrNoTenant if ctx has not tenant.
+func tenantCond(ctx context.Context, column db.Column) (squirrel.Eq, error) {
+	if column == "" {
+		return nil, nil
+	}
+
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, db.ErrNoTenant
+	}
+
+	return squirrel.Eq{column: id}, nil
+}
+
+// scope - cond AND tenant condition, tenant column is qualified by table (alias) if qualifier is not empty.
+func (r *repository) scope(ctx context.Context, method string, cond db.Condition, qualifier string) (db.Condition, error) {
+	tc, err := tenantCond(ctx, qualified(qualifier, r.tenant))
+	if err != nil {
+		return nil, fmt.Errorf("[repo.%s] %w", method, err)
+	}
+
+	if tc == nil {
+		return cond, nil
+	}
+
+	if cond == nil {
+		return tc, nil
+	}
+
+	return squirrel.And{cond, tc}, nil
+}
+
+// scopePK - add tenant condition to condition by primary key.
+func (r *repository) scopePK(ctx context.Context, method string, cond squirrel.Eq) error {
+	tc, err := tenantCond(ctx, r.tenant)
+	if err != nil {
+		return fmt.Errorf("[repo.%s] %w", method, err)
+	}
+
+	for c, v := range tc {
+		cond[c] = v
+	}
+
+	return nil
+}
+
+// scopeSelect - select builder with tenant condition.
+func (r *repository) scopeSelect(ctx context.Context, method string, sb db.SelectBuilder) (db.SelectBuilder, error) {
+	tc, err := tenantCond(ctx, r.tenant)
+	if err != nil {
+		return sb, fmt.Errorf("[repo.%s] %w", method, err)
+	}
+
+	if tc == nil {
+		return sb, nil
+	}
+
+	return sb.Where(tc), nil
+}
+
+// scopeInsert - insert columns with tenant column (value of it is replaced by tenant of ctx).
+func (r *repository) scopeInsert(
+	ctx context.Context, method string, cols []db.Column, vals []db.Argument,
+) ([]db.Column, []db.Argument, error) {
+	tc, err := tenantCond(ctx, r.tenant)
+	if err != nil {
+		return nil, nil, fmt.Errorf("[repo.%s] %w", method, err)
+	}
+
+	if tc == nil {
+		return cols, vals, nil
+	}
+
+	cols, vals = append([]db.Column{}, cols...), append([]db.Argument{}, vals...) // slices of client are not changed
+	if i := indexOf(cols, r.tenant); i >= 0 && i < len(vals) {
+		vals[i] = tc[r.tenant]
+
+		return cols, vals, nil
+	}
+
+	return append(cols, r.tenant), append(vals, tc[r.tenant]), nil
+}
+
+// scopeSet - set map of update without tenant column, so row can't be moved to other tenant.
+func (r *repository) scopeSet(set map[string]any) map[string]any {
+	if _, found := set[r.tenant]; !found || r.tenant == "" {
+		return set
+	}
+
+	sm := make(map[string]any, len(set))
+	for c, v := range set {
+		if c != r.tenant {
+			sm[c] = v
+		}
+	}
+
+	return sm
+}
+
+// scopeUpdate - update columns of upsert without tenant column, so row can't be moved to other tenant.
+func (r *repository) scopeUpdate(update []db.Column) []db.Column {
+	if r.tenant == "" || indexOf(update, r.tenant) < 0 {
+		return update
+	}
+
+	cols := make([]db.Column, 0, len(update))
+	for _, c := range update {
+		if c != r.tenant {
+			cols = append(cols, c)
+		}
+	}
+
+	return cols
+}
+
+// scopeUpsert - update part of upsert only for row of the same tenant (conflict key can be unique across tenants),
+// MySQL can't filter ON DUPLICATE KEY UPDATE, so it is rejected (db.ErrTenantUpsert).
+func (r *repository) scopeUpsert(ib db.InsertBuilder, update []db.Column) (db.InsertBuilder, error) {
+	if r.tenant == "" || len(update) == 0 {
+		return ib, nil
+	}
+
+	if r.dialect.Name() == dialect.MySQL.Name() {
+		return ib, db.ErrTenantUpsert
+	}
+
+	tc := r.dialect.QuoteIdent(r.tenant)
+
+	return ib.Suffix(fmt.Sprintf("WHERE %s.%s = EXCLUDED.%s", r.table(), tc, tc)), nil
+}
+
+// setTenant - set tenant of ctx to tenant field of created DTO (data is addressable DTO).
+func (r *repository) setTenant(ctx context.Context, data any) {
+	id, ok := tenant.FromContext(ctx)
+	if r.tenant == "" || !ok {
+		return
+	}
+
+	if v, found := orm.GetColumnValue(reflect.ValueOf(data), r.tenant); found && v.CanAddr() {
+		_ = setID(v.Addr().Interface(), id) // the same conversions as for id (e.g. int64 tenant of ctx to int field)
+	}
+}
+
+// qualified - column qualified by table (alias), empty if column is empty.
+func qualified(qualifier string, column db.Column) db.Column {
+	if qualifier == "" || column == "" {
+		return column
+	}
+
+	return qualifier + "." + column
+}
+
+// fromQualifier - alias (or table if alias is absent) of FROM part, e.g. `users AS u` -> u.
+func fromQualifier(from string) string {
+	if f := strings.Fields(from); len(f) > 0 {
+		return f[len(f)-1]
+	}
+
+	return ""
+}
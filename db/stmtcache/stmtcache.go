@@ -0,0 +1,342 @@
+// Package stmtcache - LRU cache of prepared statements over db.PureSqlxConnection.
+//
+// Conn prepares every query of QueryContext, QueryxContext, QueryRowxContext and ExecContext once (key - sql text)
+// and reuses prepared statement, least recently used statements are closed when cache is full (statement used
+// by query at that moment is closed after the query).
+// Queries inside transactions (BeginTxx) are not cached.
+//
+// Cache is invalidated:
+//   - by Invalidate() call or by Reconnect(conn) (connector.Reconnect of connector created by NewWithStmtCache
+//     calls it);
+//   - for one statement, if its query failed because of closed connection (sql.ErrConnDone, driver.ErrBadConn),
+//     query is not retried then (its outcome is unknown), only failed prepare is;
+//   - for one statement on Postgres error `cached plan must not change result type` (schema was changed),
+//     such query is prepared again and retried once.
+package stmtcache
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/imperiuse/golib/db"
+)
+
+// DefaultSize - default max count of prepared statements in cache.
+const DefaultSize = 256
+
+type (
+	// Conn - db.PureSqlxConnection with cache of prepared statements.
+	Conn struct {
+		m     sync.Mutex
+		conn  db.PureSqlxConnection
+		size  int
+		ll    *list.List // front - most recently used
+		items map[db.Query]*list.Element
+		stats Stats
+	}
+
+	// Stats - statistics of cache usage.
+	Stats struct {
+		Hits          uint64
+		Misses        uint64
+		Evictions     uint64 // statements closed because cache was full
+		Invalidations uint64 // statements closed because of invalidation (reconnect, connection or cached plan error)
+		Size          int    // current count of statements in cache
+	}
+
+	entry struct {
+		query   db.Query
+		stmt    *sqlx.Stmt
+		refs    int  // count of queries which use statement now
+		removed bool // removed from cache, statement is closed by the last query which uses it
+	}
+)
+
+// errCachedPlan - message of pq and pgx error (SQLSTATE 0A000 is feature_not_supported, so code is not enough).
+const errCachedPlan = "cached plan must not change result type"
+
+// New - wrap connection with statements cache of size statements (DefaultSize if size <= 0).
+func New(conn db.PureSqlxConnection, size int) *Conn {
+	if size <= 0 {
+		size = DefaultSize
+	}
+
+	return &Conn{
+		conn:  conn,
+		size:  size,
+		ll:    list.New(),
+		items: map[db.Query]*list.Element{},
+	}
+}
+
+// HitRatio - hits / (hits + misses), 0 if cache was not used.
+func (s Stats) HitRatio() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+
+	return 0
+}
+
+// Stats - current statistics of cache.
+func (c *Conn) Stats() Stats {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	s := c.stats
+	s.Size = c.ll.Len()
+
+	return s
+}
+
+// Invalidate - close and remove all cached statements.
+func (c *Conn) Invalidate() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.invalidate()
+}
+
+// Reconnect - close and remove all cached statements and send next queries to new connection (e.g. after reconnect).
+func (c *Conn) Reconnect(conn db.PureSqlxConnection) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.invalidate()
+	c.conn = conn
+}
+
+// Connection - current wrapped connection.
+func (c *Conn) Connection() db.PureSqlxConnection {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.conn
+}
+
+func (c *Conn) invalidate() {
+	for e := c.ll.Front(); e != nil; e = e.Next() {
+		c.release(e.Value.(*entry), true)
+		c.stats.Invalidations++
+	}
+
+	c.ll.Init()
+	c.items = map[db.Query]*list.Element{}
+}
+
+func (c *Conn) QueryContext(ctx context.Context, query db.Query, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+
+	err := c.do(ctx, query, func(stmt *sqlx.Stmt) (err error) {
+		rows, err = stmt.QueryContext(ctx, args...)
+
+		return err
+	})
+
+	return rows, err
+}
+
+func (c *Conn) QueryxContext(ctx context.Context, query db.Query, args ...any) (*sqlx.Rows, error) {
+	var rows *sqlx.Rows
+
+	err := c.do(ctx, query, func(stmt *sqlx.Stmt) (err error) {
+		rows, err = stmt.QueryxContext(ctx, args...)
+
+		return err
+	})
+
+	return rows, err
+}
+
+func (c *Conn) QueryRowxContext(ctx context.Context, query db.Query, args ...any) *sqlx.Row {
+	var row *sqlx.Row
+
+	_ = c.do(ctx, query, func(stmt *sqlx.Stmt) error { // error of query is returned by row.Scan
+		row = stmt.QueryRowxContext(ctx, args...)
+
+		return row.Err()
+	})
+	if row == nil { // prepare failed, query is sent without cache and returns the same error by row.Scan
+		return c.Connection().QueryRowxContext(ctx, query, args...)
+	}
+
+	return row
+}
+
+func (c *Conn) ExecContext(ctx context.Context, query db.Query, args ...any) (sql.Result, error) {
+	var res sql.Result
+
+	err := c.do(ctx, query, func(stmt *sqlx.Stmt) (err error) {
+		res, err = stmt.ExecContext(ctx, args...)
+
+		return err
+	})
+
+	return res, err
+}
+
+// do - call fn with cached (or new prepared) statement, statement is prepared again and query retried once only if
+// it was not executed: prepare failed because of closed connection or statement is stale. After connection error
+// of fn query is not retried, its outcome is unknown (Exec or INSERT ... RETURNING could be already applied).
+func (c *Conn) do(ctx context.Context, query db.Query, fn func(*sqlx.Stmt) error) error {
+	for attempt := 0; ; attempt++ {
+		e, err := c.acquire(ctx, query)
+		if err != nil {
+			if attempt == 0 && isConnClosedError(err) {
+				continue
+			}
+
+			return err
+		}
+
+		err = fn(e.stmt)
+
+		switch {
+		case err == nil:
+			c.done(e)
+
+			return nil
+		case isConnClosedError(err):
+			c.remove(e)
+
+			return err
+		case isStaleStmtError(err):
+			c.remove(e)
+		default:
+			c.done(e)
+
+			return err
+		}
+
+		if attempt > 0 {
+			return err
+		}
+	}
+}
+
+// acquire - cached (or new prepared and added to cache) statement for query, statement is not closed by eviction
+// or invalidation until done (remove) is called for it.
+func (c *Conn) acquire(ctx context.Context, query db.Query) (*entry, error) {
+	c.m.Lock()
+	if el, found := c.items[query]; found {
+		c.ll.MoveToFront(el)
+		c.stats.Hits++
+
+		e := el.Value.(*entry)
+		e.refs++
+		c.m.Unlock()
+
+		return e, nil
+	}
+	c.stats.Misses++
+	conn := c.conn
+	c.m.Unlock()
+
+	stmt, err := sqlx.PreparexContext(ctx, conn, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if el, found := c.items[query]; found { // prepared concurrently by other goroutine
+		_ = stmt.Close()
+		c.ll.MoveToFront(el)
+
+		e := el.Value.(*entry)
+		e.refs++
+
+		return e, nil
+	}
+
+	e := &entry{query: query, stmt: stmt, refs: 1}
+	if conn != c.conn { // reconnected while statement was prepared, it is used once and closed
+		e.removed = true
+
+		return e, nil
+	}
+
+	c.items[query] = c.ll.PushFront(e)
+
+	for c.ll.Len() > c.size {
+		el := c.ll.Back()
+		c.ll.Remove(el)
+		delete(c.items, el.Value.(*entry).query)
+		c.release(el.Value.(*entry), true)
+		c.stats.Evictions++
+	}
+
+	return e, nil
+}
+
+// done - query of statement is finished (rows of Query* are closed by database/sql before statement is closed).
+func (c *Conn) done(e *entry) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.release(e, false)
+}
+
+// remove - query of statement is finished, statement is removed from cache if it is still cached (only the failed
+// statement, the other ones are prepared on other connections of pool by database/sql).
+func (c *Conn) remove(e *entry) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if el, found := c.items[e.query]; found && el.Value.(*entry) == e {
+		c.ll.Remove(el)
+		delete(c.items, e.query)
+		c.stats.Invalidations++
+		c.release(e, true)
+	}
+
+	c.release(e, false)
+}
+
+// release - mark statement removed from cache (remove) or finish one query of it (!remove),
+// statement is closed when it is removed and not used.
+func (c *Conn) release(e *entry, remove bool) {
+	if remove {
+		e.removed = true
+	} else {
+		e.refs--
+	}
+
+	if e.removed && e.refs == 0 {
+		_ = e.stmt.Close()
+	}
+}
+
+func isConnClosedError(err error) bool {
+	return errors.Is(err, sql.ErrConnDone) || errors.Is(err, driver.ErrBadConn)
+}
+
+func isStaleStmtError(err error) bool {
+	return strings.Contains(err.Error(), errCachedPlan)
+}
+
+// DriverName, Rebind, BindNamed, PrepareContext and BeginTxx - the same as methods of wrapped connection.
+
+func (c *Conn) DriverName() string { return c.Connection().DriverName() }
+
+func (c *Conn) Rebind(query string) string { return c.Connection().Rebind(query) }
+
+func (c *Conn) BindNamed(query string, arg any) (string, []any, error) {
+	return c.Connection().BindNamed(query, arg)
+}
+
+func (c *Conn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return c.Connection().PrepareContext(ctx, query)
+}
+
+func (c *Conn) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	return c.Connection().BeginTxx(ctx, opts)
+}
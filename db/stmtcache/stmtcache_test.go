@@ -0,0 +1,225 @@
+package stmtcache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3" // for sqlite3 driver import.
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConn(t *testing.T, size int) *Conn {
+	t.Helper()
+
+	dbConn, err := sqlx.Connect("sqlite3", ":memory:")
+	require.Nil(t, err)
+
+	dbConn.SetMaxOpenConns(1)
+
+	_, err = dbConn.Exec("CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT)")
+	require.Nil(t, err)
+
+	c := New(dbConn, size)
+
+	t.Cleanup(func() {
+		c.Invalidate()
+		_ = dbConn.Close()
+	})
+
+	return c
+}
+
+func Test_Cache(t *testing.T) {
+	ctx := context.Background()
+	c := newConn(t, 0)
+	assert.Equal(t, DefaultSize, c.size)
+
+	for i := 1; i <= 3; i++ {
+		_, err := c.ExecContext(ctx, "INSERT INTO t (id, name) VALUES (?, ?)", i, fmt.Sprint("n", i))
+		assert.Nil(t, err)
+	}
+
+	var name string
+	assert.Nil(t, c.QueryRowxContext(ctx, "SELECT name FROM t WHERE id = ?", 2).Scan(&name))
+	assert.Equal(t, "n2", name)
+
+	err := c.QueryRowxContext(ctx, "SELECT name FROM t WHERE id = ?", 100).Scan(&name)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	rows, err := c.QueryxContext(ctx, "SELECT name FROM t ORDER BY id")
+	assert.Nil(t, err)
+
+	names := []string{}
+	for rows.Next() {
+		assert.Nil(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	assert.Nil(t, rows.Close())
+	assert.Equal(t, []string{"n1", "n2", "n3"}, names)
+
+	s := c.Stats()
+	assert.Equal(t, uint64(3), s.Misses)
+	assert.Equal(t, uint64(3), s.Hits)
+	assert.Equal(t, 3, s.Size)
+	assert.Equal(t, 0.5, s.HitRatio())
+
+	c.Invalidate()
+
+	s = c.Stats()
+	assert.Equal(t, 0, s.Size)
+	assert.Equal(t, uint64(3), s.Invalidations)
+
+	_, err = c.QueryContext(ctx, "SELECT name FROM unknown")
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, c.Stats().Size) // not prepared queries are not cached
+
+	err = c.QueryRowxContext(ctx, "SELECT name FROM unknown").Scan(&name)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, float64(0), Stats{}.HitRatio())
+}
+
+func Test_Eviction(t *testing.T) {
+	ctx := context.Background()
+	c := newConn(t, 2)
+
+	q1, q2, q3 := "SELECT 1 FROM t", "SELECT 2 FROM t", "SELECT 3 FROM t"
+
+	for _, q := range []string{q1, q2, q1, q3} { // q2 is least recently used when q3 is added
+		rows, err := c.QueryContext(ctx, q)
+		assert.Nil(t, err)
+		assert.Nil(t, rows.Close())
+	}
+
+	s := c.Stats()
+	assert.Equal(t, 2, s.Size)
+	assert.Equal(t, uint64(1), s.Evictions)
+	assert.Contains(t, c.items, q1)
+	assert.Contains(t, c.items, q3)
+	assert.NotContains(t, c.items, q2)
+}
+
+func Test_EvictionOfUsedStatement(t *testing.T) {
+	ctx := context.Background()
+	c := newConn(t, 1)
+
+	q1, q2 := "SELECT count(*) FROM t", "SELECT 2 FROM t"
+
+	err := c.do(ctx, q1, func(stmt *sqlx.Stmt) error {
+		rows, err := c.QueryContext(ctx, q2) // q1 is evicted while it is used
+		require.Nil(t, err)
+		require.Nil(t, rows.Close())
+
+		var n int
+
+		return stmt.QueryRowxContext(ctx).Scan(&n)
+	})
+	assert.Nil(t, err)
+
+	s := c.Stats()
+	assert.Equal(t, uint64(1), s.Evictions)
+	assert.Equal(t, 1, s.Size)
+	assert.Contains(t, c.items, q2)
+}
+
+func Test_StaleStatement(t *testing.T) {
+	ctx := context.Background()
+	c := newConn(t, 0)
+
+	q := "SELECT count(*) FROM t"
+
+	_, err := c.ExecContext(ctx, q)
+	assert.Nil(t, err)
+
+	stmt := c.items[q].Value.(*entry).stmt
+
+	calls := 0
+	err = c.do(ctx, q, func(s *sqlx.Stmt) error {
+		if calls++; calls == 1 {
+			return errors.New("ERROR: cached plan must not change result type (SQLSTATE 0A000)")
+		}
+
+		_, err := s.ExecContext(ctx)
+
+		return err
+	})
+	assert.Nil(t, err) // statement is prepared again and query is retried
+	assert.Equal(t, 2, calls)
+
+	s := c.Stats()
+	assert.Equal(t, uint64(1), s.Invalidations)
+	assert.Equal(t, 1, s.Size)
+	assert.NotEqual(t, stmt, c.items[q].Value.(*entry).stmt)
+}
+
+func Test_ConnClosedNotRetried(t *testing.T) {
+	ctx := context.Background()
+	c := newConn(t, 0)
+
+	_, err := c.ExecContext(ctx, "SELECT count(*) FROM t")
+	require.Nil(t, err)
+
+	q := "INSERT INTO t (name) VALUES ('a')"
+
+	calls := 0
+	err = c.do(ctx, q, func(stmt *sqlx.Stmt) error {
+		calls++
+		_, err := stmt.ExecContext(ctx)
+		assert.Nil(t, err)
+
+		return sql.ErrConnDone // outcome of query is unknown for caller
+	})
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.Equal(t, 1, calls) // query is not executed twice
+
+	var n int
+	require.Nil(t, c.Connection().(*sqlx.DB).Get(&n, "SELECT count(*) FROM t"))
+	assert.Equal(t, 1, n)
+
+	s := c.Stats() // only failed statement is removed
+	assert.Equal(t, 1, s.Size)
+	assert.Equal(t, uint64(1), s.Invalidations)
+	assert.NotContains(t, c.items, q)
+}
+
+func Test_Reconnect(t *testing.T) {
+	ctx := context.Background()
+	c := newConn(t, 0)
+
+	_, err := c.ExecContext(ctx, "INSERT INTO t (name) VALUES ('a')")
+	require.Nil(t, err)
+
+	other, err := sqlx.Connect("sqlite3", ":memory:")
+	require.Nil(t, err)
+
+	t.Cleanup(func() { _ = other.Close() })
+
+	_, err = other.Exec("CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT)")
+	require.Nil(t, err)
+
+	c.Reconnect(other)
+	assert.Equal(t, 0, c.Stats().Size)
+	assert.Equal(t, uint64(1), c.Stats().Invalidations)
+
+	var n int
+	assert.Nil(t, c.QueryRowxContext(ctx, "SELECT count(*) FROM t").Scan(&n))
+	assert.Equal(t, 0, n) // query is sent to new connection
+	assert.Equal(t, "sqlite3", c.DriverName())
+}
+
+func Test_Errors(t *testing.T) {
+	assert.True(t, isConnClosedError(sql.ErrConnDone))
+	assert.True(t, isConnClosedError(fmt.Errorf("wrap: %w", driver.ErrBadConn)))
+	assert.False(t, isConnClosedError(errors.New(sql.ErrConnDone.Error()))) // errors are not compared by text
+	assert.False(t, isConnClosedError(sql.ErrNoRows))
+
+	assert.True(t, isStaleStmtError(errors.New("pq: cached plan must not change result type")))
+	assert.False(t, isStaleStmtError(sql.ErrNoRows))
+}
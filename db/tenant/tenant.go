@@ -0,0 +1,22 @@
+// Package tenant - tenant of request in context.Context.
+//
+// Repositories of DTO with orm_tenant column (see orm.GetTenantColumn) scope all queries by tenant of context:
+// reads, updates and deletes get `tenant_id = ?` condition, created rows get value of tenant column.
+// Query of such repository without tenant in context fails with db.ErrNoTenant.
+package tenant
+
+import "context"
+
+type tenantKey struct{}
+
+// NewContext returns context with tenant id (nil id - without tenant).
+func NewContext(ctx context.Context, id any) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns tenant id of context (see NewContext).
+func FromContext(ctx context.Context) (any, bool) {
+	id := ctx.Value(tenantKey{})
+
+	return id, id != nil
+}
@@ -18,6 +18,45 @@ type TxxI interface {
 // that can be used for executing statements and queries against a database.
 type TxFn = func(*sqlx.Tx) error
 
+type (
+	txKey     struct{ db TxxI } // transaction of connection db
+	txLastKey struct{}          // the last transaction of ctx (any connection)
+)
+
+// ContextWithTx returns context with transaction of connection db, repositories of this connection execute queries
+// of such context in this transaction (e.g. lifecycle hooks of DTO can read and write in the same transaction
+// as repository operation). Repositories of other connections (other databases) do not use the transaction.
+func ContextWithTx(ctx context.Context, db TxxI, tx *sqlx.Tx) context.Context {
+	return context.WithValue(context.WithValue(ctx, txKey{db: db}, tx), txLastKey{}, tx)
+}
+
+// TxFromContext returns the last transaction of context (see ContextWithTx), e.g. transaction of write
+// in lifecycle hook of DTO. Use TxFromContextFor if ctx can contain transactions of several connections.
+func TxFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	tx, ok := ctx.Value(txLastKey{}).(*sqlx.Tx)
+
+	return tx, ok && tx != nil
+}
+
+// TxFromContextFor returns transaction of connection db of context (see ContextWithTx).
+func TxFromContextFor(ctx context.Context, db TxxI) (*sqlx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{db: db}).(*sqlx.Tx)
+
+	return tx, ok && tx != nil
+}
+
+// InTransaction execute fn in transaction of connection db of ctx (see ContextWithTx) or in new one,
+// ctx of fn always contains transaction of db.
+func InTransaction(ctx context.Context, db TxxI, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	if tx, ok := TxFromContextFor(ctx, db); ok {
+		return fn(ctx, tx)
+	}
+
+	return WithTransaction(ctx, nil, db, func(tx *sqlx.Tx) error {
+		return fn(ContextWithTx(ctx, db, tx), tx)
+	})
+}
+
 // WithTransaction execute [1...n] TxFn used one transaction
 // The provided context is used until the transaction is committed or rolled back.
 // If the context is canceled, the sql package will roll back the transaction.
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// tags and values are the same as in orm package (fields are walked the same way as orm does it by reflection).
+const (
+	tagDB          = "db"
+	tagOrmUseIN    = "orm_use_in"
+	tagOrmAlias    = "orm_alias"
+	tagOrmBelongs  = "orm_belongs_to"
+	tagOrmHasMany  = "orm_has_many"
+	tagOrmJSON     = "orm_json"
+	ormUseInSelect = "select"
+	ormUseInCreate = "create"
+	ormUseInUpdate = "update"
+
+	receiver = "d"
+)
+
+var (
+	ErrTypeNotFound   = errors.New("struct type is not found in package")
+	ErrJoinDTO        = errors.New("composite (join) DTO is not supported")
+	ErrExternalStruct = errors.New("embedded struct from other package is not supported")
+	ErrPointerStruct  = errors.New("pointer to struct (nil-safe access) is not supported, use reflection")
+)
+
+type (
+	dtoType struct {
+		name   string
+		params []string // names of type parameters
+		fields []column
+	}
+
+	column struct {
+		name string // db column
+		expr string // selector of field, e.g. d.BaseDTO.Id
+		uses string // orm_use_in tag value
+		json bool   // orm_json column
+	}
+
+	generator struct {
+		pkg     string
+		structs map[string]*ast.TypeSpec
+		leafs   map[string]bool // types with Value or Scan method (driver.Valuer, sql.Scanner), they are not walked
+	}
+)
+
+// generate - source of generated methods for types of package in dir.
+func generate(dir string, types []string) ([]byte, error) {
+	g, err := parse(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]dtoType, 0, len(types))
+
+	for _, name := range types {
+		d, err := g.dto(strings.TrimSpace(name))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		dtos = append(dtos, d)
+	}
+
+	return render(g.pkg, dtos)
+}
+
+func parse(dir string) (*generator, error) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{structs: map[string]*ast.TypeSpec{}, leafs: map[string]bool{}}
+
+	for name, pkg := range pkgs {
+		g.pkg = name
+
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				switch d := n.(type) {
+				case *ast.TypeSpec:
+					if _, ok := d.Type.(*ast.StructType); ok {
+						g.structs[d.Name.Name] = d
+					}
+				case *ast.FuncDecl:
+					if d.Recv != nil && len(d.Recv.List) > 0 && (d.Name.Name == "Value" || d.Name.Name == "Scan") {
+						if name, _ := g.structName(d.Recv.List[0].Type); name != "" {
+							g.leafs[name] = true
+						}
+					}
+				}
+
+				return true
+			})
+		}
+	}
+
+	return g, nil
+}
+
+func (g *generator) dto(name string) (dtoType, error) {
+	ts, found := g.structs[name]
+	if !found {
+		return dtoType{}, ErrTypeNotFound
+	}
+
+	d := dtoType{name: name}
+
+	if ts.TypeParams != nil {
+		for _, p := range ts.TypeParams.List {
+			for _, n := range p.Names {
+				d.params = append(d.params, n.Name)
+			}
+		}
+	}
+
+	fields, err := g.walk(ts.Type.(*ast.StructType), receiver)
+	if err != nil {
+		return dtoType{}, err
+	}
+
+	d.fields = fields
+
+	return d, nil
+}
+
+// walk - columns of struct in declaration order, nested structs of the same package are walked too.
+func (g *generator) walk(st *ast.StructType, path string) ([]column, error) {
+	cols := []column{}
+
+	for _, field := range st.Fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			s, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			tag = reflect.StructTag(s)
+		}
+
+		names := []string{}
+		for _, n := range field.Names {
+			names = append(names, n.Name)
+		}
+
+		typeName, local := g.structName(field.Type)
+		if len(field.Names) == 0 {
+			names = append(names, typeName)
+		}
+
+		for _, name := range names {
+			if !ast.IsExported(name) {
+				continue
+			}
+
+			expr := path + "." + name
+
+			if uses := tag.Get(tagOrmUseIN); !isTagEmpty(uses) {
+				if db := tag.Get(tagDB); !isTagEmpty(db) {
+					cols = append(cols, column{name: db, expr: expr, uses: uses, json: isTagTrue(tag.Get(tagOrmJSON))})
+				}
+
+				continue
+			}
+
+			if !isTagEmpty(tag.Get(tagOrmBelongs)) || !isTagEmpty(tag.Get(tagOrmHasMany)) {
+				continue
+			}
+
+			if !isTagEmpty(tag.Get(tagOrmAlias)) {
+				return nil, fmt.Errorf("%s: %w", name, ErrJoinDTO)
+			}
+
+			if star, ok := field.Type.(*ast.StarExpr); ok {
+				if name, local := g.structName(star.X); local && !g.leafs[name] {
+					return nil, fmt.Errorf("%s: %w", name, ErrPointerStruct)
+				}
+
+				continue
+			}
+
+			if !local || g.leafs[typeName] {
+				if len(field.Names) == 0 && typeName != "" && !local {
+					return nil, fmt.Errorf("%s: %w", name, ErrExternalStruct)
+				}
+
+				continue // struct of other package (time.Time and etc.) or not struct type, has not orm columns
+			}
+
+			c, err := g.walk(g.structs[typeName].Type.(*ast.StructType), expr)
+			if err != nil {
+				return nil, err
+			}
+
+			cols = append(cols, c...)
+		}
+	}
+
+	return cols, nil
+}
+
+// structName - name of type of field and whether it is struct declared in package (generic instantiation too).
+func (g *generator) structName(expr ast.Expr) (string, bool) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		_, found := g.structs[t.Name]
+
+		return t.Name, found
+	case *ast.IndexExpr:
+		return g.structName(t.X)
+	case *ast.IndexListExpr:
+		return g.structName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name, false
+	case *ast.StarExpr:
+		name, _ := g.structName(t.X)
+
+		return name, false // pointer receivers of methods, pointer fields are checked by walk
+	default:
+		return "", false
+	}
+}
+
+func render(pkg string, dtos []dtoType) ([]byte, error) {
+	b := &bytes.Buffer{}
+
+	fmt.Fprintf(b, "// Code generated by ormgen; DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(b, "import \"github.com/imperiuse/golib/reflect/orm\"\n")
+
+	for _, d := range dtos {
+		typ := d.name
+		if len(d.params) > 0 {
+			typ = fmt.Sprintf("%s[%s]", d.name, strings.Join(d.params, ", "))
+		}
+
+		sel, create, update := d.filter(ormUseInSelect), d.filter(ormUseInCreate), d.filter(ormUseInUpdate)
+
+		fmt.Fprintf(b, "\n// OrmGeneratedFor - see orm.Generated.\nfunc (%s) OrmGeneratedFor() any {\n\treturn %s{}\n}\n",
+			typ, typ)
+
+		fmt.Fprintf(b, "\nfunc (%s) Columns() []orm.Column {\n\treturn []orm.Column{%s}\n}\n", typ, names(sel))
+
+		fmt.Fprintf(b, "\nfunc (%s %s) CreateArgs() ([]orm.Column, []orm.Argument) {\n", receiver, typ)
+		fmt.Fprintf(b, "\treturn []orm.Column{%s}, []orm.Argument{%s}\n}\n", names(create), args(create))
+
+		fmt.Fprintf(b, "\nfunc (%s %s) UpdateMap() map[orm.Column]orm.Argument {\n", receiver, typ)
+		fmt.Fprintf(b, "\treturn map[orm.Column]orm.Argument{\n")
+
+		for _, c := range update {
+			fmt.Fprintf(b, "\t\t%q: %s,\n", c.name, c.arg())
+		}
+
+		fmt.Fprintf(b, "\t}\n}\n")
+
+		fmt.Fprintf(b, "\nfunc (%s *%s) ScanTargets() []any {\n\treturn []any{%s}\n}\n", receiver, typ, targets(sel))
+	}
+
+	return format.Source(b.Bytes())
+}
+
+func (d dtoType) filter(use string) []column {
+	cols := []column{}
+
+	for _, c := range d.fields {
+		if strings.Contains(c.uses, use) {
+			cols = append(cols, c)
+		}
+	}
+
+	return cols
+}
+
+func names(cols []column) string {
+	s := make([]string, 0, len(cols))
+	for _, c := range cols {
+		s = append(s, strconv.Quote(c.name))
+	}
+
+	return strings.Join(s, ", ")
+}
+
+func args(cols []column) string {
+	s := make([]string, 0, len(cols))
+	for _, c := range cols {
+		s = append(s, c.arg())
+	}
+
+	return strings.Join(s, ", ")
+}
+
+func targets(cols []column) string {
+	s := make([]string, 0, len(cols))
+	for _, c := range cols {
+		if c.json {
+			s = append(s, fmt.Sprintf("orm.JSON{V: &%s}", c.expr))
+		} else {
+			s = append(s, "&"+c.expr)
+		}
+	}
+
+	return strings.Join(s, ", ")
+}
+
+// arg - argument of column for create and update (orm_json value is marshaled by orm.JSON).
+func (c column) arg() string {
+	if c.json {
+		return fmt.Sprintf("orm.JSON{V: %s}", c.expr)
+	}
+
+	return c.expr
+}
+
+func isTagEmpty(tag string) bool {
+	return tag == "" || tag == "-"
+}
+
+func isTagTrue(tag string) bool {
+	return tag == "true" || tag == "1" || tag == "yes"
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/imperiuse/golib/db/example/simple/dto"
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+const dtoDir = "../../../../db/example/simple/dto"
+
+func Test_GeneratedIsUpToDate(t *testing.T) {
+	expected, err := os.ReadFile(dtoDir + "/orm_gen.go")
+	require.Nil(t, err)
+
+	src, err := generate(dtoDir, []string{"User", "Role", "Paginator"})
+	require.Nil(t, err)
+	assert.Equal(t, string(expected), string(src), "run go generate in "+dtoDir)
+}
+
+func Test_GeneratedEqualsReflection(t *testing.T) {
+	u := dto.User[int]{
+		BaseDTO: dto.BaseDTO[int]{Id: 1, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		Name:    "Bob", Email: "bob@example.com", Password: "secret", RoleID: 2,
+	}
+
+	// methods of embedded DTO are promoted to wrapper, but orm ignores them -> reflection is used
+	wrapper := struct{ dto.User[int] }{u}
+
+	_, ok := any(&wrapper).(orm.Generated)
+	assert.True(t, ok)
+
+	cols, args := orm.GetDataForCreate(&u)
+	wCols, wArgs := orm.GetDataForCreate(&wrapper)
+	assert.Equal(t, wCols, cols)
+	assert.Equal(t, wArgs, args)
+
+	assert.Equal(t, orm.GetDataForUpdate(&wrapper), orm.GetDataForUpdate(u))
+	assert.Equal(t, orm.GetScanTargets(&wrapper.User), orm.GetScanTargets(&wrapper)[:7])
+	assert.Equal(t, []orm.Column{"id", "created_at", "updated_at", "name", "email", "password", "role_id"},
+		orm.GetDataForSelectOnlyCols(u))
+}
+
+func Test_GenerateLeafAndJSON(t *testing.T) {
+	src, err := generate("testdata/leaf", []string{"Order"})
+	require.Nil(t, err)
+
+	assert.Contains(t, string(src), `return []orm.Column{"id", "price", "meta"}`)
+	assert.Contains(t, string(src), `return []orm.Column{"price", "meta"}, []orm.Argument{d.Price, orm.JSON{V: d.Meta}}`)
+	assert.Contains(t, string(src), `"meta": orm.JSON{V: d.Meta},`)
+	assert.Contains(t, string(src), `return []any{&d.ID, &d.Price, orm.JSON{V: &d.Meta}}`)
+}
+
+func Test_GenerateErrors(t *testing.T) {
+	_, err := generate(dtoDir, []string{"Unknown"})
+	assert.ErrorIs(t, err, ErrTypeNotFound)
+
+	_, err = generate("testdata/join", []string{"UsersRole"})
+	assert.ErrorIs(t, err, ErrJoinDTO)
+
+	_, err = generate("testdata/external", []string{"Admin"})
+	assert.ErrorIs(t, err, ErrExternalStruct)
+
+	_, err = generate("testdata/pointer", []string{"Event"})
+	assert.ErrorIs(t, err, ErrPointerStruct)
+
+	_, err = generate("testdata/absent", []string{"User"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,48 @@
+// Command ormgen - generator of orm methods for DTO (Columns, CreateArgs, UpdateMap, ScanTargets),
+// orm uses generated methods instead of reflection (see orm.Generated).
+//
+// Usage (in file with DTO declarations):
+//
+//	//go:generate go run github.com/imperiuse/golib/reflect/orm/cmd/ormgen -type=User,Role
+//
+// DTO and embedded structs (without db tag) must be declared in the same package, composite (join) DTO and pointers
+// to structs (embedded *BaseDTO) are not supported, orm_json columns are wrapped by orm.JSON. Only db tags are used,
+// fields named by orm.NamingStrategy are skipped, so orm ignores generated methods of such DTO.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	if err := app(); err != nil {
+		log.Fatal("ormgen failed with error: ", err)
+	}
+}
+
+func app() error {
+	types := flag.String("type", "", "comma-separated list of DTO type names (required)")
+	output := flag.String("output", "orm_gen.go", "output file name (in package dir)")
+	flag.Parse()
+
+	if *types == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if args := flag.Args(); len(args) > 0 {
+		dir = args[0]
+	}
+
+	src, err := generate(dir, strings.Split(*types, ","))
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, *output), src, 0o644)
+}
@@ -0,0 +1,8 @@
+package external
+
+import "github.com/imperiuse/golib/db/example/simple/dto"
+
+type Admin struct {
+	dto.User[int]
+	Level int `db:"level" orm_use_in:"select,create"`
+}
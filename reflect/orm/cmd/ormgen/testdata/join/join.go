@@ -0,0 +1,16 @@
+package join
+
+type (
+	User struct {
+		ID int64 `db:"id" orm_use_in:"select"`
+	}
+
+	Role struct {
+		ID int64 `db:"id" orm_use_in:"select"`
+	}
+
+	UsersRole struct {
+		User `db:"u" orm_alias:"u"`
+		Role `db:"r" orm_alias:"r"`
+	}
+)
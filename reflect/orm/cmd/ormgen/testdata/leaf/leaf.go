@@ -0,0 +1,19 @@
+package leaf
+
+import "database/sql/driver"
+
+type (
+	// Money - driver.Valuer, one column, fields are not walked.
+	Money struct {
+		Amount int64 `db:"amount" orm_use_in:"select,create"`
+	}
+
+	Order struct {
+		ID    int64          `db:"id"    orm_use_in:"select"`
+		Price Money          `db:"price" orm_use_in:"select,create"`
+		Total Money          // not column
+		Meta  map[string]any `db:"meta"  orm_use_in:"select,create,update" orm_json:"true"`
+	}
+)
+
+func (m *Money) Value() (driver.Value, error) { return m.Amount, nil }
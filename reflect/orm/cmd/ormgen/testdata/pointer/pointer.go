@@ -0,0 +1,12 @@
+package pointer
+
+type (
+	Base struct {
+		ID int64 `db:"id" orm_use_in:"select"`
+	}
+
+	Event struct {
+		*Base
+		Kind string `db:"kind" orm_use_in:"select,create"`
+	}
+)
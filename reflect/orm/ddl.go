@@ -0,0 +1,316 @@
+package orm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DDL generation based on DTO tags.
+//
+// Column is every field with `db` tag and `orm_use_in` or `orm_type` tag (embedded structs are walked like for select),
+// additional tags:
+//
+//	orm_type:"VARCHAR(255)"               - sql type, if absent, type is derived from go type of field for dialect
+//	orm_null:"true"                       - column is nullable, pointers and sql.Null* types are nullable by default
+//	orm_default:"CURRENT_TIMESTAMP"       - default value expression
+//	orm_pk:"true" | orm_pk:"identity"     - primary key (identity - auto generated), several pk columns -> composite pk
+//	orm_pk:"uuid"                         - primary key generated by client on create (see GetGeneratedKeys)
+//	orm_unique:"true"                     - unique constraint
+//	orm_fk:"roles(id) ON DELETE CASCADE"  - foreign key, references part (+ optional actions)
+//	orm_json:"true"                       - JSON column (JSONB, JSON or TEXT), value is marshaled (see JSON)
+//
+// Embedded pointer structs (*BaseDTO) are walked like embedded structs.
+//
+// uint and uint64 are NUMERIC(20) on Postgres (BIGINT can't hold values above math.MaxInt64, identity is BIGINT),
+// BIGINT UNSIGNED on MySQL and INTEGER on SQLite (driver rejects values above math.MaxInt64).
+
+type (
+	Dialect = string
+
+	// ColumnDef - description of one column for DDL.
+	ColumnDef = struct {
+		Name       Column
+		Type       Typ
+		Nullable   bool
+		Default    string
+		PrimaryKey bool
+		Identity   bool
+		Unique     bool
+		ForeignKey string // references part, e.g. `roles(id) ON DELETE CASCADE`
+	}
+)
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectMySQL    Dialect = "mysql"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+const (
+	tagOrmType    = "orm_type"
+	tagOrmNull    = "orm_null"
+	tagOrmDefault = "orm_default"
+	tagOrmPK      = "orm_pk"
+	tagOrmUnique  = "orm_unique"
+	tagOrmFK      = "orm_fk"
+
+	ormPKIdentity = "identity"
+	ormPKUUID     = "uuid"
+)
+
+var (
+	ErrNoTableName         = errors.New("orm_table_name tag is not set")
+	ErrNoColumns           = errors.New("DTO has not any column")
+	ErrUnknownDialect      = errors.New("unknown sql dialect")
+	ErrUnknownColumnType   = errors.New("can't derive sql type from go type, use orm_type tag")
+	ErrIdentityCompositePK = errors.New("identity is not allowed for composite primary key")
+
+	typeTime    = reflect.TypeOf(time.Time{})
+	typeBytes   = reflect.TypeOf([]byte{})
+	typeScanner = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+	nullTypes = map[reflect.Type]reflect.Type{
+		reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+		reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+		reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+		reflect.TypeOf(sql.NullInt16{}):   reflect.TypeOf(int16(0)),
+		reflect.TypeOf(sql.NullByte{}):    reflect.TypeOf(int16(0)),
+		reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+		reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+		reflect.TypeOf(sql.NullTime{}):    typeTime,
+	}
+
+	dialectTypes = map[Dialect]map[reflect.Kind]Typ{
+		DialectPostgres: {
+			reflect.Bool: "BOOLEAN", reflect.String: "TEXT", reflect.Float32: "REAL", reflect.Float64: "DOUBLE PRECISION",
+			reflect.Int8: "SMALLINT", reflect.Int16: "SMALLINT", reflect.Int32: "INTEGER",
+			reflect.Int: "BIGINT", reflect.Int64: "BIGINT",
+			reflect.Uint8: "SMALLINT", reflect.Uint16: "INTEGER", reflect.Uint32: "BIGINT",
+			reflect.Uint: "NUMERIC(20)", reflect.Uint64: "NUMERIC(20)",
+		},
+		DialectMySQL: {
+			reflect.Bool: "BOOLEAN", reflect.String: "TEXT", reflect.Float32: "FLOAT", reflect.Float64: "DOUBLE",
+			reflect.Int8: "TINYINT", reflect.Int16: "SMALLINT", reflect.Int32: "INT",
+			reflect.Int: "BIGINT", reflect.Int64: "BIGINT",
+			reflect.Uint8: "TINYINT UNSIGNED", reflect.Uint16: "SMALLINT UNSIGNED", reflect.Uint32: "INT UNSIGNED",
+			reflect.Uint: "BIGINT UNSIGNED", reflect.Uint64: "BIGINT UNSIGNED",
+		},
+		DialectSQLite: {
+			reflect.Bool: "BOOLEAN", reflect.String: "TEXT", reflect.Float32: "REAL", reflect.Float64: "REAL",
+			reflect.Int8: "INTEGER", reflect.Int16: "INTEGER", reflect.Int32: "INTEGER",
+			reflect.Int: "INTEGER", reflect.Int64: "INTEGER",
+			reflect.Uint8: "INTEGER", reflect.Uint16: "INTEGER", reflect.Uint32: "INTEGER",
+			reflect.Uint: "INTEGER", reflect.Uint64: "INTEGER",
+		},
+	}
+
+	dialectJSONTypes = map[Dialect]Typ{DialectPostgres: "JSONB", DialectMySQL: "JSON", DialectSQLite: "TEXT"}
+
+	dialectSpecialTypes = map[Dialect]map[reflect.Type]Typ{
+		DialectPostgres: {typeTime: "TIMESTAMP", typeBytes: "BYTEA", reflect.TypeOf([16]byte{}): "UUID"},
+		DialectMySQL:    {typeTime: "DATETIME", typeBytes: "BLOB", reflect.TypeOf([16]byte{}): "BINARY(16)"},
+		DialectSQLite:   {typeTime: "TIMESTAMP", typeBytes: "BLOB", reflect.TypeOf([16]byte{}): "BLOB"},
+	}
+)
+
+// GetColumnDefs - return columns description of DTO for DDL in declaration order.
+func GetColumnDefs(obj any, dialect Dialect) ([]ColumnDef, error) {
+	if _, found := dialectTypes[dialect]; !found {
+		return nil, fmt.Errorf("%s: %w", dialect, ErrUnknownDialect)
+	}
+
+	if obj == nil {
+		return []ColumnDef{}, nil
+	}
+
+	t := reflect.Indirect(reflect.ValueOf(obj)).Type()
+	if t.Kind() != reflect.Struct {
+		return []ColumnDef{}, nil
+	}
+
+	return getColumnDefs(t, dialect)
+}
+
+func getColumnDefs(t reflect.Type, dialect Dialect) ([]ColumnDef, error) {
+	defs := []ColumnDef{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		dbTagValue := columnName(t, field)
+		if isTagEmpty(dbTagValue) || (isTagEmpty(field.Tag.Get(tagOrmUseIN)) && isTagEmpty(field.Tag.Get(tagOrmType))) {
+			if ft, ok := nestedStruct(field.Type); ok && field.Anonymous {
+				d, err := getColumnDefs(ft, dialect)
+				if err != nil {
+					return nil, err
+				}
+				defs = append(defs, d...)
+			}
+
+			continue
+		}
+
+		def := ColumnDef{
+			Name:       dbTagValue,
+			Type:       field.Tag.Get(tagOrmType),
+			Default:    field.Tag.Get(tagOrmDefault),
+			Nullable:   isTagTrue(field.Tag.Get(tagOrmNull)),
+			PrimaryKey: !isTagEmpty(field.Tag.Get(tagOrmPK)) && field.Tag.Get(tagOrmPK) != "false",
+			Identity:   field.Tag.Get(tagOrmPK) == ormPKIdentity,
+			Unique:     isTagTrue(field.Tag.Get(tagOrmUnique)),
+			ForeignKey: strings.TrimSpace(field.Tag.Get(tagOrmFK)),
+		}
+
+		typ, nullable := field.Type, false
+		if typ.Kind() == reflect.Pointer {
+			typ, nullable = typ.Elem(), true
+		}
+
+		if under, found := nullTypes[typ]; found {
+			typ, nullable = under, true
+		}
+
+		if isJSONField(field) {
+			nullable = nullable || typ.Kind() == reflect.Map || typ.Kind() == reflect.Slice // nil is NULL
+			if def.Type == "" {
+				def.Type = dialectJSONTypes[dialect]
+			}
+		}
+
+		if def.Type == "" {
+			def.Type = sqlType(typ, dialect)
+			if def.Type == "" {
+				return nil, fmt.Errorf("%s.%s (%s): %w", t.Name(), field.Name, field.Type, ErrUnknownColumnType)
+			}
+
+			if def.Identity && dialect == DialectPostgres && (typ.Kind() == reflect.Uint || typ.Kind() == reflect.Uint64) {
+				def.Type = "BIGINT" // identity must be integer type, generated values are positive BIGINT
+			}
+		}
+
+		if nullable && field.Tag.Get(tagOrmNull) != "false" {
+			def.Nullable = true
+		}
+
+		defs = append(defs, def)
+	}
+
+	return defs, nil
+}
+
+func sqlType(t reflect.Type, dialect Dialect) Typ {
+	if typ, found := dialectSpecialTypes[dialect][t]; found {
+		return typ
+	}
+
+	if t.Kind() == reflect.Array && t.Len() == 16 && t.Elem().Kind() == reflect.Uint8 {
+		return dialectSpecialTypes[dialect][reflect.TypeOf([16]byte{})] // uuid.UUID and similar types
+	}
+
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return dialectSpecialTypes[dialect][typeBytes]
+	}
+
+	if t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(typeScanner) {
+		return "" // custom Scanner types must be described by orm_type tag
+	}
+
+	return dialectTypes[dialect][t.Kind()]
+}
+
+// GetCreateTableDDL - return `CREATE TABLE IF NOT EXISTS` statement for DTO (see tags description above).
+func GetCreateTableDDL(obj any, dialect Dialect) (string, error) {
+	table := GetTableName(obj)
+	if table == "" {
+		return "", ErrNoTableName
+	}
+
+	defs, err := GetColumnDefs(obj, dialect)
+	if err != nil {
+		return "", err
+	}
+
+	if len(defs) == 0 {
+		return "", fmt.Errorf("%s: %w", table, ErrNoColumns)
+	}
+
+	pks := []Column{}
+	for _, d := range defs {
+		if d.PrimaryKey {
+			pks = append(pks, d.Name)
+		}
+	}
+
+	lines := make([]string, 0, len(defs))
+	constraints := []string{}
+
+	for _, d := range defs {
+		if d.Identity && len(pks) > 1 {
+			return "", fmt.Errorf("%s: %w", table, ErrIdentityCompositePK)
+		}
+
+		lines = append(lines, columnDDL(d, dialect, len(pks) == 1))
+
+		if d.ForeignKey != "" {
+			constraints = append(constraints, fmt.Sprintf("CONSTRAINT fk_%s_%s FOREIGN KEY (%s) REFERENCES %s",
+				strings.ToLower(table), d.Name, d.Name, d.ForeignKey))
+		}
+	}
+
+	if len(pks) > 1 {
+		constraints = append([]string{fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(pks, ", "))}, constraints...)
+	}
+
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s\n(\n%s\n);",
+		table, strings.Join(append(lines, constraints...), ",\n")), nil
+}
+
+func columnDDL(d ColumnDef, dialect Dialect, singlePK bool) string {
+	sb := strings.Builder{}
+	sb.WriteString(d.Name)
+	sb.WriteString(" ")
+
+	if d.Identity && dialect == DialectSQLite {
+		sb.WriteString("INTEGER PRIMARY KEY AUTOINCREMENT") // sqlite allows autoincrement only for INTEGER PRIMARY KEY
+
+		return sb.String()
+	}
+
+	sb.WriteString(d.Type)
+
+	if d.PrimaryKey && singlePK {
+		sb.WriteString(" PRIMARY KEY")
+	}
+
+	if d.Identity {
+		switch dialect {
+		case DialectPostgres:
+			sb.WriteString(" GENERATED BY DEFAULT AS IDENTITY")
+		case DialectMySQL:
+			sb.WriteString(" AUTO_INCREMENT")
+		}
+	}
+
+	if !d.Nullable && !(d.PrimaryKey && singlePK) {
+		sb.WriteString(" NOT NULL")
+	}
+
+	if d.Default != "" {
+		sb.WriteString(" DEFAULT ")
+		sb.WriteString(d.Default)
+	}
+
+	if d.Unique {
+		sb.WriteString(" UNIQUE")
+	}
+
+	return sb.String()
+}
+
+func isTagTrue(tag string) bool {
+	return tag == "true" || tag == "1" || tag == "yes"
+}
@@ -0,0 +1,154 @@
+package orm
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	DDLRole struct {
+		ID   int64  `db:"id"   orm_use_in:"select" orm_pk:"identity"`
+		Name string `db:"name" orm_use_in:"select,create" orm_type:"VARCHAR(64)" orm_unique:"true"`
+		_    any    `orm_table_name:"roles"`
+	}
+
+	DDLUser struct {
+		BaseDTO
+		UID       uuid.UUID      `db:"uid"        orm_use_in:"select,create" orm_unique:"true"`
+		Email     string         `db:"email"      orm_use_in:"select,create"`
+		Nick      sql.NullString `db:"nick"       orm_use_in:"select,create"`
+		Age       *int32         `db:"age"        orm_use_in:"select,create"`
+		Score     float64        `db:"score"      orm_use_in:"select" orm_default:"0"`
+		Active    bool           `db:"active"     orm_use_in:"select" orm_null:"true"`
+		Avatar    []byte         `db:"avatar"     orm_use_in:"select"`
+		RoleID    int64          `db:"role_id"    orm_use_in:"select" orm_fk:"roles(id) ON DELETE CASCADE"`
+		DeletedAt *time.Time     `db:"deleted_at" orm_type:"TIMESTAMPTZ"`
+		Ignored   string         `db:"ignored"`
+		_         any            `orm_table_name:"users"`
+	}
+
+	DDLUserRole struct {
+		UserID int64 `db:"user_id" orm_use_in:"select" orm_pk:"true" orm_fk:"users(id)"`
+		RoleID int64 `db:"role_id" orm_use_in:"select" orm_pk:"true" orm_fk:"roles(id)"`
+		_      any   `orm_table_name:"users_roles"`
+	}
+
+	DDLCounter struct {
+		ID    uint64  `db:"id"    orm_use_in:"select" orm_pk:"identity"`
+		Value uint64  `db:"value" orm_use_in:"select,create"`
+		Quota *uint32 `db:"quota" orm_use_in:"select,create"`
+		_     any     `orm_table_name:"counters"`
+	}
+
+	DDLBadType struct {
+		Ch chan int `db:"ch" orm_use_in:"select"`
+		_  any      `orm_table_name:"bad"`
+	}
+
+	DDLBadIdentity struct {
+		A int64 `db:"a" orm_use_in:"select" orm_pk:"identity"`
+		B int64 `db:"b" orm_use_in:"select" orm_pk:"true"`
+		_ any   `orm_table_name:"bad_identity"`
+	}
+)
+
+func Test_GetCreateTableDDL(t *testing.T) {
+	ddl, err := GetCreateTableDDL(&DDLRole{}, DialectPostgres)
+	assert.Nil(t, err)
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS roles
+(
+id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+name VARCHAR(64) NOT NULL UNIQUE
+);`, ddl)
+
+	ddl, err = GetCreateTableDDL(DDLRole{}, DialectMySQL)
+	assert.Nil(t, err)
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS roles
+(
+id BIGINT PRIMARY KEY AUTO_INCREMENT,
+name VARCHAR(64) NOT NULL UNIQUE
+);`, ddl)
+
+	ddl, err = GetCreateTableDDL(&DDLRole{}, DialectSQLite)
+	assert.Nil(t, err)
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS roles
+(
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+name VARCHAR(64) NOT NULL UNIQUE
+);`, ddl)
+
+	ddl, err = GetCreateTableDDL(&DDLUser{}, DialectPostgres)
+	assert.Nil(t, err)
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS users
+(
+id BIGINT NOT NULL,
+created_at TIMESTAMP NOT NULL,
+updated_at TIMESTAMP NOT NULL,
+uid UUID NOT NULL UNIQUE,
+email TEXT NOT NULL,
+nick TEXT,
+age INTEGER,
+score DOUBLE PRECISION NOT NULL DEFAULT 0,
+active BOOLEAN,
+avatar BYTEA NOT NULL,
+role_id BIGINT NOT NULL,
+deleted_at TIMESTAMPTZ,
+CONSTRAINT fk_users_role_id FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);`, ddl)
+
+	ddl, err = GetCreateTableDDL(&DDLUserRole{}, DialectSQLite)
+	assert.Nil(t, err)
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS users_roles
+(
+user_id INTEGER NOT NULL,
+role_id INTEGER NOT NULL,
+PRIMARY KEY (user_id, role_id),
+CONSTRAINT fk_users_roles_user_id FOREIGN KEY (user_id) REFERENCES users(id),
+CONSTRAINT fk_users_roles_role_id FOREIGN KEY (role_id) REFERENCES roles(id)
+);`, ddl)
+}
+
+func Test_GetCreateTableDDL_Unsigned(t *testing.T) {
+	ddl, err := GetCreateTableDDL(&DDLCounter{}, DialectPostgres)
+	assert.Nil(t, err)
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS counters
+(
+id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+value NUMERIC(20) NOT NULL,
+quota BIGINT
+);`, ddl)
+
+	ddl, err = GetCreateTableDDL(&DDLCounter{}, DialectMySQL)
+	assert.Nil(t, err)
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS counters
+(
+id BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+value BIGINT UNSIGNED NOT NULL,
+quota INT UNSIGNED
+);`, ddl)
+}
+
+func Test_GetCreateTableDDL_Negative(t *testing.T) {
+	_, err := GetCreateTableDDL(&DDLRole{}, "oracle")
+	assert.ErrorIs(t, err, ErrUnknownDialect)
+
+	_, err = GetCreateTableDDL(&BaseDTO{}, DialectPostgres)
+	assert.ErrorIs(t, err, ErrNoTableName)
+
+	_, err = GetCreateTableDDL(nil, DialectPostgres)
+	assert.ErrorIs(t, err, ErrNoTableName)
+
+	_, err = GetCreateTableDDL(&DDLBadType{}, DialectPostgres)
+	assert.ErrorIs(t, err, ErrUnknownColumnType)
+
+	_, err = GetCreateTableDDL(&DDLBadIdentity{}, DialectPostgres)
+	assert.ErrorIs(t, err, ErrIdentityCompositePK)
+
+	defs, err := GetColumnDefs(123, DialectPostgres)
+	assert.Nil(t, err)
+	assert.Equal(t, []ColumnDef{}, defs)
+}
@@ -0,0 +1,239 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type (
+	// Schema - structured description of DTO for tooling (see Describe).
+	Schema = struct {
+		Type       string // full type name with package path
+		Table      Table
+		Alias      Alias
+		PrimaryKey []Column
+		Columns    []ColumnInfo
+		JoinParts  []JoinPart
+		Relations  []Relation
+	}
+
+	// ColumnInfo - description of one column of DTO (field with db and orm_use_in tags).
+	ColumnInfo = struct {
+		Name      Column
+		Alias     Alias  // alias of table for composite (join) DTO, empty for root table
+		Field     string // path of go field, e.g. BaseDTO.ID
+		GoType    string
+		Select    bool
+		Create    bool
+		Update    bool
+		JSON      bool
+		Sensitive bool
+		Nullable  bool // pointer, sql.Null*, nil-able JSON or orm_null:"true"
+	}
+
+	// SchemaError - all problems of DTO tags found by Validate.
+	SchemaError struct {
+		Type     string
+		Problems []error
+	}
+)
+
+var (
+	ErrDuplicateColumn = errors.New("duplicate db column")
+	ErrConflictingTags = errors.New("conflicting orm tags")
+	ErrNoJoinCond      = errors.New("join condition is not set, use orm_join tag")
+
+	ormUseInValues = map[string]bool{ormUseInSelect: true, ormUseInCreate: true, ormUseInUpdate: true}
+)
+
+// Describe - structured schema of DTO: table, columns in declaration order, pk, join parts and relations.
+func Describe(obj any) Schema {
+	t := metaKey(obj)
+	if t == nil || t.Kind() != reflect.Struct {
+		return Schema{Columns: []ColumnInfo{}, JoinParts: []JoinPart{}, Relations: []Relation{}}
+	}
+
+	zero := reflect.New(t).Interface()
+	meta := GetMetaDTO(zero)
+
+	return Schema{
+		Type:       fullTypeName(t),
+		Table:      meta.TableName,
+		Alias:      meta.TableAlias,
+		PrimaryKey: GetPrimaryKeyColumns(zero),
+		Columns:    describeFields(t, "", emptyRootAlias),
+		JoinParts:  meta.JoinParts,
+		Relations:  GetRelations(zero),
+	}
+}
+
+// Validate - check tags of DTO: duplicate db columns, orm_use_in without db column, unknown orm_use_in values,
+// relation fields with db column, missing orm_table_name (for not composite DTO) and joined table without
+// condition (ErrNoJoinCond), nil if tags are correct,
+// *SchemaError (errors.Is works for every problem) otherwise.
+func Validate(obj any) error {
+	t := metaKey(obj)
+	if t == nil || t.Kind() != reflect.Struct {
+		return &SchemaError{Type: fmt.Sprint(t), Problems: []error{fmt.Errorf("struct expected: %w", ErrConflictingTags)}}
+	}
+
+	typeName := fullTypeName(t)
+	problems := validateFields(t, typeName, "")
+
+	seen := map[string]string{}
+	for _, f := range describeFields(t, "", emptyRootAlias) {
+		key := f.Alias + "." + f.Name
+		if prev, found := seen[key]; found {
+			problems = append(problems, fmt.Errorf("%s.%s and %s: column %q: %w",
+				typeName, prev, f.Field, f.Name, ErrDuplicateColumn))
+
+			continue
+		}
+
+		seen[key] = f.Field
+	}
+
+	meta := GetMetaDTO(reflect.New(t).Interface())
+	if isTagEmpty(meta.TableName) && len(meta.JoinParts) == 0 {
+		problems = append(problems, fmt.Errorf("%s: %w", typeName, ErrNoTableName))
+	}
+
+	if meta.JoinErr != nil {
+		problems = append(problems, meta.JoinErr)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return &SchemaError{Type: typeName, Problems: problems}
+}
+
+func (e *SchemaError) Error() string {
+	msgs := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		msgs = append(msgs, p.Error())
+	}
+
+	return fmt.Sprintf("invalid DTO %s: %s", e.Type, strings.Join(msgs, "; "))
+}
+
+// Is - errors.Is(err, ErrDuplicateColumn) and etc. for any of problems.
+func (e *SchemaError) Is(target error) bool {
+	for _, p := range e.Problems {
+		if errors.Is(p, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// describeFields - columns of DTO, walked the same way as getMetaInfoUseInTag does it (by type, not by value).
+func describeFields(t reflect.Type, path string, alias Alias) []ColumnInfo {
+	fields := []ColumnInfo{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.TrimPrefix(path+"."+field.Name, ".")
+
+		if tagValue := field.Tag.Get(tagOrmUseIN); !isTagEmpty(tagValue) {
+			dbTagValue := columnName(t, field)
+			if isTagEmpty(dbTagValue) {
+				continue
+			}
+
+			fields = append(fields, columnInfo(field, name, dbTagValue, tagValue, alias))
+
+			continue
+		}
+
+		if ft, ok := nestedField(field); ok && field.IsExported() && !isRelationField(field) {
+			if a := field.Tag.Get(tagOrmAlias); !isTagEmpty(a) {
+				alias = a // the same as for select columns, alias is kept for next nested structs
+			}
+
+			fields = append(fields, describeFields(ft, name, alias)...)
+		}
+	}
+
+	return fields
+}
+
+func columnInfo(field reflect.StructField, name string, column Column, useIn string, alias Alias) ColumnInfo {
+	typ := field.Type
+	nullable := typ.Kind() == reflect.Pointer || isTagTrue(field.Tag.Get(tagOrmNull))
+
+	if _, found := nullTypes[typ]; found {
+		nullable = true
+	}
+
+	if isJSONField(field) && (typ.Kind() == reflect.Map || typ.Kind() == reflect.Slice) {
+		nullable = true
+	}
+
+	return ColumnInfo{
+		Name:      column,
+		Alias:     alias,
+		Field:     name,
+		GoType:    typ.String(),
+		Select:    strings.Contains(useIn, ormUseInSelect),
+		Create:    strings.Contains(useIn, ormUseInCreate),
+		Update:    strings.Contains(useIn, ormUseInUpdate),
+		JSON:      isJSONField(field),
+		Sensitive: IsSensitiveField(field),
+		Nullable:  nullable && field.Tag.Get(tagOrmNull) != "false",
+	}
+}
+
+// validateFields - problems of tags of single fields (conflicting tags).
+func validateFields(t reflect.Type, typeName, path string) []error {
+	problems := []error{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.TrimPrefix(path+"."+field.Name, ".")
+		dbTagValue := columnName(t, field)
+
+		if useIn := field.Tag.Get(tagOrmUseIN); !isTagEmpty(useIn) {
+			if isTagEmpty(dbTagValue) && field.Name != "_" { // blank field is only holder of tags
+				problems = append(problems, fmt.Errorf("%s.%s: orm_use_in without db column: %w",
+					typeName, name, ErrConflictingTags))
+			}
+
+			for _, v := range strings.Split(useIn, ",") {
+				if !ormUseInValues[strings.TrimSpace(v)] {
+					problems = append(problems, fmt.Errorf("%s.%s: unknown orm_use_in value %q: %w",
+						typeName, name, v, ErrConflictingTags))
+				}
+			}
+
+			continue
+		}
+
+		if isRelationField(field) {
+			if !isTagEmpty(dbTagValue) {
+				problems = append(problems, fmt.Errorf("%s.%s: relation field must have db:\"-\" tag: %w",
+					typeName, name, ErrConflictingTags))
+			}
+
+			continue
+		}
+
+		if ft, ok := nestedField(field); ok {
+			problems = append(problems, validateFields(ft, typeName, name)...)
+		}
+	}
+
+	return problems
+}
+
+func fullTypeName(t reflect.Type) string {
+	if t.PkgPath() == "" {
+		return t.String()
+	}
+
+	return t.PkgPath() + "." + t.Name()
+}
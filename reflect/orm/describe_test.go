@@ -0,0 +1,106 @@
+package orm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	DescribedUser struct {
+		DDLRole
+		Password string            `db:"password" orm_use_in:"create"        orm_sensitive:"true"`
+		Meta     map[string]string `db:"meta"     orm_use_in:"select,update" orm_json:"true"`
+		Role     *DDLRole          `db:"-"        orm_belongs_to:"role_id"`
+		_        any               `orm_table_name:"users"`
+	}
+
+	GenericDTO[T any] struct {
+		Value T   `db:"value" orm_use_in:"select,create"`
+		_     any `orm_table_name:"generic"`
+	}
+
+	InvalidDTO struct {
+		ID      int64    `db:"id"   orm_use_in:"select"`
+		Name    string   `db:"id"   orm_use_in:"select,insert"`
+		NoDB    string   `orm_use_in:"select"`
+		Role    *DDLRole `db:"role" orm_belongs_to:"role_id"`
+		DDLRole          // id column again
+	}
+)
+
+func Test_MetaCacheKeyedByType(t *testing.T) {
+	assert.Equal(t, "A", GetTableName(&A{})) // A of package level
+
+	type A struct { // the same name as A of package level
+		X int `db:"x" orm_use_in:"select"`
+		_ any `orm_table_name:"local"`
+	}
+
+	assert.Equal(t, "local", GetTableName(A{}))
+	assert.Equal(t, []Column{"x"}, GetDataForSelectOnlyCols(A{}))
+
+	assert.Equal(t, []Column{"value"}, GetDataForSelectOnlyCols(GenericDTO[int]{}))
+	cols, args := GetDataForCreate(&GenericDTO[string]{Value: "v"})
+	assert.Equal(t, []Column{"value"}, cols)
+	assert.Equal(t, []Argument{"v"}, args)
+	assert.NotSame(t, GetMetaDTO(GenericDTO[int]{}), GetMetaDTO(GenericDTO[string]{}))
+	assert.Same(t, GetMetaDTO(GenericDTO[int]{}), GetMetaDTO(&GenericDTO[int]{}))
+}
+
+func Test_Describe(t *testing.T) {
+	s := Describe(&DescribedUser{})
+
+	assert.Equal(t, "github.com/imperiuse/golib/reflect/orm.DescribedUser", s.Type)
+	assert.Equal(t, "users", s.Table)
+	assert.Equal(t, []Column{"id"}, s.PrimaryKey)
+	assert.Empty(t, s.JoinParts)
+	assert.Equal(t, []string{"Role"}, relationFields(s.Relations))
+	assert.Equal(t, []ColumnInfo{
+		{Name: "id", Field: "DDLRole.ID", GoType: "int64", Select: true},
+		{Name: "name", Field: "DDLRole.Name", GoType: "string", Select: true, Create: true},
+		{Name: "password", Field: "Password", GoType: "string", Create: true, Sensitive: true},
+		{Name: "meta", Field: "Meta", GoType: "map[string]string", Select: true, Update: true,
+			JSON: true, Nullable: true},
+	}, s.Columns)
+
+	s = Describe(C{})
+	assert.Len(t, s.JoinParts, 2)
+	assert.Equal(t, "a", s.Columns[0].Alias)
+	assert.Equal(t, "b", s.Columns[len(s.Columns)-1].Alias)
+
+	assert.Empty(t, Describe(1).Columns)
+}
+
+func Test_Validate(t *testing.T) {
+	assert.NoError(t, Validate(DescribedUser{}))
+	assert.NoError(t, Validate(&C{}))
+	assert.NoError(t, Validate(GenericDTO[int]{}))
+
+	err := Validate(InvalidDTO{})
+
+	var schemaErr *SchemaError
+	assert.True(t, errors.As(err, &schemaErr))
+	assert.Equal(t, "github.com/imperiuse/golib/reflect/orm.InvalidDTO", schemaErr.Type)
+	assert.Len(t, schemaErr.Problems, 6)
+	assert.ErrorIs(t, err, ErrDuplicateColumn)
+	assert.ErrorIs(t, err, ErrConflictingTags)
+	assert.ErrorIs(t, err, ErrNoTableName)
+	assert.Contains(t, err.Error(), `InvalidDTO.ID and Name: column "id"`)
+	assert.Contains(t, err.Error(), `InvalidDTO.ID and DDLRole.ID: column "id"`)
+	assert.Contains(t, err.Error(), `InvalidDTO.Name: unknown orm_use_in value "insert"`)
+	assert.Contains(t, err.Error(), "InvalidDTO.NoDB: orm_use_in without db column")
+	assert.Contains(t, err.Error(), "InvalidDTO.Role: relation field must have db:\"-\" tag")
+
+	assert.ErrorIs(t, Validate(nil), ErrConflictingTags)
+}
+
+func relationFields(rels []Relation) []string {
+	fields := make([]string, 0, len(rels))
+	for _, r := range rels {
+		fields = append(fields, r.Field)
+	}
+
+	return fields
+}
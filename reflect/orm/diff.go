@@ -0,0 +1,266 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Partial updates helpers, only columns with orm_use_in:"update" tag are used.
+//
+//	set := orm.Diff(loaded, modified)                    // only changed columns
+//	set, err := orm.GetDataForUpdateFields(dto, "Name")  // only listed fields (go field names or db columns)
+//
+// Nil pointer and not valid sql.Null* (any driver.Valuer with nil value) are NULL, such values are nil in result map,
+// so column is explicitly set to NULL.
+
+type (
+	// Snapshot - values of update columns of DTO at some moment (e.g. just after load), see TakeSnapshot.
+	Snapshot struct {
+		values map[Column]Argument // deep copies of driver values (pointers are dereferenced), for compare only
+	}
+
+	updateField struct {
+		name   string
+		column Column
+		value  reflect.Value
+		json   bool // orm_json column
+	}
+)
+
+var ErrUnknownUpdateField = errors.New("field is absent or has not orm_use_in:\"update\" tag")
+
+// Diff - update columns of modified DTO which values differ from old DTO (minimal SetMap for Update).
+func Diff(old, modified any) map[Column]Argument {
+	return TakeSnapshot(old).Diff(modified)
+}
+
+// TakeSnapshot - remember values of update columns of obj, obj can be changed in place after that
+// (values are deep copied, so changes of []byte, slices and maps of obj are found by Diff too).
+func TakeSnapshot(obj any) Snapshot {
+	s := Snapshot{values: map[Column]Argument{}}
+
+	for _, f := range getUpdateFields(obj) {
+		s.values[f.column] = deepCopy(driverValue(f))
+	}
+
+	return s
+}
+
+// Diff - update columns of obj which values differ from snapshot.
+func (s Snapshot) Diff(obj any) map[Column]Argument {
+	set := map[Column]Argument{}
+
+	for _, f := range getUpdateFields(obj) {
+		old, found := s.values[f.column]
+		if found && isEqualValues(old, driverValue(f)) {
+			continue
+		}
+
+		set[f.column] = setMapValue(f)
+	}
+
+	return set
+}
+
+// GetDataForUpdateFields - SetMap for update of listed fields only, field is go name of field or db column name.
+func GetDataForUpdateFields(obj any, fields ...string) (map[Column]Argument, error) {
+	all := getUpdateFields(obj)
+	set := make(map[Column]Argument, len(fields))
+
+	for _, name := range fields {
+		found := false
+
+		for _, f := range all {
+			if f.name == name || f.column == name {
+				set[f.column], found = setMapValue(f), true
+
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%s: %w", name, ErrUnknownUpdateField)
+		}
+	}
+
+	return set, nil
+}
+
+// getUpdateFields - fields with orm_use_in:"update" tag, walked the same way as in getMetaInfoUseInTag.
+func getUpdateFields(obj any) []updateField {
+	if obj == nil {
+		return nil
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return walkUpdateFields(v)
+}
+
+func walkUpdateFields(v reflect.Value) []updateField {
+	fields := []updateField{}
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if tagValue := field.Tag.Get(tagOrmUseIN); !isTagEmpty(tagValue) {
+			dbTagValue := columnName(t, field)
+			if strings.Contains(tagValue, ormUseInUpdate) && !isTagEmpty(dbTagValue) && field.IsExported() {
+				fields = append(fields, updateField{
+					name: field.Name, column: dbTagValue, value: v.Field(i), json: isJSONField(field),
+				})
+			}
+
+			continue
+		}
+
+		if fv, ok := nestedValue(field, v.Field(i)); ok && field.IsExported() && !isRelationField(field) {
+			fields = append(fields, walkUpdateFields(fv)...) // nil embedded pointer is skipped
+		}
+	}
+
+	return fields
+}
+
+// setMapValue - value of field for SetMap, NULL values are nil, orm_json values are wrapped by JSON.
+func setMapValue(f updateField) Argument {
+	if isNull(f.value) {
+		return nil
+	}
+
+	if f.json {
+		return JSON{V: f.value.Interface()}
+	}
+
+	return f.value.Interface()
+}
+
+// driverValue - value of field for compare: nil for NULL, driver.Value if it can be converted, value itself otherwise.
+func driverValue(f updateField) Argument {
+	v := setMapValue(f)
+	if v == nil {
+		return nil
+	}
+
+	if dv, err := driver.DefaultParameterConverter.ConvertValue(v); err == nil {
+		return dv
+	}
+
+	return v
+}
+
+// deepCopy - copy of value without shared arrays of slices, maps and pointers with the original one,
+// unexported fields of structs (e.g. time.Time) are copied as is, cyclic pointers stay cyclic in copy.
+func deepCopy(v Argument) Argument {
+	if v == nil {
+		return nil
+	}
+
+	return copyValue(reflect.ValueOf(v), map[uintptr]reflect.Value{}).Interface()
+}
+
+func copyValue(v reflect.Value, copied map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+
+		if c, found := copied[v.Pointer()]; found {
+			return c
+		}
+
+		c := reflect.New(v.Type().Elem())
+		copied[v.Pointer()] = c
+		c.Elem().Set(copyValue(v.Elem(), copied))
+
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i), copied))
+		}
+
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			c.SetMapIndex(iter.Key(), copyValue(iter.Value(), copied))
+		}
+
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.New(v.Type()).Elem()
+		c.Set(copyValue(v.Elem(), copied))
+
+		return c
+	case reflect.Array, reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+
+		if v.Kind() == reflect.Array {
+			for i := 0; i < v.Len(); i++ {
+				c.Index(i).Set(copyValue(v.Index(i), copied))
+			}
+
+			return c
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(copyValue(v.Field(i), copied))
+			}
+		}
+
+		return c
+	default:
+		return v
+	}
+}
+
+func isNull(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return true
+		}
+	}
+
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		if dv, err := valuer.Value(); err == nil && dv == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isEqualValues(a, b Argument) bool {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Equal(tb)
+		}
+	}
+
+	return reflect.DeepEqual(a, b)
+}
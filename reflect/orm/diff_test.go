@@ -0,0 +1,103 @@
+package orm
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type DiffProfile struct {
+	BaseDTO
+	Name     string         `db:"name"     orm_use_in:"select,create,update"`
+	Nick     sql.NullString `db:"nick"     orm_use_in:"select,update"`
+	Age      *int           `db:"age"      orm_use_in:"select,update"`
+	Password string         `db:"password" orm_use_in:"select,create"`
+	_        any            `orm_table_name:"profiles"`
+}
+
+func Test_Diff(t *testing.T) {
+	age, now := 30, time.Now()
+
+	loaded := DiffProfile{
+		BaseDTO: BaseDTO{ID: 1, UpdatedAt: now},
+		Name:    "bob",
+		Nick:    sql.NullString{String: "b", Valid: true},
+		Age:     &age,
+	}
+
+	modified := loaded
+	assert.Empty(t, Diff(loaded, &modified))
+
+	modified.UpdatedAt = now.UTC() // the same time
+	modified.Password = "new"      // not update column
+	modified.ID = 2                // not update column
+	assert.Empty(t, Diff(loaded, modified))
+
+	modified.Name = "alice"
+	modified.Nick = sql.NullString{}
+	assert.Equal(t, map[Column]Argument{"name": "alice", "nick": nil}, Diff(loaded, modified))
+
+	s := TakeSnapshot(&loaded)
+	age2 := 31
+	loaded.Age = &age2 // changed in place
+	assert.Equal(t, map[Column]Argument{"age": &age2}, s.Diff(loaded))
+
+	*loaded.Age = 30 // pointer value is compared, not pointer itself
+	assert.Empty(t, s.Diff(loaded))
+
+	loaded.Age = nil
+	assert.Equal(t, map[Column]Argument{"age": nil}, s.Diff(loaded))
+
+	assert.Len(t, TakeSnapshot(nil).Diff(loaded), 4) // all update columns: updated_at, name, nick, age
+}
+
+type DiffDocument struct {
+	ID     int64             `db:"id"     orm_use_in:"select"`
+	Data   []byte            `db:"data"   orm_use_in:"select,update"`
+	Tags   []string          `db:"tags"   orm_use_in:"select,update"`
+	Attrs  map[string]string `db:"attrs"  orm_use_in:"select,update"`
+	Parent *DiffDocument     `db:"parent" orm_use_in:"select,update"`
+	_      any               `orm_table_name:"documents"`
+}
+
+func Test_SnapshotMutatedInPlace(t *testing.T) {
+	doc := DiffDocument{
+		ID:    1,
+		Data:  []byte("abc"),
+		Tags:  []string{"a", "b"},
+		Attrs: map[string]string{"k": "v"},
+	}
+	doc.Parent = &doc // cyclic pointer is copied without infinite recursion
+
+	s := TakeSnapshot(&doc)
+	assert.Empty(t, s.Diff(doc))
+
+	doc.Data[0] = 'x'
+	doc.Tags[1] = "c"
+	doc.Attrs["k"] = "w"
+
+	set := s.Diff(doc)
+	assert.Equal(t, []byte("xbc"), set["data"])
+	assert.Equal(t, []string{"a", "c"}, set["tags"])
+	assert.Equal(t, map[string]string{"k": "w"}, set["attrs"])
+}
+
+func Test_GetDataForUpdateFields(t *testing.T) {
+	p := DiffProfile{Name: "bob", Nick: sql.NullString{String: "b", Valid: true}}
+
+	set, err := GetDataForUpdateFields(p, "Name", "nick", "Age")
+	assert.Nil(t, err)
+	assert.Equal(t, map[Column]Argument{"name": "bob", "nick": sql.NullString{String: "b", Valid: true}, "age": nil}, set)
+
+	set, err = GetDataForUpdateFields(&p)
+	assert.Nil(t, err)
+	assert.Empty(t, set)
+
+	_, err = GetDataForUpdateFields(p, "Password")
+	assert.ErrorIs(t, err, ErrUnknownUpdateField)
+
+	_, err = GetDataForUpdateFields(p, "unknown")
+	assert.ErrorIs(t, err, ErrUnknownUpdateField)
+}
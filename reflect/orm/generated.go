@@ -0,0 +1,97 @@
+package orm
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Methods generated by ormgen tool (see cmd/ormgen) are used instead of reflection, if DTO implements them:
+//
+//	//go:generate go run github.com/imperiuse/golib/reflect/orm/cmd/ormgen -type=User,Role
+//
+// GetDataForSelectOnlyCols, GetDataForCreate, GetDataForUpdate and GetScanTargets prefer generated methods.
+// Don't forget to re-run go generate after change of DTO tags. ormgen knows only db tags, so generated methods of DTO
+// with columns derived by naming strategy (see SetNamingStrategy) are ignored and reflection is used for it.
+
+type (
+	// Generated - DTO with generated methods. Methods are used only for type returned by OrmGeneratedFor,
+	// so methods promoted from embedded DTO to other DTO are ignored (reflection is used for such DTO).
+	Generated interface {
+		OrmGeneratedFor() any // zero value of DTO type for which methods are generated
+		Columns() []Column    // columns with orm_use_in:"select"
+		CreateArgs() ([]Column, []Argument)
+		UpdateMap() map[Column]Argument
+	}
+
+	// Scannable - pointer to DTO with generated ScanTargets method, pointers to fields of Columns() in the same order.
+	Scannable interface {
+		ScanTargets() []any
+	}
+)
+
+// GetScanTargets - pointers to fields of select columns (see GetDataForSelectOnlyCols) in the same order,
+// for rows.Scan, obj must be pointer to DTO (orm_json fields are wrapped by JSON).
+func GetScanTargets(obj any) []any {
+	if s, ok := obj.(Scannable); ok && isGeneratedFor(obj) {
+		return s.ScanTargets()
+	}
+
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return []any{}
+	}
+
+	return getScanTargets(v.Elem())
+}
+
+func getScanTargets(v reflect.Value) []any {
+	targets := []any{}
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if tagValue := field.Tag.Get(tagOrmUseIN); !isTagEmpty(tagValue) {
+			if strings.Contains(tagValue, ormUseInSelect) && !isTagEmpty(columnName(t, field)) && field.IsExported() {
+				if isJSONField(field) {
+					targets = append(targets, JSON{V: v.Field(i).Addr().Interface()})
+				} else {
+					targets = append(targets, v.Field(i).Addr().Interface())
+				}
+			}
+
+			continue
+		}
+
+		if !field.IsExported() || isRelationField(field) {
+			continue
+		}
+
+		if fv, ok := allocNested(field, v.Field(i)); ok { // nil embedded pointer is allocated
+			targets = append(targets, getScanTargets(fv)...)
+		}
+	}
+
+	return targets
+}
+
+func generated(obj any) (Generated, bool) {
+	g, ok := obj.(Generated)
+	if !ok || !isGeneratedFor(obj) {
+		return nil, false
+	}
+
+	return g, true
+}
+
+// isGeneratedFor - generated methods of obj are declared for type of obj (not promoted from embedded DTO).
+func isGeneratedFor(obj any) bool {
+	g, ok := obj.(interface{ OrmGeneratedFor() any })
+	if !ok {
+		return false
+	}
+
+	t := reflect.Indirect(reflect.ValueOf(obj)).Type()
+
+	return reflect.TypeOf(g.OrmGeneratedFor()) == t && !hasDerivedColumnsCached(t)
+}
@@ -0,0 +1,84 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	GenRole struct {
+		ID   int64  `db:"id"   orm_use_in:"select"`
+		Name string `db:"name" orm_use_in:"select,create,update"`
+	}
+
+	GenAdmin struct {
+		GenRole
+		Level int `db:"level" orm_use_in:"select,create"`
+	}
+
+	GenNamed struct {
+		ID       int64  `db:"id" orm_use_in:"select"`
+		UserName string `orm_use_in:"select,create"` // skipped by ormgen
+	}
+)
+
+func (GenNamed) OrmGeneratedFor() any { return GenNamed{} }
+func (GenNamed) Columns() []Column    { return []Column{"id"} }
+
+func (GenNamed) CreateArgs() ([]Column, []Argument) { return []Column{}, []Argument{} }
+func (GenNamed) UpdateMap() map[Column]Argument     { return map[Column]Argument{} }
+
+func (n *GenNamed) ScanTargets() []any { return []any{&n.ID} }
+
+func (GenRole) OrmGeneratedFor() any { return GenRole{} }
+func (GenRole) Columns() []Column    { return []Column{"generated"} }
+
+func (r GenRole) CreateArgs() ([]Column, []Argument) {
+	return []Column{"generated"}, []Argument{r.Name}
+}
+
+func (r GenRole) UpdateMap() map[Column]Argument {
+	return map[Column]Argument{"generated": r.Name}
+}
+
+func (r *GenRole) ScanTargets() []any { return []any{&r.Name} }
+
+func Test_Generated(t *testing.T) {
+	r := GenRole{ID: 1, Name: "admin"}
+
+	cols, args := GetDataForCreate(r)
+	assert.Equal(t, []Column{"generated"}, cols)
+	assert.Equal(t, []Argument{"admin"}, args)
+	assert.Equal(t, map[Column]Argument{"generated": "admin"}, GetDataForUpdate(&r))
+	assert.Equal(t, []Column{"generated"}, GetDataForSelectOnlyCols(r))
+	assert.Equal(t, []any{&r.Name}, GetScanTargets(&r))
+
+	// methods promoted from embedded DTO are ignored
+	a := GenAdmin{GenRole: r, Level: 3}
+
+	cols, args = GetDataForCreate(&a)
+	assert.Equal(t, []Column{"name", "level"}, cols)
+	assert.Equal(t, []Argument{"admin", 3}, args)
+	assert.Equal(t, map[Column]Argument{"name": "admin"}, GetDataForUpdate(&a))
+	assert.Equal(t, []any{&a.ID, &a.Name, &a.Level}, GetScanTargets(&a))
+	assert.Empty(t, GetScanTargets(a)) // not pointer
+}
+
+func Test_GeneratedWithNamingStrategy(t *testing.T) {
+	n := GenNamed{ID: 1, UserName: "bob"}
+	assert.Equal(t, []Column{"id"}, GetDataForSelectOnlyCols(n)) // generated methods without strategy
+
+	SetNamingStrategy(SnakeCase{})
+	t.Cleanup(func() { SetNamingStrategy(nil) })
+
+	// user_name is derived by strategy, generated methods know only db tags, so reflection is used
+	assert.Equal(t, []Column{"id", "user_name"}, GetDataForSelectOnlyCols(n))
+	cols, args := GetDataForCreate(&n)
+	assert.Equal(t, []Column{"user_name"}, cols)
+	assert.Equal(t, []Argument{"bob"}, args)
+	assert.Equal(t, []any{&n.ID, &n.UserName}, GetScanTargets(&n))
+
+	// DTO with db tags only keeps generated methods
+	assert.Equal(t, []Column{"generated"}, GetDataForSelectOnlyCols(GenRole{}))
+}
@@ -0,0 +1,202 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// JSON columns are declared by orm_json tag, value of field is marshaled on write and unmarshaled on read:
+//
+//	Meta map[string]any `db:"meta" orm_use_in:"select,create,update" orm_json:"true"`
+//
+// Nil pointer, map or slice is NULL. DDL type of such column is JSONB (postgres), JSON (mysql) or TEXT (sqlite).
+// sqlx can't scan JSON to not sql.Scanner types, so DTO with JSON columns must be scanned by
+// GetScanTargetsByColumns (repositories of db package do it automatically, see HasJSONColumns).
+
+type (
+	// JSON - argument of orm_json column (driver.Valuer, V is value of field) and scan target of it
+	// (sql.Scanner, V is pointer to field).
+	JSON struct {
+		V any
+	}
+)
+
+const tagOrmJSON = "orm_json"
+
+var (
+	ErrMissingScanTarget = errors.New("missing destination field of DTO for column")
+
+	jsonColumnsCache sync.Map // reflect.Type -> bool
+)
+
+// Value - implementation of driver.Valuer, JSON string or nil for NULL.
+func (j JSON) Value() (driver.Value, error) {
+	if isNilValue(reflect.ValueOf(j.V)) {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, fmt.Errorf("orm.JSON: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// Scan - implementation of sql.Scanner, NULL sets zero value.
+func (j JSON) Scan(src any) error {
+	v := reflect.ValueOf(j.V)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("orm.JSON: pointer expected, got %T", j.V)
+	}
+
+	v.Elem().Set(reflect.Zero(v.Elem().Type())) // json.Unmarshal merges maps, so old value is reset
+
+	switch s := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(s), j.V)
+	case []byte:
+		return json.Unmarshal(s, j.V)
+	default:
+		return fmt.Errorf("orm.JSON: unsupported source type %T", src)
+	}
+}
+
+// HasJSONColumns - DTO (or pointer to DTO, slice of DTO) has orm_json columns.
+func HasJSONColumns(obj any) bool {
+	t := elemStructType(obj)
+	if t == nil {
+		return false
+	}
+
+	if has, found := jsonColumnsCache.Load(t); found {
+		return has.(bool)
+	}
+
+	has := hasJSONColumns(t)
+	jsonColumnsCache.Store(t, has)
+
+	return has
+}
+
+func hasJSONColumns(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if isJSONField(field) {
+			return true
+		}
+
+		ft, ok := nestedField(field)
+		if ok && field.IsExported() && !isRelationField(field) && hasJSONColumns(ft) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetScanTargetsByColumns - pointers to fields of DTO for rows.Scan by names of result columns (rows.Columns()),
+// orm_json fields are wrapped by JSON, nil embedded pointer structs are allocated, obj must be pointer to DTO.
+func GetScanTargetsByColumns(obj any, columns []Column) ([]any, error) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("orm: pointer to struct expected, got %T", obj)
+	}
+
+	byColumn := map[Column]any{}
+	collectScanTargets(v.Elem(), "", byColumn)
+
+	targets := make([]any, 0, len(columns))
+
+	for _, c := range columns {
+		target, found := byColumn[c]
+		if !found {
+			return nil, fmt.Errorf("%s: %w", c, ErrMissingScanTarget)
+		}
+
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// collectScanTargets - the same mapping as sqlx does: embedded structs without db tag are flattened, columns of
+// nested structs with db tag are prefixed by it (columns of composite DTO, e.g. "u.name" for User `db:"u"`).
+func collectScanTargets(v reflect.Value, prefix string, byColumn map[Column]any) {
+	t := v.Type()
+	nested := []int{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || isRelationField(field) {
+			continue
+		}
+
+		dbTagValue := columnName(t, field)
+
+		if _, ok := nestedField(field); ok && isTagEmpty(field.Tag.Get(tagOrmUseIN)) {
+			if (field.Anonymous && dbTagValue == "") || !isTagEmpty(dbTagValue) {
+				nested = append(nested, i)
+			}
+
+			continue
+		}
+
+		if isTagEmpty(dbTagValue) {
+			continue
+		}
+
+		if _, found := byColumn[prefix+dbTagValue]; found {
+			continue
+		}
+
+		if isJSONField(field) {
+			byColumn[prefix+dbTagValue] = JSON{V: v.Field(i).Addr().Interface()}
+		} else {
+			byColumn[prefix+dbTagValue] = v.Field(i).Addr().Interface()
+		}
+	}
+
+	// fields of upper level win over fields of embedded structs
+	for _, i := range nested {
+		nestedPrefix := prefix
+		if dbTagValue := t.Field(i).Tag.Get(tagDB); dbTagValue != "" {
+			nestedPrefix = prefix + dbTagValue + "."
+		}
+
+		if fv, ok := allocNested(t.Field(i), v.Field(i)); ok {
+			collectScanTargets(fv, nestedPrefix, byColumn)
+		}
+	}
+}
+
+func isJSONField(field reflect.StructField) bool {
+	return isTagTrue(field.Tag.Get(tagOrmJSON))
+}
+
+// columnArg - argument of column for create and update, orm_json field is wrapped by JSON.
+func columnArg(field reflect.StructField, v reflect.Value) Argument {
+	if isJSONField(field) {
+		return JSON{V: v.Interface()}
+	}
+
+	return v.Interface()
+}
+
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,162 @@
+package orm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	JSONBase struct {
+		ID        int64     `db:"id"         orm_use_in:"select" orm_pk:"identity"`
+		UpdatedAt time.Time `db:"updated_at" orm_use_in:"select,update"`
+	}
+
+	// Money - Valuer struct with tagged fields, it is one column (leaf), fields are not walked.
+	Money struct {
+		Amount   int64  `db:"amount"   orm_use_in:"select,create"`
+		Currency string `db:"currency" orm_use_in:"select,create"`
+	}
+
+	JSONEvent struct {
+		*JSONBase
+		Kind    string            `db:"kind"    orm_use_in:"select,create,update"`
+		Payload map[string]any    `db:"payload" orm_use_in:"select,create,update" orm_json:"true"`
+		Labels  *[]string         `db:"labels"  orm_use_in:"select,create" orm_json:"true"`
+		Price   Money             `db:"price"   orm_use_in:"select,create,update" orm_type:"TEXT"`
+		Total   Money             // not column, leaf type is not walked
+		Nick    sql.NullString    `db:"nick"    orm_use_in:"select,create"`
+		Extra   map[string]string `db:"extra"`
+		_       any               `orm_table_name:"events"`
+	}
+)
+
+// Node - self reference by pointer field (not embedded), it is not walked.
+type Node struct {
+	ID   int `db:"id" orm_use_in:"select,create"`
+	Next *Node
+}
+
+func (m Money) Value() (driver.Value, error) { return m.Currency, nil }
+
+func Test_JSONValueScan(t *testing.T) {
+	v, err := JSON{V: map[string]int{"a": 1}}.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":1}`, v)
+
+	v, err = JSON{V: []string(nil)}.Value()
+	assert.Nil(t, err)
+	assert.Nil(t, v)
+
+	_, err = JSON{V: func() {}}.Value()
+	assert.NotNil(t, err)
+
+	m := map[string]int{"old": 1}
+	assert.Nil(t, JSON{V: &m}.Scan([]byte(`{"b":2}`)))
+	assert.Equal(t, map[string]int{"b": 2}, m)
+	assert.Nil(t, JSON{V: &m}.Scan(`{"c":3}`))
+	assert.Equal(t, map[string]int{"c": 3}, m)
+	assert.Nil(t, JSON{V: &m}.Scan(nil))
+	assert.Nil(t, m)
+
+	assert.NotNil(t, JSON{V: &m}.Scan(1))
+	assert.NotNil(t, JSON{V: m}.Scan(`{}`)) // not pointer
+}
+
+func Test_PointerEmbedAndLeafs(t *testing.T) {
+	e := JSONEvent{Kind: "k", Payload: map[string]any{"a": 1}, Price: Money{Currency: "EUR"}}
+
+	cols, args := GetDataForCreate(&e) // nil embedded pointer: nothing to create
+	assert.Equal(t, []Column{"kind", "payload", "labels", "price", "nick"}, cols)
+	assert.Equal(t, []Argument{"k", JSON{V: e.Payload}, JSON{V: e.Labels}, e.Price, e.Nick}, args)
+	assert.Equal(t, []Column{"kind", "payload", "price"}, sortedKeys(GetDataForUpdate(&e)))
+
+	// select columns are derived from type
+	assert.Equal(t, []Column{"id", "updated_at", "kind", "payload", "labels", "price", "nick"},
+		GetDataForSelectOnlyCols(&JSONEvent{}))
+
+	e.JSONBase = &JSONBase{ID: 5}
+	assert.Equal(t, []Column{"kind", "payload", "price", "updated_at"}, sortedKeys(GetDataForUpdate(&e)))
+	assert.Equal(t, []Column{"id"}, GetPrimaryKeyColumns(JSONEvent{}))
+
+	v, found := GetColumnValue(reflect.ValueOf(JSONEvent{}), "id") // zero value of nil embedded pointer
+	assert.True(t, found)
+	assert.Equal(t, int64(0), v.Interface())
+
+	assert.Equal(t, map[Column]Argument{"payload": JSON{V: map[string]any{"b": 2}}},
+		Diff(e, JSONEvent{JSONBase: e.JSONBase, Kind: "k", Payload: map[string]any{"b": 2}, Price: e.Price}))
+}
+
+func Test_ScanTargets(t *testing.T) {
+	assert.True(t, HasJSONColumns(&[]*JSONEvent{}))
+	assert.False(t, HasJSONColumns([]JSONBase{}))
+	assert.False(t, HasJSONColumns(nil))
+	assert.False(t, HasJSONColumns(1))
+
+	e := JSONEvent{}
+	targets, err := GetScanTargetsByColumns(&e, []Column{"payload", "id", "extra", "kind"})
+	assert.Nil(t, err)
+	assert.NotNil(t, e.JSONBase) // allocated
+	assert.Equal(t, []any{JSON{V: &e.Payload}, &e.ID, &e.Extra, &e.Kind}, targets)
+
+	_, err = GetScanTargetsByColumns(&e, []Column{"unknown"})
+	assert.ErrorIs(t, err, ErrMissingScanTarget)
+
+	_, err = GetScanTargetsByColumns(e, []Column{"id"})
+	assert.NotNil(t, err)
+
+	e = JSONEvent{}
+	targets = GetScanTargets(&e)
+	assert.Equal(t,
+		[]any{&e.JSONBase.ID, &e.JSONBase.UpdatedAt, &e.Kind, JSON{V: &e.Payload}, JSON{V: &e.Labels}, &e.Price, &e.Nick},
+		targets)
+}
+
+func Test_SelfReference(t *testing.T) {
+	n := Node{ID: 1, Next: &Node{ID: 2}}
+
+	assert.Equal(t, []Column{"id"}, GetDataForSelectOnlyCols(&Node{}))
+	assert.Equal(t, []Column{"id"}, GetDataForSelectOnlyCols(&n))
+	assert.Equal(t, []any{&n.ID}, GetScanTargets(&n))
+	assert.False(t, HasJSONColumns(&n))
+
+	targets, err := GetScanTargetsByColumns(&n, []Column{"id"})
+	assert.Nil(t, err)
+	assert.Equal(t, []any{&n.ID}, targets)
+	assert.Nil(t, n.Next.Next)
+
+	cols, args := GetDataForCreate(&n)
+	assert.Equal(t, []Column{"id"}, cols)
+	assert.Equal(t, []Argument{1}, args)
+}
+
+func Test_JSONColumnDefs(t *testing.T) {
+	ddl, err := GetCreateTableDDL(JSONEvent{}, DialectPostgres)
+	assert.Nil(t, err)
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS events
+(
+id BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+updated_at TIMESTAMP NOT NULL,
+kind TEXT NOT NULL,
+payload JSONB,
+labels JSONB,
+price TEXT NOT NULL,
+nick TEXT
+);`, ddl)
+}
+
+func sortedKeys(m map[Column]Argument) []Column {
+	keys := make([]Column, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,205 @@
+package orm
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/imperiuse/golib/strcase"
+)
+
+// By default every column must be declared by db tag and table by orm_table_name tag. Naming strategy (opt-in)
+// derives them from names of go field and struct, so orm_use_in tag is enough:
+//
+//	orm.SetNamingStrategy(orm.SnakeCase{}) // UserName -> user_name, strcase.ConfigureAcronym is respected
+//
+//	type UserProfile struct {
+//		UserName string `orm_use_in:"select,create"`            // user_name
+//		Email    string `db:"mail" orm_use_in:"select,create"`  // db tag wins
+//		_        any    `orm_naming:"-"`                        // per DTO: "-" disables strategy, "snake" enables
+//	}
+//
+// Table of UserProfile is user_profile (orm_table_name wins). Override of orm_naming tag works for fields declared
+// by the struct itself (embedded structs have their own orm_naming tag). DTO with derived columns are scanned by
+// GetScanTargetsByColumns (see NeedsScanTargets), because sqlx knows only db tags. ormgen uses only db tags too,
+// so generated methods of DTO with derived columns are ignored and reflection is used for it.
+
+type (
+	// NamingStrategy - names of columns and tables for fields and structs without db and orm_table_name tags.
+	NamingStrategy interface {
+		ColumnName(field string) Column
+		TableName(structName string) Table
+	}
+
+	// SnakeCase - naming strategy by strcase.ToSnakeWithAcronyms (acronyms of strcase.ConfigureAcronym are words).
+	SnakeCase struct{}
+)
+
+const (
+	tagOrmNaming = "orm_naming"
+
+	namingSnake = "snake"
+)
+
+var (
+	namingMu       sync.RWMutex
+	namingStrategy NamingStrategy // nil - only tags
+
+	namingOverrideCache sync.Map // reflect.Type -> string (value of orm_naming tag)
+	scanTargetsCache    sync.Map // reflect.Type -> bool
+	derivedCache        sync.Map // reflect.Type -> bool
+)
+
+func (SnakeCase) ColumnName(field string) Column {
+	return strcase.ToSnakeWithAcronyms(field)
+}
+
+func (SnakeCase) TableName(structName string) Table {
+	return strcase.ToSnakeWithAcronyms(structName)
+}
+
+// SetNamingStrategy - set global naming strategy (nil - only tags, default), cached metadata of DTO is reset,
+// so call it on init before usage of DTO (and after strcase.ConfigureAcronym).
+func SetNamingStrategy(n NamingStrategy) {
+	namingMu.Lock()
+	namingStrategy = n
+	namingMu.Unlock()
+
+	m.Lock()
+	cacheMetaDTO = map[reflect.Type]*MetaDTO{}
+	m.Unlock()
+
+	for _, cache := range []*sync.Map{&scanTargetsCache, &derivedCache} {
+		cache.Range(func(key, _ any) bool {
+			cache.Delete(key)
+			return true
+		})
+	}
+}
+
+// NeedsScanTargets - DTO (or pointer to DTO, slice of DTO) can't be scanned by sqlx: it has orm_json columns
+// or columns derived by naming strategy, use GetScanTargetsByColumns for it.
+func NeedsScanTargets(obj any) bool {
+	t := elemStructType(obj)
+	if t == nil {
+		return false
+	}
+
+	if needs, found := scanTargetsCache.Load(t); found {
+		return needs.(bool)
+	}
+
+	needs := HasJSONColumns(obj) || hasDerivedColumnsCached(t)
+	scanTargetsCache.Store(t, needs)
+
+	return needs
+}
+
+func hasDerivedColumnsCached(t reflect.Type) bool {
+	if derived, found := derivedCache.Load(t); found {
+		return derived.(bool)
+	}
+
+	derived := hasDerivedColumns(t)
+	derivedCache.Store(t, derived)
+
+	return derived
+}
+
+func hasDerivedColumns(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if column := columnName(t, field); !isTagEmpty(column) && field.Tag.Get(tagDB) == "" {
+			return true
+		}
+
+		ft, ok := nestedField(field)
+		if ok && field.IsExported() && !isRelationField(field) && hasDerivedColumns(ft) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// columnName - db column of field declared by struct t: db tag or name derived by naming strategy (only for fields
+// with orm_use_in tag), empty string (or "-") if field is not column.
+func columnName(t reflect.Type, field reflect.StructField) Column {
+	if dbTagValue := field.Tag.Get(tagDB); dbTagValue != "" {
+		return dbTagValue
+	}
+
+	if field.Name == underscored || !field.IsExported() || isTagEmpty(field.Tag.Get(tagOrmUseIN)) {
+		return ""
+	}
+
+	if n := namingOf(t); n != nil {
+		return n.ColumnName(field.Name)
+	}
+
+	return ""
+}
+
+// tableName - orm_table_name of struct t or name derived by naming strategy.
+func tableName(t reflect.Type) Table {
+	if t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	if table := getMetaInfoForOrmTagOnlyOne(tagOrmTableName, reflect.New(t).Interface()); !isTagEmpty(table) {
+		return table
+	}
+
+	if n := namingOf(t); n != nil && t.Name() != "" {
+		name, _, _ := strings.Cut(t.Name(), "[") // type arguments of generic DTO
+		return n.TableName(name)
+	}
+
+	return ""
+}
+
+// namingOf - naming strategy of struct t: orm_naming tag of `_` field or global one.
+func namingOf(t reflect.Type) NamingStrategy {
+	override, found := namingOverrideCache.Load(t)
+	if !found {
+		override = ""
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.Name == underscored && f.Tag.Get(tagOrmNaming) != "" {
+				override = f.Tag.Get(tagOrmNaming) // "-" is value too, so not getMetaInfoForOrmTagOnlyOne
+				break
+			}
+		}
+
+		namingOverrideCache.Store(t, override)
+	}
+
+	switch override {
+	case "-":
+		return nil
+	case namingSnake:
+		return SnakeCase{}
+	}
+
+	namingMu.RLock()
+	defer namingMu.RUnlock()
+
+	return namingStrategy
+}
+
+func elemStructType(obj any) reflect.Type {
+	if obj == nil {
+		return nil
+	}
+
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return t
+}
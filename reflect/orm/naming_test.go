@@ -0,0 +1,78 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	NamedUser struct {
+		ID       int64  `orm_use_in:"select" orm_pk:"identity"`
+		UserName string `orm_use_in:"select,create,update"`
+		APIKey   string `orm_use_in:"create"`
+		Email    string `db:"mail" orm_use_in:"select,create"`
+		Comment  string // not column without orm_use_in
+	}
+
+	NamedRole struct {
+		ID    int64  `orm_use_in:"select"`
+		Title string `orm_use_in:"select,create"`
+		_     any    `orm_table_name:"roles"`
+	}
+
+	NamedUserRole struct {
+		NamedUser `db:"u" orm_alias:"u"`
+		NamedRole `db:"r" orm_alias:"r" orm_join:"u.id = r.id"`
+	}
+
+	NotNamed struct {
+		UserName string `orm_use_in:"select"`
+		_        any    `orm_naming:"-" orm_table_name:"not_named"`
+	}
+
+	SnakeNamed struct {
+		UserName string `orm_use_in:"select"`
+		_        any    `orm_naming:"snake"`
+	}
+)
+
+func Test_NamingStrategy(t *testing.T) {
+	assert.Equal(t, []Column{"mail"}, GetDataForSelectOnlyCols(NamedUser{})) // only db tags by default
+	assert.Equal(t, "", GetTableName(NamedUser{}))
+	assert.False(t, NeedsScanTargets(&NamedUser{}))
+	assert.Equal(t, []Column{"user_name"}, GetDataForSelectOnlyCols(SnakeNamed{})) // orm_naming tag of DTO
+	assert.Equal(t, "snake_named", GetTableName(SnakeNamed{}))
+
+	SetNamingStrategy(SnakeCase{})
+	t.Cleanup(func() { SetNamingStrategy(nil) })
+
+	assert.Equal(t, "named_user", GetTableName(NamedUser{}))
+	assert.Equal(t, "roles", GetTableName(NamedRole{}))
+	assert.Equal(t, []Column{"id", "user_name", "mail"}, GetDataForSelectOnlyCols(NamedUser{}))
+
+	cols, args := GetDataForCreate(&NamedUser{UserName: "bob", APIKey: "k", Email: "b@x"})
+	assert.Equal(t, []Column{"user_name", "api_key", "mail"}, cols)
+	assert.Equal(t, []Argument{"bob", "k", "b@x"}, args)
+	assert.Equal(t, map[Column]Argument{"user_name": "bob"}, GetDataForUpdate(&NamedUser{UserName: "bob"}))
+	assert.Equal(t, []Column{"id"}, GetPrimaryKeyColumns(NamedUser{}))
+	assert.NoError(t, Validate(NamedUser{}))
+
+	assert.Empty(t, GetDataForSelectOnlyCols(NotNamed{}))
+	assert.Equal(t, "not_named", GetTableName(NotNamed{}))
+
+	// composite DTO: select aliases and scan targets use the same derived names
+	cols, _ = GetDataForSelect(NamedUserRole{})
+	assert.Equal(t, []Column{
+		`u.id as "u.id"`, `u.user_name as "u.user_name"`, `u.mail as "u.mail"`,
+		`r.id as "r.id"`, `r.title as "r.title"`,
+	}, cols)
+	assert.Equal(t, "", GetTableName(NamedUserRole{}))
+
+	ur := NamedUserRole{}
+	targets, err := GetScanTargetsByColumns(&ur, []Column{"u.user_name", "r.title", "u.mail"})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{&ur.UserName, &ur.Title, &ur.Email}, targets)
+	assert.True(t, NeedsScanTargets(&[]NamedUserRole{}))
+	assert.False(t, NeedsScanTargets(NotNamed{}))
+}
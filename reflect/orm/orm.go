@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"strings"
@@ -23,28 +24,44 @@ type (
 	Table    = string
 	Typ      = string
 	JoinCond = string
+	JoinType = string
 	Alias    = string
 	Argument = any
 
-	structName       = string
 	ormUseInTagValue = string
 
 	MetaDTO = struct {
 		ColsMap    map[ormUseInTagValue][]Column
 		JoinCond   JoinCond
+		JoinParts  []JoinPart
+		JoinErr    error // invalid join part of composite DTO (see GetJoinParts)
 		TableName  Table
 		TableAlias Alias
 		StructName Typ
 	}
+
+	// JoinPart - one table of composite (join) DTO, first part is root (FROM) table, other parts are joined to it.
+	JoinPart = struct {
+		Table  Table
+		Alias  Alias
+		Type   JoinType // empty for root part
+		Cond   JoinCond // ON ... or USING (...) part of join, empty for root part
+		Tenant Column   // orm_tenant column of part, empty if part is not scoped by tenant
+	}
 )
 
 const (
 	Undefined = ""
+
+	JoinInner JoinType = "INNER"
+	JoinLeft  JoinType = "LEFT"
+	JoinRight JoinType = "RIGHT"
+	JoinFull  JoinType = "FULL"
 )
 
 var (
 	m            sync.RWMutex
-	cacheMetaDTO = map[structName]*MetaDTO{}
+	cacheMetaDTO = map[reflect.Type]*MetaDTO{} // key is full type identity (package path, type arguments)
 )
 
 // custom tag for "sugar" columns values prepare for Update squirrel library staff
@@ -55,6 +72,7 @@ const (
 	underscored     = "_" // special name fo field contains tag orn_tab_name, orm_alias, orm_join
 	tagOrmAlias     = "orm_alias"
 	tagOrmJoin      = "orm_join"
+	tagOrmJoinType  = "orm_join_type" // inner (default), left, right, full
 	tagOrmTableName = "orm_table_name"
 
 	ormUseInSelect = "select"
@@ -72,29 +90,60 @@ func InitMetaTagInfoCache(objs ...any) {
 		if obj == nil {
 			continue
 		}
-		cacheMetaDTO[getObjTypeNameByReflect(obj)] = getNoneCacheMetaDTO(obj)
+		key := metaKey(obj)
+		cacheMetaDTO[key] = newMetaDTO(key, obj)
 	}
 }
 
 func GetMetaDTO(obj any) *MetaDTO {
-	return getMetaDTO(getObjTypeNameByReflect(obj), obj)
+	return getMetaDTO(metaKey(obj), obj)
 }
 
-func getMetaDTO(structName string, obj any) *MetaDTO {
+func getMetaDTO(key reflect.Type, obj any) *MetaDTO {
 	m.RLock()
-	if _, found := cacheMetaDTO[structName]; !found {
-		m.RUnlock()
-		m.Lock()
-		defer m.Unlock()
-		cacheMetaDTO[structName] = getNoneCacheMetaDTO(obj)
-	} else {
-		m.RUnlock()
+	meta, found := cacheMetaDTO[key]
+	m.RUnlock()
+
+	if found {
+		return meta
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if meta, found = cacheMetaDTO[key]; !found {
+		meta = newMetaDTO(key, obj)
+		cacheMetaDTO[key] = meta
+	}
+
+	return meta
+}
+
+// newMetaDTO - metadata depends only on type, so addressable zero value of struct is walked (not obj itself).
+func newMetaDTO(key reflect.Type, obj any) *MetaDTO {
+	if key != nil && key.Kind() == reflect.Struct {
+		obj = reflect.New(key).Interface()
 	}
 
-	return cacheMetaDTO[structName]
+	return getNoneCacheMetaDTO(obj)
+}
+
+// metaKey - key of cacheMetaDTO: type of obj (pointer is dereferenced), so dto.User[int] and dto.User[string]
+// or two User types of different packages have own metadata.
+func metaKey(obj any) reflect.Type {
+	t := reflect.TypeOf(obj)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
 }
 
 func GetDataForSelectOnlyCols(obj any) []Column {
+	if g, ok := generated(obj); ok {
+		return g.Columns()
+	}
+
 	meta := GetMetaDTO(obj)
 	return meta.ColsMap[ormUseInSelect]
 }
@@ -105,11 +154,19 @@ func GetDataForSelect(obj any) ([]Column, JoinCond) {
 }
 
 func GetDataForCreate(obj any) ([]Column, []Argument) {
+	if g, ok := generated(obj); ok {
+		return g.CreateArgs()
+	}
+
 	cols, args := getMetaInfoUseInTag(obj, ormUseInCreate, emptyRootAlias)
 	return cols, args
 }
 
 func GetDataForUpdate(obj any) map[Column]Argument {
+	if g, ok := generated(obj); ok {
+		return g.UpdateMap()
+	}
+
 	cols, args := getMetaInfoUseInTag(obj, ormUseInUpdate, emptyRootAlias)
 
 	cv := make(map[Column]Argument, len(cols))
@@ -119,6 +176,16 @@ func GetDataForUpdate(obj any) map[Column]Argument {
 	return cv
 }
 
+// GetJoinParts - return tables of composite (join) DTO in order of declaration.
+// Composite DTO is struct which contains struct fields (usually embedded) with orm_alias tag and orm_table_name inside.
+// Join condition and type are taken from orm_join and orm_join_type tags of the field,
+// for the first joined table condition may be also declared by orm_join tag on `_` field (see GetDataForSelect).
+// Joined table without condition => ErrNoJoinCond.
+func GetJoinParts(obj any) ([]JoinPart, error) {
+	meta := GetMetaDTO(obj)
+	return meta.JoinParts, meta.JoinErr
+}
+
 // GetTableName - return table name
 func GetTableName(obj any) Table {
 	meta := GetMetaDTO(obj)
@@ -169,6 +236,12 @@ func getNoneCacheMetaDTO(obj any) *MetaDTO {
 
 	meta.TableAlias = getMetaInfoForOrmTagOnlyOne(tagOrmAlias, obj)
 
+	meta.JoinParts, meta.JoinErr = getJoinParts(obj, meta.JoinCond)
+
+	if meta.TableName == "" && len(meta.JoinParts) == 0 {
+		meta.TableName = tableName(reflect.Indirect(reflect.ValueOf(obj)).Type()) // naming strategy, see naming.go
+	}
+
 	for _, v := range []string{ormUseInSelect, ormUseInCreate, ormUseInUpdate} {
 		meta.ColsMap[v], _ = getMetaInfoUseInTag(obj, v, emptyRootAlias)
 	}
@@ -202,10 +275,71 @@ func getMetaInfoForOrmTagOnlyOne(value ormUseInTagValue, obj interface{}) string
 	return ""
 }
 
+func getJoinParts(obj any, rootJoinCond JoinCond) ([]JoinPart, error) {
+	parts := []JoinPart{}
+
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	t := v.Type()
+
+	if t.Kind() != reflect.Struct {
+		return parts, nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Type.Kind() != reflect.Struct || field.Name == underscored || isRelationField(field) {
+			continue
+		}
+
+		fieldObj := reflect.New(field.Type).Interface() // only tags are needed, also safe for unexported fields
+
+		table := tableName(field.Type)
+		if isTagEmpty(table) {
+			continue
+		}
+
+		alias := field.Tag.Get(tagOrmAlias)
+		if isTagEmpty(alias) {
+			if alias = getMetaInfoForOrmTagOnlyOne(tagOrmAlias, fieldObj); alias == "" {
+				continue // without alias columns can't be mapped to nested struct
+			}
+		}
+
+		part := JoinPart{Table: table, Alias: alias, Tenant: tenantColumn(field.Type)}
+		if len(parts) > 0 {
+			part.Type = JoinInner
+			if jt := field.Tag.Get(tagOrmJoinType); !isTagEmpty(jt) {
+				part.Type = strings.ToUpper(strings.TrimSpace(jt))
+			}
+
+			part.Cond = strings.TrimSpace(field.Tag.Get(tagOrmJoin))
+			if part.Cond == "" && len(parts) == 1 {
+				part.Cond = strings.TrimSpace(rootJoinCond)
+			}
+
+			if isTagEmpty(part.Cond) {
+				return parts, fmt.Errorf("%s.%s: %w", t.Name(), field.Name, ErrNoJoinCond)
+			}
+
+			if up := strings.ToUpper(part.Cond); part.Cond != "" &&
+				!strings.HasPrefix(up, "ON ") && !strings.HasPrefix(up, "USING") {
+				part.Cond = "ON " + part.Cond
+			}
+		}
+
+		parts = append(parts, part)
+	}
+
+	return parts, nil
+}
+
 func isTagEmpty(tag string) bool {
 	return tag == "" || tag == "-"
 }
 
+// getMetaInfoUseInTag - columns and values of fields with orm_use_in tag, nested structs are walked (embedded pointer
+// too: nil pointer gives only select columns), leaf types (time.Time, driver.Valuer, sql.Scanner) are not walked.
 func getMetaInfoUseInTag(obj interface{}, useInTag ormUseInTagValue, alias Alias) ([]Column, []Argument) {
 	cols, args := []Column{}, []Argument{}
 
@@ -228,7 +362,7 @@ func getMetaInfoUseInTag(obj interface{}, useInTag ormUseInTagValue, alias Alias
 				continue
 			}
 
-			dbTagValue := field.Tag.Get(tagDB)
+			dbTagValue := columnName(t, field)
 			if isTagEmpty(dbTagValue) {
 				continue
 			}
@@ -239,18 +373,28 @@ func getMetaInfoUseInTag(obj interface{}, useInTag ormUseInTagValue, alias Alias
 				colValue = fmt.Sprintf("%s as \"%s\"", colValue, colValue)
 			}
 
-			cols, args = append(cols, colValue), append(args, v.Field(i).Interface())
+			cols, args = append(cols, colValue), append(args, columnArg(field, v.Field(i)))
 			continue
 		}
 
-		if v.Field(i).Kind() == reflect.Struct {
+		if _, ok := nestedField(field); ok && !isRelationField(field) {
 			if aliasTagValue := field.Tag.Get(tagOrmAlias); !isTagEmpty(aliasTagValue) {
 				alias = field.Tag.Get(tagOrmAlias)
 			}
 
 			c, a := []Column{}, []Argument{}
-			if v.Field(i).CanAddr() && v.Field(i).Addr().CanInterface() {
-				c, a = getMetaInfoUseInTag(v.Field(i).Addr().Interface(), useInTag, alias)
+			fv := v.Field(i)
+
+			switch {
+			case fv.Kind() == reflect.Pointer && fv.IsNil():
+				// nil embedded pointer: columns are known for select, nothing to create or update
+				if useInTag == ormUseInSelect {
+					c, _ = getMetaInfoUseInTag(reflect.New(fv.Type().Elem()).Interface(), useInTag, alias)
+				}
+			case fv.Kind() == reflect.Pointer && fv.CanInterface():
+				c, a = getMetaInfoUseInTag(fv.Interface(), useInTag, alias)
+			case fv.CanAddr() && fv.Addr().CanInterface():
+				c, a = getMetaInfoUseInTag(fv.Addr().Interface(), useInTag, alias)
 			}
 			cols, args = append(cols, c...), append(args, a...)
 		}
@@ -259,3 +403,90 @@ func getMetaInfoUseInTag(obj interface{}, useInTag ormUseInTagValue, alias Alias
 
 	return cols, args
 }
+
+var (
+	typeValuer = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// isLeafType - type is value of one column (time.Time, driver.Valuer, sql.Scanner), such structs are not walked.
+func isLeafType(t reflect.Type) bool {
+	return t == typeTime || t.Implements(typeValuer) || reflect.PointerTo(t).Implements(typeValuer) ||
+		reflect.PointerTo(t).Implements(typeScanner)
+}
+
+// nestedStruct - type of struct which fields are walked: struct or pointer to struct (embedded *BaseDTO),
+// but not leaf type.
+func nestedStruct(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t, t.Kind() == reflect.Struct && !isLeafType(t)
+}
+
+// nestedField - type of nested struct of field (see nestedStruct), pointer is walked only if it is embedded, so
+// pointer fields of DTO (e.g. self reference `Next *Node`) are not walked.
+func nestedField(field reflect.StructField) (reflect.Type, bool) {
+	if field.Type.Kind() == reflect.Pointer && !field.Anonymous {
+		return nil, false
+	}
+
+	return nestedStruct(field.Type)
+}
+
+// NestedStruct - type of nested struct of field (struct or pointer to struct, not leaf type and not relation), for
+// packages which walk values of DTO (e.g. reflect/validate), they must stop on recursive types themselves.
+func NestedStruct(field reflect.StructField) (reflect.Type, bool) {
+	if isRelationField(field) {
+		return nil, false
+	}
+
+	return nestedStruct(field.Type)
+}
+
+// nestedValue - value v of nested struct field (see nestedField), false if it is nil pointer.
+func nestedValue(field reflect.StructField, v reflect.Value) (reflect.Value, bool) {
+	if _, ok := nestedField(field); !ok {
+		return reflect.Value{}, false
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+
+		return v.Elem(), true
+	}
+
+	return v, true
+}
+
+// nestedOrZero - the same as nestedValue, but for nil pointer zero value of struct is returned (type info only).
+func nestedOrZero(field reflect.StructField, v reflect.Value) (reflect.Value, bool) {
+	if _, ok := nestedField(field); !ok {
+		return reflect.Value{}, false
+	}
+
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return reflect.New(v.Type().Elem()).Elem(), true
+	}
+
+	return reflect.Indirect(v), true
+}
+
+// allocNested - the same as nestedValue, but nil pointer is set to new struct (if it is possible), for scan.
+func allocNested(field reflect.StructField, v reflect.Value) (reflect.Value, bool) {
+	if _, ok := nestedField(field); !ok {
+		return reflect.Value{}, false
+	}
+
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		if !v.CanSet() {
+			return reflect.Value{}, false
+		}
+
+		v.Set(reflect.New(v.Type().Elem()))
+	}
+
+	return reflect.Indirect(v), true
+}
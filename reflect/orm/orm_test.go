@@ -46,6 +46,19 @@ type (
 		_    any     `orm_table_name:"D"`
 	}
 
+	E struct {
+		A `db:"a"`
+		B `db:"b" orm_join:"a.id = b.id"`
+		D `db:"d" orm_alias:"d" orm_join:"USING (id)" orm_join_type:"left"`
+	}
+
+	NoJoinCond struct {
+		A `orm_alias:"a"`
+		B `orm_alias:"b"`
+		D `orm_alias:"d"`
+		_ bool `orm_join:"ON a.id = b.id"`
+	}
+
 	BadStruct struct {
 		*A
 		_              struct{ a int }
@@ -155,7 +168,8 @@ func (suite *OrmTestSuit) Test_BadGetOrmDataForSelect() {
 	cols2 := GetDataForSelectOnlyCols(&BadStruct{})
 	assert.Equal(t, col, cols2)
 
-	assert.Equal(t, []string{}, col)
+	// columns of nil embedded pointer are derived from type (nothing to create or update, see Test_BadGetOrmDataForCreate)
+	assert.Equal(t, []string{"id", "created_at", "updated_at", "select_field"}, col)
 	assert.Equal(t, "", join)
 
 	col, join = GetDataForSelect(nil)
@@ -218,3 +232,36 @@ func (suite *OrmTestSuit) Test_GetTableNameWithAlias() {
 	assert.Equal(t, "", GetTableNameWithAlias(nil))
 	assert.Equal(t, "", GetTableNameWithAlias(&BadStruct{}))
 }
+
+func (suite *OrmTestSuit) Test_GetJoinParts() {
+	t := suite.T()
+
+	parts, err := GetJoinParts(&C{})
+	assert.Nil(t, err)
+	assert.Equal(t, []JoinPart{
+		{Table: "A", Alias: "a"},
+		{Table: "B", Alias: "b", Type: JoinInner, Cond: "ON a.id = b.id"},
+	}, parts)
+
+	parts, err = GetJoinParts(E{})
+	assert.Nil(t, err)
+	assert.Equal(t, []JoinPart{
+		{Table: "A", Alias: "a"},
+		{Table: "B", Alias: "b", Type: JoinInner, Cond: "ON a.id = b.id"},
+		{Table: "D", Alias: "d", Type: JoinLeft, Cond: "USING (id)"},
+	}, parts)
+
+	_, err = GetJoinParts(NoJoinCond{}) // root orm_join is condition of the first joined table only
+	assert.ErrorIs(t, err, ErrNoJoinCond)
+	assert.ErrorIs(t, Validate(NoJoinCond{}), ErrNoJoinCond)
+
+	for _, obj := range []any{&A{}, &BadStruct{}, 123} {
+		parts, err = GetJoinParts(obj)
+		assert.Nil(t, err)
+		assert.Equal(t, []JoinPart{}, parts)
+	}
+
+	parts, err = GetJoinParts(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []JoinPart(nil), parts)
+}
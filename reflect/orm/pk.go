@@ -0,0 +1,149 @@
+package orm
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/google/uuid"
+)
+
+// Primary keys of DTO are declared by orm_pk tag (see also DDL tags):
+//
+//	orm_pk:"true"      - primary key, value is set by client, several pk columns -> composite pk
+//	orm_pk:"identity"  - primary key generated by database (serial, identity, autoincrement)
+//	orm_pk:"uuid"      - primary key generated by client on create if value is zero (uuid.UUID or string field)
+
+type (
+	// PrimaryKey - primary key column of DTO.
+	PrimaryKey = struct {
+		Column   Column
+		Identity bool // generated by database
+		UUID     bool // generated by client on create
+	}
+)
+
+// DefaultPrimaryKey - primary key column of DTO without orm_pk tags.
+const DefaultPrimaryKey Column = "id"
+
+var (
+	ErrUnsupportedUUIDType = errors.New("unsupported type of uuid primary key field, uuid.UUID ([16]byte) or string expected")
+
+	typeUUID = reflect.TypeOf(uuid.UUID{})
+)
+
+// GetPrimaryKeys - primary keys of DTO in declaration order (embedded structs are walked too),
+// empty if DTO has not orm_pk tags.
+func GetPrimaryKeys(obj any) []PrimaryKey {
+	pks := []PrimaryKey{}
+
+	for _, f := range primaryKeyFields(obj) {
+		pks = append(pks, f.pk)
+	}
+
+	return pks
+}
+
+// GetPrimaryKeyColumns - primary key columns of DTO, []Column{DefaultPrimaryKey} if DTO has not orm_pk tags.
+func GetPrimaryKeyColumns(obj any) []Column {
+	pks := GetPrimaryKeys(obj)
+	if len(pks) == 0 {
+		return []Column{DefaultPrimaryKey}
+	}
+
+	cols := make([]Column, 0, len(pks))
+	for _, pk := range pks {
+		cols = append(cols, pk.Column)
+	}
+
+	return cols
+}
+
+// GetGeneratedKeys - values of orm_pk:"uuid" columns for create: value of field if it is not zero, new uuid otherwise.
+// If obj is pointer, generated values are set to fields of obj too.
+func GetGeneratedKeys(obj any) (map[Column]Argument, error) {
+	keys := map[Column]Argument{}
+
+	for _, f := range primaryKeyFields(obj) {
+		if !f.pk.UUID {
+			continue
+		}
+
+		if !f.value.IsZero() {
+			keys[f.pk.Column] = f.value.Interface()
+
+			continue
+		}
+
+		v, err := newUUID(f.value.Type())
+		if err != nil {
+			return nil, err
+		}
+
+		if f.value.CanSet() {
+			f.value.Set(v)
+		}
+
+		keys[f.pk.Column] = v.Interface()
+	}
+
+	return keys, nil
+}
+
+type pkField struct {
+	pk    PrimaryKey
+	value reflect.Value
+}
+
+func primaryKeyFields(obj any) []pkField {
+	if obj == nil {
+		return nil
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return getPrimaryKeyFields(v)
+}
+
+func getPrimaryKeyFields(v reflect.Value) []pkField {
+	fields := []pkField{}
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, dbTag := field.Tag.Get(tagOrmPK), columnName(t, field)
+		if isTagEmpty(dbTag) || isTagEmpty(tag) || tag == "false" {
+			// parts of composite (join) DTO are embedded with db tag (prefix), their pk are not pk of DTO
+			if fv, ok := nestedOrZero(field, v.Field(i)); ok && field.Anonymous && isTagEmpty(dbTag) {
+				fields = append(fields, getPrimaryKeyFields(fv)...)
+			}
+
+			continue
+		}
+
+		fields = append(fields, pkField{
+			pk: PrimaryKey{
+				Column:   dbTag,
+				Identity: tag == ormPKIdentity,
+				UUID:     tag == ormPKUUID,
+			},
+			value: v.Field(i),
+		})
+	}
+
+	return fields
+}
+
+func newUUID(t reflect.Type) (reflect.Value, error) {
+	switch {
+	case t.Kind() == reflect.String:
+		return reflect.ValueOf(uuid.NewString()).Convert(t), nil
+	case typeUUID.ConvertibleTo(t):
+		return reflect.ValueOf(uuid.New()).Convert(t), nil
+	default:
+		return reflect.Value{}, ErrUnsupportedUUIDType
+	}
+}
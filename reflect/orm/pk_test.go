@@ -0,0 +1,59 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	PKToken struct {
+		UID  uuid.UUID `db:"uid"  orm_use_in:"select" orm_pk:"uuid"`
+		Code string    `db:"code" orm_use_in:"select,create" orm_pk:"uuid"`
+		Name string    `db:"name" orm_use_in:"select,create"`
+		_    any       `orm_table_name:"tokens"`
+	}
+
+	PKBadUUID struct {
+		N int64 `db:"n" orm_use_in:"select" orm_pk:"uuid"`
+	}
+
+	PKUsersRole struct {
+		DDLUser     `db:"u" orm_alias:"u"`
+		DDLUserRole `db:"ur" orm_alias:"ur"`
+	}
+)
+
+func Test_GetPrimaryKeys(t *testing.T) {
+	assert.Equal(t, []PrimaryKey{{Column: "id", Identity: true}}, GetPrimaryKeys(DDLRole{}))
+	assert.Equal(t, []Column{"user_id", "role_id"}, GetPrimaryKeyColumns(&DDLUserRole{}))
+	assert.Equal(t, []PrimaryKey{{Column: "uid", UUID: true}, {Column: "code", UUID: true}}, GetPrimaryKeys(PKToken{}))
+
+	assert.Equal(t, []Column{DefaultPrimaryKey}, GetPrimaryKeyColumns(PKUsersRole{})) // pk of join parts are skipped
+	assert.Equal(t, []Column{DefaultPrimaryKey}, GetPrimaryKeyColumns(nil))
+	assert.Empty(t, GetPrimaryKeys(1))
+}
+
+func Test_GetGeneratedKeys(t *testing.T) {
+	token := PKToken{Code: "c1"}
+
+	keys, err := GetGeneratedKeys(&token)
+	assert.Nil(t, err)
+	assert.NotEqual(t, uuid.Nil, token.UID) // set to obj
+	assert.Equal(t, map[Column]Argument{"uid": token.UID, "code": "c1"}, keys)
+
+	keys, err = GetGeneratedKeys(PKToken{})
+	assert.Nil(t, err)
+	assert.Len(t, keys, 2)
+	assert.NotEqual(t, uuid.Nil, keys["uid"])
+	_, err = uuid.Parse(keys["code"].(string))
+	assert.Nil(t, err)
+
+	keys, err = GetGeneratedKeys(DDLRole{})
+	assert.Nil(t, err)
+	assert.Empty(t, keys)
+
+	_, err = GetGeneratedKeys(PKBadUUID{})
+	assert.ErrorIs(t, err, ErrUnsupportedUUIDType)
+}
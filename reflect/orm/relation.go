@@ -0,0 +1,132 @@
+package orm
+
+import (
+	"reflect"
+)
+
+// Relations of DTO are fields with relation tags, such fields must have db:"-" tag (they are not columns):
+//
+//	Role  *Role  `db:"-" orm_belongs_to:"role_id"` - role_id column of DTO references pk of Role (pointer or struct)
+//	Users []User `db:"-" orm_has_many:"role_id"`   - role_id column of User references pk of DTO (slice)
+//
+// Related DTO must have orm_table_name tag (or Repo() method), referenced pk is first pk column (see GetPrimaryKeys).
+
+type (
+	RelationKind = string
+
+	// Relation - description of relation field of DTO.
+	Relation = struct {
+		Field      string       // name of go field
+		Kind       RelationKind // RelationBelongsTo or RelationHasMany
+		ForeignKey Column       // column of DTO (belongs to) or of related DTO (has many)
+		Index      []int        // index of field for reflect.Value.FieldByIndex
+		Type       reflect.Type // type of related DTO (struct)
+	}
+)
+
+const (
+	RelationBelongsTo RelationKind = "belongs_to"
+	RelationHasMany   RelationKind = "has_many"
+
+	tagOrmBelongsTo = "orm_belongs_to"
+	tagOrmHasMany   = "orm_has_many"
+)
+
+// GetRelations - relation fields of DTO in declaration order (embedded structs are walked too).
+func GetRelations(obj any) []Relation {
+	if obj == nil {
+		return []Relation{}
+	}
+
+	t := reflect.Indirect(reflect.ValueOf(obj)).Type()
+	if t.Kind() != reflect.Struct {
+		return []Relation{}
+	}
+
+	return getRelations(t, nil)
+}
+
+// GetRelation - relation field of DTO by go name of field.
+func GetRelation(obj any, field string) (Relation, bool) {
+	for _, r := range GetRelations(obj) {
+		if r.Field == field {
+			return r, true
+		}
+	}
+
+	return Relation{}, false
+}
+
+func getRelations(t reflect.Type, index []int) []Relation {
+	relations := []Relation{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if r, ok := relationOf(field); ok {
+			r.Index = fieldIndex
+			relations = append(relations, r)
+
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && isTagEmpty(field.Tag.Get(tagDB)) {
+			relations = append(relations, getRelations(field.Type, fieldIndex)...)
+		}
+	}
+
+	return relations
+}
+
+func relationOf(field reflect.StructField) (Relation, bool) {
+	r := Relation{Field: field.Name}
+
+	if fk := field.Tag.Get(tagOrmBelongsTo); !isTagEmpty(fk) {
+		r.Kind, r.ForeignKey = RelationBelongsTo, fk
+	} else if fk = field.Tag.Get(tagOrmHasMany); !isTagEmpty(fk) {
+		r.Kind, r.ForeignKey = RelationHasMany, fk
+	} else {
+		return r, false
+	}
+
+	t := field.Type
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+
+	r.Type = t
+
+	return r, t.Kind() == reflect.Struct
+}
+
+func isRelationField(field reflect.StructField) bool {
+	return !isTagEmpty(field.Tag.Get(tagOrmBelongsTo)) || !isTagEmpty(field.Tag.Get(tagOrmHasMany))
+}
+
+// GetColumnValue - value of field of DTO by db column name (embedded structs without db tag are walked too).
+func GetColumnValue(v reflect.Value, column Column) (reflect.Value, bool) {
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		dbTagValue := columnName(t, field)
+		if dbTagValue == column {
+			return v.Field(i), true
+		}
+
+		if fv, ok := nestedOrZero(field, v.Field(i)); ok && field.Anonymous && isTagEmpty(dbTagValue) {
+			if cv, found := GetColumnValue(fv, column); found {
+				return cv, true
+			}
+		}
+	}
+
+	return reflect.Value{}, false
+}
@@ -0,0 +1,68 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	RelRole struct {
+		DDLRole
+		Users []RelUser `db:"-" orm_has_many:"role_id"`
+	}
+
+	RelUser struct {
+		ID     int64    `db:"id"      orm_use_in:"select"`
+		Name   string   `db:"name"    orm_use_in:"select,create,update"`
+		RoleID int64    `db:"role_id" orm_use_in:"select,create,update"`
+		Role   *DDLRole `db:"-"       orm_belongs_to:"role_id"`
+		Owner  DDLRole  `db:"-"       orm_belongs_to:"owner_id"`
+		_      any      `orm_table_name:"users"`
+	}
+)
+
+func Test_GetRelations(t *testing.T) {
+	assert.Equal(t, []Relation{
+		{Field: "Role", Kind: RelationBelongsTo, ForeignKey: "role_id", Index: []int{3}, Type: reflect.TypeOf(DDLRole{})},
+		{Field: "Owner", Kind: RelationBelongsTo, ForeignKey: "owner_id", Index: []int{4}, Type: reflect.TypeOf(DDLRole{})},
+	}, GetRelations(&RelUser{}))
+
+	r, found := GetRelation(RelRole{}, "Users")
+	assert.True(t, found)
+	assert.Equal(t, Relation{
+		Field: "Users", Kind: RelationHasMany, ForeignKey: "role_id", Index: []int{1}, Type: reflect.TypeOf(RelUser{}),
+	}, r)
+
+	_, found = GetRelation(RelRole{}, "Name")
+	assert.False(t, found)
+	assert.Empty(t, GetRelations(nil))
+	assert.Empty(t, GetRelations(DDLRole{}))
+}
+
+func Test_RelationsAreNotColumns(t *testing.T) {
+	u := RelUser{Name: "Bob", RoleID: 2, Role: &DDLRole{ID: 2, Name: "admin"}}
+
+	cols, args := GetDataForCreate(&u)
+	assert.Equal(t, []Column{"name", "role_id"}, cols)
+	assert.Equal(t, []Argument{"Bob", int64(2)}, args)
+	assert.Equal(t, map[Column]Argument{"name": "Bob", "role_id": int64(2)}, GetDataForUpdate(&u))
+	assert.Equal(t, map[Column]Argument{"name": "Bob"}, Diff(RelUser{RoleID: 2}, &u))
+	parts, err := GetJoinParts(RelUser{})
+	assert.Nil(t, err)
+	assert.Empty(t, parts)
+}
+
+func Test_GetColumnValue(t *testing.T) {
+	r := RelRole{DDLRole: DDLRole{ID: 7, Name: "admin"}}
+
+	v, found := GetColumnValue(reflect.ValueOf(&r), "id")
+	assert.True(t, found)
+	assert.Equal(t, int64(7), v.Interface())
+
+	_, found = GetColumnValue(reflect.ValueOf(r), "role_id")
+	assert.False(t, found)
+	_, found = GetColumnValue(reflect.ValueOf(1), "id")
+	assert.False(t, found)
+}
@@ -0,0 +1,248 @@
+package orm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Sensitive fields of DTO are masked in logs, field is sensitive if it has one of tags:
+//
+//	orm_sensitive:"true"
+//	log:"-"
+
+const (
+	tagOrmSensitive = "orm_sensitive"
+	tagLog          = "log"
+	tagLogSkip      = "-"
+
+	Masked = "***" // value of sensitive fields in logs
+)
+
+type (
+	sensitive struct {
+		obj any
+	}
+
+	// sensitiveValue - struct (object), map (object) or slice/array (array) with masked sensitive fields of structs
+	// of any depth, path - pointers and maps which are encoded now (cyclic value is logged as Cycle).
+	sensitiveValue struct {
+		v    reflect.Value
+		path map[uintptr]struct{}
+	}
+
+	// valueEncoder - ObjectEncoder with key or ArrayEncoder.
+	valueEncoder struct {
+		object    func(zapcore.ObjectMarshaler) error
+		array     func(zapcore.ArrayMarshaler) error
+		reflected func(any) error
+		str       func(string)
+	}
+)
+
+const Cycle = "<cycle>" // value of pointer to struct which is logged already (cyclic value)
+
+// IsSensitiveField - is field marked by orm_sensitive:"true" or log:"-" tag.
+func IsSensitiveField(field reflect.StructField) bool {
+	return isTagTrue(field.Tag.Get(tagOrmSensitive)) || field.Tag.Get(tagLog) == tagLogSkip
+}
+
+// Sensitive - zapcore.ObjectMarshaler wrapper for obj, exported fields are logged by go names
+// (embedded structs are flattened, nested structs are logged as objects), sensitive fields are masked in structs
+// of any depth: nested, in slices, arrays and maps (e.g. preloaded relations). Pointer to struct which is logged
+// already on the path from obj (cyclic value) is logged as Cycle.
+//
+//	logger.Info("user created", zap.Object("user", orm.Sensitive(user)))
+func Sensitive(obj any) zapcore.ObjectMarshaler {
+	return sensitive{obj: obj}
+}
+
+// LogField - zap field for obj with masked sensitive fields, shortcut for zap.Object(key, Sensitive(obj)).
+func LogField(key string, obj any) zap.Field {
+	return zap.Object(key, Sensitive(obj))
+}
+
+func (s sensitive) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	path := map[uintptr]struct{}{}
+	if p := reflect.ValueOf(s.obj); p.Kind() == reflect.Pointer && !p.IsNil() {
+		path[p.Pointer()] = struct{}{}
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(s.obj))
+	if !v.IsValid() || v.Kind() != reflect.Struct {
+		return encodeValue(reflect.ValueOf(s.obj), path, objectField(enc, "value"))
+	}
+
+	return sensitiveValue{v: v, path: path}.MarshalLogObject(enc)
+}
+
+func (s sensitiveValue) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if s.v.Kind() == reflect.Map {
+		iter := s.v.MapRange()
+		for iter.Next() {
+			if err := encodeValue(iter.Value(), s.path, objectField(enc, fmt.Sprint(iter.Key().Interface()))); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return marshalStruct(s.v, s.path, enc)
+}
+
+func (s sensitiveValue) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for i := 0; i < s.v.Len(); i++ {
+		if err := encodeValue(s.v.Index(i), s.path, arrayElem(enc)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func objectField(enc zapcore.ObjectEncoder, key string) valueEncoder {
+	return valueEncoder{
+		object:    func(m zapcore.ObjectMarshaler) error { return enc.AddObject(key, m) },
+		array:     func(m zapcore.ArrayMarshaler) error { return enc.AddArray(key, m) },
+		reflected: func(v any) error { return enc.AddReflected(key, v) },
+		str:       func(v string) { enc.AddString(key, v) },
+	}
+}
+
+func arrayElem(enc zapcore.ArrayEncoder) valueEncoder {
+	return valueEncoder{
+		object:    enc.AppendObject,
+		array:     enc.AppendArray,
+		reflected: enc.AppendReflected,
+		str:       enc.AppendString,
+	}
+}
+
+func marshalStruct(v reflect.Value, path map[uintptr]struct{}, enc zapcore.ObjectEncoder) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if _, ok := nestedStruct(field.Type); ok && field.Anonymous {
+			if fv, ok := nestedValue(field, v.Field(i)); ok { // nil embedded pointer is skipped
+				if err := enter(v.Field(i), path, nil, func() error { return marshalStruct(fv, path, enc) }); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if IsSensitiveField(field) {
+			enc.AddString(field.Name, Masked)
+
+			continue
+		}
+
+		if err := encodeValue(v.Field(i), path, objectField(enc, field.Name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeValue - nested structs, maps and slices/arrays of them are encoded with masked sensitive fields,
+// other values are encoded by reflection (json).
+func encodeValue(v reflect.Value, path map[uintptr]struct{}, enc valueEncoder) error {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	cycle := func() { enc.str(Cycle) }
+
+	switch {
+	case isNestedStruct(v):
+		return enter(v, path, cycle, func() error {
+			return enc.object(sensitiveValue{v: reflect.Indirect(v), path: path})
+		})
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && !v.IsZero() && mayContainStructs(v.Type().Elem()):
+		return enc.array(sensitiveValue{v: v, path: path})
+	case v.Kind() == reflect.Map && !v.IsNil() && mayContainStructs(v.Type().Elem()):
+		return enter(v, path, cycle, func() error { return enc.object(sensitiveValue{v: v, path: path}) })
+	case v.Kind() == reflect.Pointer && !v.IsNil() && mayContainStructs(v.Type().Elem()): // e.g. *[]User
+		return enter(v, path, cycle, func() error { return encodeValue(v.Elem(), path, enc) })
+	}
+
+	if !v.IsValid() {
+		return enc.reflected(nil)
+	}
+
+	return enc.reflected(v.Interface())
+}
+
+// enter - fn for pointer or map v which is not encoded now, cycle (if not nil) otherwise.
+func enter(v reflect.Value, path map[uintptr]struct{}, cycle func(), fn func() error) error {
+	if v.Kind() != reflect.Pointer && v.Kind() != reflect.Map {
+		return fn()
+	}
+
+	p := v.Pointer()
+	if _, found := path[p]; found {
+		if cycle != nil {
+			cycle()
+		}
+
+		return nil
+	}
+
+	path[p] = struct{}{}
+	defer delete(path, p)
+
+	return fn()
+}
+
+// mayContainStructs - values of type t can contain nested structs (interfaces are checked by value).
+func mayContainStructs(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return mayContainStructs(t.Elem())
+	}
+
+	return false
+}
+
+// isNestedStruct - struct (or not nil pointer to struct) without own representation (time.Time, Stringer, Valuer...).
+func isNestedStruct(v reflect.Value) bool {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return false
+		}
+
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+
+	switch v.Interface().(type) {
+	case fmt.Stringer, json.Marshaler, driver.Valuer:
+		return false
+	}
+
+	switch reflect.New(v.Type()).Interface().(type) {
+	case fmt.Stringer, json.Marshaler, sql.Scanner:
+		return false
+	}
+
+	return true
+}
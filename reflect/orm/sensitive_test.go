@@ -0,0 +1,133 @@
+package orm
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	Credentials struct {
+		Login string `db:"login"`
+		Token string `db:"token" log:"-"`
+	}
+
+	SensitiveDTO struct {
+		BaseDTO
+		Name     string         `db:"name"     orm_use_in:"select,create,update"`
+		Password string         `db:"password" orm_use_in:"select,create,update" orm_sensitive:"true"`
+		Phone    sql.NullString `db:"phone"    orm_use_in:"select,create,update" orm_sensitive:"true"`
+		Note     sql.NullString `db:"note"     orm_use_in:"select,create,update"`
+		Creds    Credentials
+		CredsPtr *Credentials
+		secret   string
+	}
+
+	SensitiveRelations struct {
+		List    []Credentials
+		Ptrs    []*Credentials
+		ByName  map[string]Credentials
+		PtrList *[]Credentials
+		Any     []any
+		Tags    []string
+	}
+
+	SensitiveNode struct {
+		Name  string
+		Token string `log:"-"`
+		Next  *SensitiveNode
+		Nodes []*SensitiveNode
+	}
+)
+
+func Test_Sensitive(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+
+	obj := &SensitiveDTO{
+		BaseDTO:  BaseDTO{ID: 1, CreatedAt: time.Unix(0, 0).UTC()},
+		Name:     "bob",
+		Password: "p@ssw0rd",
+		Phone:    sql.NullString{String: "+100500", Valid: true},
+		Note:     sql.NullString{String: "note", Valid: true},
+		Creds:    Credentials{Login: "bob", Token: "t0ken"},
+		secret:   "secret",
+	}
+
+	logger.Info("obj", LogField("obj", obj))
+	logger.Info("value", zap.Object("obj", Sensitive(1)))
+
+	assert.Equal(t, 2, logs.Len())
+
+	m := logs.All()[0].ContextMap()["obj"].(map[string]any)
+	assert.Equal(t, int64(1), m["ID"])
+	assert.Equal(t, "bob", m["Name"])
+	assert.Equal(t, Masked, m["Password"])
+	assert.Equal(t, Masked, m["Phone"])
+	assert.Equal(t, sql.NullString{String: "note", Valid: true}, m["Note"])
+	assert.Equal(t, map[string]any{"Login": "bob", "Token": Masked}, m["Creds"])
+	assert.Nil(t, m["CredsPtr"])
+	assert.Equal(t, time.Unix(0, 0).UTC(), m["CreatedAt"])
+	assert.NotContains(t, m, "secret")
+
+	assert.Equal(t, map[string]any{"value": 1}, logs.All()[1].ContextMap()["obj"])
+}
+
+func Test_SensitiveCollections(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+
+	creds := Credentials{Login: "bob", Token: "t0ken"}
+	masked := map[string]any{"Login": "bob", "Token": Masked}
+
+	logger.Info("obj", LogField("obj", SensitiveRelations{
+		List:    []Credentials{creds, creds},
+		Ptrs:    []*Credentials{&creds, nil},
+		ByName:  map[string]Credentials{"bob": creds},
+		PtrList: &[]Credentials{creds},
+		Any:     []any{creds, 1},
+		Tags:    []string{"a"},
+	}))
+	logger.Info("slice", LogField("obj", []Credentials{creds}))
+	logger.Info("map", LogField("obj", map[int]*Credentials{1: &creds}))
+
+	m := logs.All()[0].ContextMap()["obj"].(map[string]any)
+	assert.Equal(t, []any{masked, masked}, m["List"])
+	assert.Equal(t, []any{masked, (*Credentials)(nil)}, m["Ptrs"])
+	assert.Equal(t, map[string]any{"bob": masked}, m["ByName"])
+	assert.Equal(t, []any{masked}, m["PtrList"])
+	assert.Equal(t, []any{masked, 1}, m["Any"])
+	assert.Equal(t, []string{"a"}, m["Tags"])
+
+	assert.Equal(t, map[string]any{"value": []any{masked}}, logs.All()[1].ContextMap()["obj"])
+	assert.Equal(t, map[string]any{"value": map[string]any{"1": masked}}, logs.All()[2].ContextMap()["obj"])
+}
+
+func Test_SensitiveCycle(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+
+	a := &SensitiveNode{Name: "a", Token: "t0ken"}
+	b := &SensitiveNode{Name: "b", Token: "t0ken", Next: a}
+	a.Next, a.Nodes = b, []*SensitiveNode{a, b}
+
+	logger.Info("cycle", LogField("obj", a))
+
+	m := logs.All()[0].ContextMap()["obj"].(map[string]any)
+	assert.Equal(t, "a", m["Name"])
+	assert.Equal(t, Masked, m["Token"])
+
+	next := m["Next"].(map[string]any)
+	assert.Equal(t, "b", next["Name"])
+	assert.Equal(t, Cycle, next["Next"])
+
+	nodes := m["Nodes"].([]any)
+	assert.Equal(t, Cycle, nodes[0])
+	assert.Equal(t, Cycle, nodes[1].(map[string]any)["Next"])
+}
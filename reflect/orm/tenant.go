@@ -0,0 +1,43 @@
+package orm
+
+import (
+	"reflect"
+)
+
+// Tenant column of DTO is declared by orm_tenant tag, repositories of db package scope all queries of such DTO
+// by tenant of context.Context (see package db/tenant):
+//
+//	TenantID int64 `db:"tenant_id" orm_use_in:"select,create" orm_tenant:"true"`
+
+const tagOrmTenant = "orm_tenant"
+
+// GetTenantColumn - column of orm_tenant field of DTO (or pointer to DTO, slice of DTO), embedded structs without
+// db tag are walked too, empty if DTO is not scoped by tenant.
+func GetTenantColumn(obj any) Column {
+	t := elemStructType(obj)
+	if t == nil {
+		return ""
+	}
+
+	return tenantColumn(t)
+}
+
+func tenantColumn(t reflect.Type) Column {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		column := columnName(t, field)
+
+		if isTagTrue(field.Tag.Get(tagOrmTenant)) && !isTagEmpty(column) {
+			return column
+		}
+
+		// parts of composite (join) DTO are embedded with db tag (prefix), their tenant is not tenant of DTO
+		if ft, ok := nestedStruct(field.Type); ok && field.Anonymous && column == "" && !isRelationField(field) {
+			if c := tenantColumn(ft); c != "" {
+				return c
+			}
+		}
+	}
+
+	return ""
+}
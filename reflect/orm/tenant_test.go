@@ -0,0 +1,33 @@
+package orm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	TenantBase struct {
+		TenantID int64 `db:"tenant_id" orm_use_in:"select,create" orm_tenant:"true"`
+	}
+
+	TenantUser struct {
+		TenantBase
+		ID   int64  `db:"id" orm_use_in:"select"`
+		Name string `db:"name" orm_use_in:"select,create,update"`
+	}
+
+	TenantUserRole struct {
+		TenantUser `db:"u" orm_alias:"u" orm_table_name:"users"`
+		DDLRole    `db:"r" orm_alias:"r" orm_join:"u.id = r.id"`
+	}
+)
+
+func Test_GetTenantColumn(t *testing.T) {
+	assert.Equal(t, "tenant_id", GetTenantColumn(TenantUser{}))
+	assert.Equal(t, "tenant_id", GetTenantColumn(&[]*TenantUser{}))
+	assert.Equal(t, "", GetTenantColumn(DDLRole{}))
+	assert.Equal(t, "", GetTenantColumn(TenantUserRole{})) // tenant of part is not tenant of composite DTO
+	assert.Equal(t, "", GetTenantColumn(nil))
+	assert.Equal(t, "", GetTenantColumn(1))
+}
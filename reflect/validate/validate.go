@@ -0,0 +1,409 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/imperiuse/golib/reflect/orm"
+)
+
+// Validation of DTO by `validate` tag, nested structs are walked the same way as orm does it (embedded structs and
+// pointers to structs, but not time.Time, driver.Valuer, sql.Scanner and relations):
+//
+//	Email string `validate:"required,email"`
+//	Name  string `validate:"required,min=3,max=64"`
+//	Role  string `validate:"oneof=admin user guest"`
+//	Code  string `validate:"regexp=^[A-Z]{2}[0-9]+$"` // regexp is the last rule, so it can contain commas
+//
+// min and max - length of string (in runes), slice, map and array, value of number. Empty string is valid for
+// email, regexp and oneof (use required). Rules except required are skipped for nil pointer.
+// Struct reached by pointer is validated once (cyclic pointers, e.g. Parent *Node of Node, are not followed again).
+
+type (
+	// FieldError - one failed rule of field.
+	FieldError struct {
+		Field string // path of go field, e.g. BaseDTO.Name
+		Rule  string // rule with param, e.g. min=3
+		Err   error  // ErrRequired, ErrMin, ErrMax, ErrPattern, ErrEmail or ErrOneOf
+	}
+
+	// Error - all failed rules of DTO (errors.Is works for ErrValidation and errors of rules).
+	Error struct {
+		Type   string
+		Fields []FieldError
+	}
+
+	rule struct {
+		name  string // with param
+		check func(reflect.Value) error
+	}
+
+	fieldRules struct {
+		index  int
+		name   string
+		rules  []rule
+		nested bool
+	}
+
+	structRules struct {
+		fields []fieldRules
+		err    error // invalid tag
+	}
+
+	// visitKey - struct reached by pointer (address and type, embedded struct at offset 0 has the same address).
+	visitKey struct {
+		ptr uintptr
+		t   reflect.Type
+	}
+)
+
+const (
+	tagValidate = "validate"
+
+	ruleRequired = "required"
+	ruleMin      = "min"
+	ruleMax      = "max"
+	ruleRegexp   = "regexp"
+	ruleEmail    = "email"
+	ruleOneOf    = "oneof"
+)
+
+var (
+	ErrValidation = errors.New("validation failed")
+	ErrInvalidTag = errors.New("invalid validate tag")
+	ErrNotStruct  = errors.New("struct expected")
+
+	ErrRequired = errors.New("value is required")
+	ErrMin      = errors.New("value (length) is less than min")
+	ErrMax      = errors.New("value (length) is greater than max")
+	ErrPattern  = errors.New("value does not match regexp")
+	ErrEmail    = errors.New("value is not email")
+	ErrOneOf    = errors.New("value is not one of allowed")
+
+	cache sync.Map // reflect.Type -> *structRules
+)
+
+// Struct - validate DTO (or pointer to DTO) by validate tags: nil, *Error with all failed rules or
+// error of invalid tag (ErrInvalidTag).
+func Struct(obj any) error {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("validate: %T: %w", obj, ErrNotStruct)
+	}
+
+	visited := map[visitKey]bool{}
+	if v.CanAddr() {
+		visited[visitKey{ptr: v.Addr().Pointer(), t: v.Type()}] = true
+	}
+
+	e := &Error{Type: v.Type().String(), Fields: []FieldError{}}
+	if err := validateStruct(v, "", e, visited); err != nil {
+		return err
+	}
+
+	if len(e.Fields) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func (e *Error) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Error())
+	}
+
+	return fmt.Sprintf("validate: invalid %s: %s", e.Type, strings.Join(msgs, "; "))
+}
+
+// Is - errors.Is(err, ErrValidation), errors.Is(err, ErrRequired) and etc.
+func (e *Error) Is(target error) bool {
+	if target == ErrValidation {
+		return true
+	}
+
+	for _, f := range e.Fields {
+		if errors.Is(f.Err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f FieldError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", f.Field, f.Rule, f.Err)
+}
+
+func (f FieldError) Unwrap() error {
+	return f.Err
+}
+
+func validateStruct(v reflect.Value, path string, e *Error, visited map[visitKey]bool) error {
+	sr := rulesOf(v.Type())
+	if sr.err != nil {
+		return sr.err
+	}
+
+	for _, fr := range sr.fields {
+		fv, name := v.Field(fr.index), path+fr.name
+
+		for _, r := range fr.rules {
+			if err := r.check(fv); err != nil {
+				e.Fields = append(e.Fields, FieldError{Field: name, Rule: r.name, Err: err})
+				break // the first failed rule of field only
+			}
+		}
+
+		if !fr.nested {
+			continue
+		}
+
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+
+			key := visitKey{ptr: fv.Pointer(), t: fv.Type().Elem()}
+			if visited[key] {
+				continue
+			}
+
+			visited[key], fv = true, fv.Elem()
+		}
+
+		if err := validateStruct(fv, name+".", e, visited); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func rulesOf(t reflect.Type) *structRules {
+	return rulesOfVisiting(t, map[reflect.Type]bool{})
+}
+
+func rulesOfVisiting(t reflect.Type, visiting map[reflect.Type]bool) *structRules {
+	if sr, found := cache.Load(t); found {
+		return sr.(*structRules)
+	}
+
+	visiting[t] = true
+	sr := parseStruct(t, visiting)
+	cache.Store(t, sr)
+
+	return sr
+}
+
+// parseStruct - rules of fields, nested structs are parsed too (to find invalid tags before validation),
+// except recursive ones (they are parsed on validation).
+func parseStruct(t reflect.Type, visiting map[reflect.Type]bool) *structRules {
+	sr := &structRules{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fr := fieldRules{index: i, name: field.Name}
+
+		if tag := field.Tag.Get(tagValidate); tag != "" && tag != "-" {
+			rules, err := parseTag(field.Type, tag)
+			if err != nil {
+				sr.err = fmt.Errorf("validate: %s.%s: %w", t, field.Name, err)
+				return sr
+			}
+
+			fr.rules = rules
+		}
+
+		if nt, ok := orm.NestedStruct(field); ok {
+			if !visiting[nt] {
+				if nested := rulesOfVisiting(nt, visiting); nested.err != nil {
+					sr.err = nested.err
+					return sr
+				}
+			}
+
+			fr.nested = true
+		}
+
+		if len(fr.rules) > 0 || fr.nested {
+			sr.fields = append(sr.fields, fr)
+		}
+	}
+
+	return sr
+}
+
+// parseTag - rules of tag, regexp consumes the rest of tag.
+func parseTag(t reflect.Type, tag string) ([]rule, error) {
+	rules := []rule{}
+
+	for tag = strings.TrimLeft(tag, " "); tag != ""; tag = strings.TrimLeft(tag, " ") {
+		var part string
+		if strings.HasPrefix(tag, ruleRegexp+"=") {
+			part, tag = tag, ""
+		} else {
+			part, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, param, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		r, err := newRule(t, name, param)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", part, err)
+		}
+
+		r.name = strings.TrimSpace(part)
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+func newRule(t reflect.Type, name, param string) (rule, error) {
+	elem := t
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	switch name {
+	case ruleRequired:
+		return rule{check: func(v reflect.Value) error {
+			if v.IsZero() {
+				return ErrRequired
+			}
+
+			return nil
+		}}, nil
+
+	case ruleMin, ruleMax:
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil || !isMeasurable(elem.Kind()) {
+			return rule{}, ErrInvalidTag
+		}
+
+		return rule{check: deref(func(v reflect.Value) error {
+			n := measure(v)
+			if name == ruleMin && n < limit {
+				return ErrMin
+			}
+
+			if name == ruleMax && n > limit {
+				return ErrMax
+			}
+
+			return nil
+		})}, nil
+
+	case ruleRegexp:
+		re, err := regexp.Compile(param)
+		if err != nil || elem.Kind() != reflect.String {
+			return rule{}, ErrInvalidTag
+		}
+
+		return rule{check: deref(func(v reflect.Value) error {
+			if s := v.String(); s != "" && !re.MatchString(s) {
+				return ErrPattern
+			}
+
+			return nil
+		})}, nil
+
+	case ruleEmail:
+		if elem.Kind() != reflect.String {
+			return rule{}, ErrInvalidTag
+		}
+
+		return rule{check: deref(func(v reflect.Value) error {
+			s := v.String()
+			if s == "" {
+				return nil
+			}
+
+			if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+				return ErrEmail
+			}
+
+			return nil
+		})}, nil
+
+	case ruleOneOf:
+		allowed := strings.Fields(param)
+		if len(allowed) == 0 {
+			return rule{}, ErrInvalidTag
+		}
+
+		return rule{check: deref(func(v reflect.Value) error {
+			s := fmt.Sprint(v.Interface())
+			if v.Kind() == reflect.String && s == "" {
+				return nil
+			}
+
+			for _, a := range allowed {
+				if s == a {
+					return nil
+				}
+			}
+
+			return ErrOneOf
+		})}, nil
+
+	default:
+		return rule{}, ErrInvalidTag
+	}
+}
+
+// deref - check of not nil value (pointer is dereferenced, nil pointer is valid).
+func deref(check func(reflect.Value) error) func(reflect.Value) error {
+	return func(v reflect.Value) error {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil
+			}
+
+			v = v.Elem()
+		}
+
+		return check(v)
+	}
+}
+
+func isMeasurable(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// measure - length of string (runes), slice, map, array or value of number.
+func measure(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String()))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
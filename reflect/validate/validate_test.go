@@ -0,0 +1,144 @@
+package validate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	Base struct {
+		ID        int64     `validate:"min=0"`
+		CreatedAt time.Time // leaf type, not walked
+	}
+
+	Profile struct {
+		Bio string `validate:"max=5"`
+	}
+
+	User struct {
+		Base
+		*Profile
+		Email string   `validate:"required,email"`
+		Name  string   `validate:"required,min=2,max=4"`
+		Role  string   `validate:"oneof=admin user"`
+		Code  string   `validate:"regexp=^[A-Z]{2},[0-9]+$"`
+		Tags  []string `validate:"max=2"`
+		Age   *int     `validate:"min=18"`
+		Owner *User    `db:"-" orm_belongs_to:"owner_id"` // relation, not walked
+		skip  string   `validate:"required"`
+	}
+
+	Node struct {
+		Name string `validate:"required"`
+		Next *Node
+	}
+
+	BadTag struct {
+		Name string `validate:"min=abc"`
+	}
+
+	BadNested struct {
+		BadTag
+	}
+)
+
+func Test_Struct(t *testing.T) {
+	age := 20
+	valid := User{Email: "bob@example.com", Name: "Bob", Role: "user", Code: "AB,12", Age: &age}
+
+	assert.NoError(t, Struct(valid))
+	assert.NoError(t, Struct(&valid))
+	assert.NoError(t, Struct(&User{Email: "a@b.c", Name: "Al", Owner: &User{}})) // empty Role, Code; nil Age
+
+	young := 10
+	err := Struct(User{
+		Base:    Base{ID: -1},
+		Profile: &Profile{Bio: "too long"},
+		Name:    "B",
+		Role:    "root",
+		Code:    "ab,12",
+		Tags:    []string{"a", "b", "c"},
+		Age:     &young,
+	})
+
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, "validate.User", e.Type)
+	assert.Equal(t, []FieldError{
+		{Field: "Base.ID", Rule: "min=0", Err: ErrMin},
+		{Field: "Profile.Bio", Rule: "max=5", Err: ErrMax},
+		{Field: "Email", Rule: "required", Err: ErrRequired},
+		{Field: "Name", Rule: "min=2", Err: ErrMin},
+		{Field: "Role", Rule: "oneof=admin user", Err: ErrOneOf},
+		{Field: "Code", Rule: "regexp=^[A-Z]{2},[0-9]+$", Err: ErrPattern},
+		{Field: "Tags", Rule: "max=2", Err: ErrMax},
+		{Field: "Age", Rule: "min=18", Err: ErrMin},
+	}, e.Fields)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.ErrorIs(t, err, ErrOneOf)
+	assert.NotErrorIs(t, err, ErrEmail)
+	assert.Contains(t, err.Error(), "validate: invalid validate.User: Base.ID: min=0: value (length) is less than min; ")
+
+	assert.ErrorIs(t, Struct(User{Email: "Bob <bob@example.com>", Name: "Bob"}), ErrEmail)
+	assert.ErrorIs(t, Struct(User{Email: "bob", Name: "Bob"}), ErrEmail)
+	assert.ErrorIs(t, Struct(User{Email: "bob@example.com", Name: "Bobby"}), ErrMax)
+	assert.NoError(t, Struct(User{Email: "bob@example.com", Name: "Вася"})) // length in runes
+}
+
+func Test_StructRecursiveAndInvalid(t *testing.T) {
+	assert.NoError(t, Struct(Node{Name: "a", Next: &Node{Name: "b"}}))
+
+	err := Struct(Node{Name: "a", Next: &Node{}})
+	assert.ErrorIs(t, err, ErrRequired)
+	assert.Contains(t, err.Error(), "Next.Name: required")
+
+	assert.ErrorIs(t, Struct(BadTag{}), ErrInvalidTag)
+	assert.ErrorIs(t, Struct(&BadNested{}), ErrInvalidTag)
+	assert.NotErrorIs(t, Struct(BadTag{}), ErrValidation)
+	assert.ErrorIs(t, Struct(1), ErrNotStruct)
+	assert.ErrorIs(t, Struct(nil), ErrNotStruct)
+}
+
+func Test_StructCyclic(t *testing.T) {
+	a := &Node{Name: "a"}
+	a.Next = &Node{Next: a} // a -> b -> a
+
+	err := Struct(a)
+	assert.ErrorIs(t, err, ErrRequired)
+
+	var e *Error
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, []FieldError{{Field: "Next.Name", Rule: "required", Err: ErrRequired}}, e.Fields) // once
+
+	self := Node{Name: "self"}
+	self.Next = &self
+	assert.NoError(t, Struct(self)) // value: the copy is validated, then pointed struct once
+}
+
+func Test_InvalidTags(t *testing.T) {
+	for _, tc := range []struct {
+		obj any
+		tag string
+	}{
+		{obj: struct {
+			A string `validate:"unknown"`
+		}{}, tag: "unknown"},
+		{obj: struct {
+			A bool `validate:"min=1"`
+		}{}, tag: "min on bool"},
+		{obj: struct {
+			A int `validate:"email"`
+		}{}, tag: "email on int"},
+		{obj: struct {
+			A string `validate:"regexp=["`
+		}{}, tag: "bad regexp"},
+		{obj: struct {
+			A string `validate:"oneof="`
+		}{}, tag: "empty oneof"},
+	} {
+		assert.ErrorIs(t, Struct(tc.obj), ErrInvalidTag, tc.tag)
+	}
+}
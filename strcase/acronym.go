@@ -1,10 +1,125 @@
 package strcase
 
-var uppercaseAcronym = map[string]string{
-	"ID": "id",
-}
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	acronymMu        sync.RWMutex
+	uppercaseAcronym = map[string]string{
+		"ID": "id",
+	}
+	acronymsByFirst = indexAcronyms(uppercaseAcronym) // first byte of key -> keys, the longest first
+)
 
 // ConfigureAcronym allows you to add additional words which will be considered acronyms
 func ConfigureAcronym(key, val string) {
+	acronymMu.Lock()
+	defer acronymMu.Unlock()
+
 	uppercaseAcronym[key] = val
+	acronymsByFirst = indexAcronyms(uppercaseAcronym)
+}
+
+func acronym(s string) (string, bool) {
+	acronymMu.RLock()
+	defer acronymMu.RUnlock()
+
+	a, ok := uppercaseAcronym[s]
+
+	return a, ok
+}
+
+func indexAcronyms(acronyms map[string]string) map[byte][]string {
+	index := map[byte][]string{}
+	for key := range acronyms {
+		if key != "" {
+			index[key[0]] = append(index[key[0]], key)
+		}
+	}
+
+	for _, keys := range index {
+		sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+	}
+
+	return index
+}
+
+// ToSnakeWithAcronyms converts a string to snake_case, configured acronyms are whole words replaced by their values
+// (see ConfigureAcronym): ConfigureAcronym("PostgreSQL", "postgresql"), PostgreSQLVersion -> postgresql_version
+func ToSnakeWithAcronyms(s string) string {
+	return ToDelimitedWithAcronyms(s, '_', false)
+}
+
+// ToDelimitedWithAcronyms converts a string to delimited.snake.case (SCREAMING.DELIMITED.SNAKE.CASE if screaming),
+// configured acronyms are whole words replaced by their values (see ConfigureAcronym). Acronym is a whole word if it
+// begins after lower case letter, digit or delimiter and ends before end of string, delimiter, digit or begin
+// of next word (upper case letter followed by lower case one): API is acronym of userAPIKey, but not of RAPIDS
+func ToDelimitedWithAcronyms(s string, delimiter uint8, screaming bool) string {
+	s = strings.TrimSpace(s)
+
+	acronymMu.RLock()
+	defer acronymMu.RUnlock()
+
+	words := make([]string, 0, 4)
+	add := func(part string) {
+		if part = strings.Trim(part, " _-."); part != "" {
+			words = append(words, ToScreamingDelimited(part, delimiter, "", screaming))
+		}
+	}
+
+	begin := 0
+	for i := 0; i < len(s); {
+		key := acronymAt(s, i)
+		if key == "" {
+			i++
+			continue
+		}
+
+		add(s[begin:i])
+
+		value := strings.ToLower(uppercaseAcronym[key])
+		if screaming {
+			value = strings.ToUpper(value)
+		}
+
+		words = append(words, value)
+		i += len(key)
+		begin = i
+	}
+
+	add(s[begin:])
+
+	return strings.Join(words, string(delimiter))
+}
+
+// acronymAt - the longest configured acronym which is a whole word at position i of s, or empty string.
+func acronymAt(s string, i int) string {
+	if i > 0 && isUpper(s[i-1]) {
+		return ""
+	}
+
+	for _, key := range acronymsByFirst[s[i]] {
+		if !strings.HasPrefix(s[i:], key) {
+			continue
+		}
+
+		end := i + len(key)
+		if end == len(s) || isDelimiter(s[end]) || isDigit(s[end]) ||
+			(isUpper(s[end]) && end+1 < len(s) && isLower(s[end+1])) {
+			return key
+		}
+	}
+
+	return ""
 }
+
+func isUpper(b byte) bool { return b >= 'A' && b <= 'Z' }
+
+func isLower(b byte) bool { return b >= 'a' && b <= 'z' }
+
+func isDigit(b byte) bool { return b >= '0' && b <= '9' }
+
+func isDelimiter(b byte) bool { return b == ' ' || b == '_' || b == '-' || b == '.' }
@@ -10,7 +10,7 @@ func toCamelInitCase(s string, initCase bool) string {
 	if s == "" {
 		return s
 	}
-	if a, ok := uppercaseAcronym[s]; ok {
+	if a, ok := acronym(s); ok {
 		s = a
 	}
 
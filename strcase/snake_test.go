@@ -44,6 +44,95 @@ func toSnake(tb testing.TB) {
 
 func TestToSnake(t *testing.T) { toSnake(t) }
 
+// restoreAcronyms - restore configured acronyms after test.
+func restoreAcronyms(t *testing.T) {
+	acronymMu.RLock()
+	prev := make(map[string]string, len(uppercaseAcronym))
+	for k, v := range uppercaseAcronym {
+		prev[k] = v
+	}
+	acronymMu.RUnlock()
+
+	t.Cleanup(func() {
+		acronymMu.Lock()
+		defer acronymMu.Unlock()
+
+		uppercaseAcronym = prev
+		acronymsByFirst = indexAcronyms(prev)
+	})
+}
+
+func TestCustomAcronymsToSnake(t *testing.T) {
+	restoreAcronyms(t)
+
+	tests := []struct {
+		name         string
+		acronymKey   string
+		acronymValue string
+		in           string
+		expected     string
+	}{
+		{
+			name:         "PostgreSQL Custom Acronym",
+			acronymKey:   "PostgreSQL",
+			acronymValue: "PostgreSQL",
+			in:           "PostgreSQLVersion",
+			expected:     "postgresql_version",
+		},
+		{
+			name:         "API Custom Acronym",
+			acronymKey:   "API",
+			acronymValue: "api",
+			in:           "userAPIKey",
+			expected:     "user_api_key",
+		},
+		{
+			name:         "Acronym inside of word",
+			acronymKey:   "API",
+			acronymValue: "api",
+			in:           "RAPIDS",
+			expected:     "rapids",
+		},
+		{
+			name:         "Acronym between delimiters",
+			acronymKey:   "API",
+			acronymValue: "api",
+			in:           "user_API key2",
+			expected:     "user_api_key_2",
+		},
+		{
+			name:         "Value of acronym",
+			acronymKey:   "K8s",
+			acronymValue: "Kubernetes",
+			in:           "K8sCluster",
+			expected:     "kubernetes_cluster",
+		},
+		{
+			name:         "Default ID Acronym",
+			acronymKey:   "ID",
+			acronymValue: "id",
+			in:           "IDENTITY",
+			expected:     "identity",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ConfigureAcronym(test.acronymKey, test.acronymValue)
+			if result := ToSnakeWithAcronyms(test.in); result != test.expected {
+				t.Errorf("expected custom acronym result %s, got %s", test.expected, result)
+			}
+		})
+	}
+
+	if result := ToSnake("PostgreSQLVersion"); result != "postgre_sql_version" { // acronyms are opt-in
+		t.Errorf("expected result of ToSnake postgre_sql_version, got %s", result)
+	}
+
+	if result := ToDelimitedWithAcronyms("userAPIKey", '-', true); result != "USER-API-KEY" {
+		t.Errorf("expected screaming result USER-API-KEY, got %s", result)
+	}
+}
+
 func BenchmarkToSnake(b *testing.B) {
 	benchmarkSnakeTest(b, toSnake)
 }